@@ -1,40 +1,49 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/alvarowolfx/gamer-journal-wrapped/src/airtablesql"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/highlights"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/i18n"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/icons"
 	"github.com/alvarowolfx/gamer-journal-wrapped/src/imagegen"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/stats"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/summary"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/yearbook"
 	sqle "github.com/dolthub/go-mysql-server"
 	"github.com/dolthub/go-mysql-server/server"
-	_ "github.com/go-sql-driver/mysql"
-	"github.com/jmoiron/sqlx"
+	"github.com/dolthub/go-mysql-server/sql"
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/mehanizm/airtable"
-	"golang.org/x/sync/errgroup"
 )
 
 var (
-	db           *sqlx.DB
-	serperAPIKey string
+	repo          stats.Repository
+	mysqlPort     int
+	databaseName  string
+	statsFolder   string
+	themesFolder  string
+	fontsFolder   string
+	fallbackFonts string
+	customStats   []stats.Definition
 )
 
-type StatsResponse struct {
-	Year               int                             `json:"year"`
-	MostPlayedConsoles []imagegen.MostPlayedByPlaytime `json:"most_played_consoles"`
-	MostPlayedPlatform []imagegen.MostPlayedByPlaytime `json:"most_played_platforms"`
-	MostPlayedGames    []imagegen.MostPlayedGame       `json:"most_played_games"`
-	MostPlayedSeries   []imagegen.MostPlayedByPlaytime `json:"most_played_series"`
-	GamesByStatus      []imagegen.MostPlayedByNumGames `json:"games_by_status"`
-	BusiestMonths      []imagegen.MostPlayedByPlaytime `json:"busiest_months"`
-}
+type StatsResponse = stats.Report
+
+// playerRangeKey keeps the resolved player in the context of the request.
+const playerRangeKey = "playerRange"
 
 func main() {
 	err := godotenv.Load()
@@ -42,7 +51,6 @@ func main() {
 		log.Printf("failed to read .env: %v \n", err)
 	}
 
-	serperAPIKey = os.Getenv("SERPER_API_KEY")
 	airtableAPIKey := os.Getenv("AIRTABLE_API_KEY")
 	recordCacheTTL := os.Getenv("AIRTABLE_RECORD_CACHE_TTL")
 	recordCacheTTLDuration, err := time.ParseDuration(recordCacheTTL)
@@ -50,39 +58,69 @@ func main() {
 		log.Printf("failed to parse record cache ttl: %v \n", err)
 		recordCacheTTLDuration = 1 * time.Minute
 	}
-	imagegen.LoadFonts()
+	logos, boxArt, err := icons.ConfigFromEnv(imagegen.AssetsFolder).Chains()
+	if err != nil {
+		log.Fatalf("failed to configure icon providers: %v", err)
+	}
+	imagegen.SetIconProviders(logos, boxArt)
+
+	flag.IntVar(&mysqlPort, "mysql-port", 0, "also expose the airtable database as a mysql server on this port (disabled when 0)")
+	flag.StringVar(&databaseName, "database", "gaming_journal", "airtable base to query, in snake case")
+	flag.StringVar(&statsFolder, "stats", "./stats/", "folder with user defined stats")
+	flag.StringVar(&themesFolder, "themes", "./themes/", "folder with user defined chart themes")
+	flag.StringVar(&imagegen.SVGFontsURL, "svg-fonts-url", "", "url SVG charts load their fonts from, followed by the font file name, instead of embedding them")
+	flag.StringVar(&fontsFolder, "fonts", imagegen.DefaultFontsDir, "folder with the fonts of the default theme, and the fallback fonts in its fallback folder")
+	flag.StringVar(&fallbackFonts, "fallback-fonts", "", "comma separated TrueType or OpenType files the characters missing from the theme fonts are taken from, like CJK or emoji fonts")
+	flag.Parse()
+
+	fallbacks := []string{}
+	if fallbackFonts != "" {
+		fallbacks = strings.Split(fallbackFonts, ",")
+	}
+	if err := imagegen.LoadFonts(fontsFolder, fallbacks); err != nil {
+		log.Fatalf("failed to load fonts: %v", err)
+	}
+
+	if err := imagegen.LoadThemes(themesFolder); err != nil {
+		log.Fatalf("failed to load themes: %v", err)
+	}
+
+	customStats, err = stats.LoadDefinitions(statsFolder)
+	if err != nil {
+		log.Fatalf("failed to load user defined stats: %v", err)
+	}
+
+	if airtableAPIKey == "" {
+		log.Fatalf("AIRTABLE_API_KEY not provided")
+	}
 
 	client := airtable.NewClient(airtableAPIKey)
 	provider, err := airtablesql.NewProvider(client, recordCacheTTLDuration)
 	if err != nil {
 		log.Fatalf("failed to init airtable sql provider: %v", err)
 	}
+	if _, err := provider.Database(sql.NewEmptyContext(), databaseName); err != nil {
+		log.Fatalf("failed to load airtable base %q: %v", databaseName, err)
+	}
 
 	engine := sqle.NewDefault(provider)
+	repo = stats.NewEngineRepository(engine, databaseName)
 
-	sqlPort := 3307
-	config := server.Config{
-		Protocol: "tcp",
-		Address:  fmt.Sprintf("localhost:%d", sqlPort),
-	}
-	s, err := server.NewDefaultServer(config, engine)
-	if err != nil {
-		log.Fatalf("failed to create mysql server: %v", err)
-	}
-
-	go func() {
-		if err = s.Start(); err != nil {
-			log.Fatalf("failed to start mysql server: %v", err)
+	if mysqlPort != 0 {
+		config := server.Config{
+			Protocol: "tcp",
+			Address:  fmt.Sprintf("localhost:%d", mysqlPort),
+		}
+		s, err := server.NewDefaultServer(config, engine)
+		if err != nil {
+			log.Fatalf("failed to create mysql server: %v", err)
 		}
-	}()
-
-	// Wait for server to start
-	time.Sleep(2 * time.Second)
 
-	mysqlDSN := fmt.Sprintf("root:@tcp(localhost:%d)/gaming_journal?parseTime=true", sqlPort)
-	db, err = sqlx.Connect("mysql", mysqlDSN)
-	if err != nil {
-		log.Fatalf("failed to connect to internal mysql: %v", err)
+		go func() {
+			if err := s.Start(); err != nil {
+				log.Fatalf("failed to start mysql server: %v", err)
+			}
+		}()
 	}
 
 	e := echo.New()
@@ -90,8 +128,19 @@ func main() {
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
 
+	e.Use(validatePlayer)
+
+	e.GET("/api/themes", handleGetThemes)
+	e.GET("/api/players", handleGetPlayers)
+	e.GET("/api/household", handleGetHousehold)
 	e.GET("/api/stats", handleGetStats)
+	e.GET("/api/stats/by/:dimension", handleGetStatsByDimension)
 	e.GET("/api/charts/:type", handleGetChart)
+	e.GET("/api/charts/by/:dimension", handleGetDimensionChart)
+	e.GET("/api/highlights", handleGetHighlights)
+	e.GET("/api/yearbook.pdf", handleGetYearbook)
+	e.GET("/api/compare", handleGetComparison)
+	e.GET("/api/compare/:type", handleGetComparisonChart)
 
 	// Serve static files from the frontend build
 	e.Use(middleware.StaticWithConfig(middleware.StaticConfig{
@@ -104,143 +153,677 @@ func main() {
 	e.Logger.Fatal(e.Start(":8080"))
 }
 
-func handleGetStats(c echo.Context) error {
-	yearStr := c.QueryParam("year")
-	if yearStr == "" {
-		yearStr = fmt.Sprintf("%d", time.Now().Year())
+// validatePlayer rejects requests for players that are not in the
+// players table, before running any query. The player is resolved once
+// and kept for the ranges of the request.
+func validatePlayer(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		player := c.QueryParam("player")
+		if player == "" {
+			return next(c)
+		}
+		r, err := stats.ResolvePlayer(c.Request().Context(), repo, stats.Range{Player: player})
+		if errors.Is(err, stats.ErrUnknownPlayer) {
+			return c.String(http.StatusNotFound, "Unknown player")
+		}
+		if err != nil {
+			return err
+		}
+		c.Set(playerRangeKey, r)
+		return next(c)
+	}
+}
+
+// playerRange is the range of the player of the request, resolved by
+// validatePlayer, in the given year.
+func playerRange(c echo.Context, year int) stats.Range {
+	if r, ok := c.Get(playerRangeKey).(stats.Range); ok {
+		return r.InYear(year)
+	}
+	return stats.Year(year).ForPlayer(c.QueryParam("player"))
+}
+
+func handleGetThemes(c echo.Context) error {
+	return c.JSON(http.StatusOK, imagegen.ThemeNames())
+}
+
+func handleGetPlayers(c echo.Context) error {
+	players, err := repo.Players(c.Request().Context())
+	if err != nil {
+		return err
 	}
-	year, _ := strconv.Atoi(yearStr)
 
-	stats := StatsResponse{Year: year}
+	return c.JSON(http.StatusOK, players)
+}
 
-	var g errgroup.Group
+func handleGetHousehold(c echo.Context) error {
+	statsRange, err := rangeFromQuery(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid year")
+	}
 
-	g.Go(func() error {
-		return db.Select(&stats.MostPlayedConsoles, imagegen.QueryMostPlayedConsoles, yearStr)
-	})
+	household, err := stats.CollectHousehold(c.Request().Context(), repo, statsRange, customStats...)
+	if err != nil {
+		return err
+	}
 
-	g.Go(func() error {
-		return db.Select(&stats.MostPlayedPlatform, imagegen.QueryMostPlayedPlatforms, yearStr)
-	})
+	return c.JSON(http.StatusOK, household)
+}
 
-	g.Go(func() error {
-		return db.Select(&stats.MostPlayedGames, imagegen.QueryMostPlayedGames, yearStr)
-	})
+func handleGetStats(c echo.Context) error {
+	statsRange, err := rangeFromQuery(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid year")
+	}
 
-	g.Go(func() error {
-		return db.Select(&stats.MostPlayedSeries, imagegen.QueryMostPlayedSeries, yearStr)
-	})
+	report, err := stats.Collect(c.Request().Context(), repo, statsRange, customStats...)
+	if err != nil {
+		return err
+	}
 
-	g.Go(func() error {
-		return db.Select(&stats.GamesByStatus, imagegen.QueryGamesByStatus, yearStr)
-	})
+	return c.JSON(http.StatusOK, report)
+}
 
-	var busiestMonth []imagegen.MostPlayedByPlaytime
-	g.Go(func() error {
-		return db.Select(&busiestMonth, imagegen.QueryBusiestMonths, yearStr)
-	})
+func handleGetStatsByDimension(c echo.Context) error {
+	statsRange, err := rangeFromQuery(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid year")
+	}
+	ctx := c.Request().Context()
 
-	if err := g.Wait(); err != nil {
+	dimension, ok, err := findDimension(c)
+	if err != nil {
 		return err
 	}
+	if !ok {
+		return c.String(http.StatusNotFound, "Unknown dimension")
+	}
 
-	stats.BusiestMonths = make([]imagegen.MostPlayedByPlaytime, len(busiestMonth))
-	for i, d := range busiestMonth {
-		monthNum, _ := strconv.ParseInt(d.Title, 10, 64)
-		d.Title = time.Month(int(monthNum)).String()
-		d.NoIcon = true
-		stats.BusiestMonths[i] = d
+	rows, err := repo.PlaytimeBy(ctx, dimension, statsRange)
+	if err != nil {
+		return err
 	}
 
-	return c.JSON(http.StatusOK, stats)
+	return c.JSON(http.StatusOK, rows)
+}
+
+func handleGetDimensionChart(c echo.Context) error {
+	statsRange, err := rangeFromQuery(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid year")
+	}
+	ctx := c.Request().Context()
+
+	opts, invalid := renderOptionsFromQuery(c)
+	if invalid != "" {
+		return c.String(http.StatusBadRequest, invalid)
+	}
+
+	dimension, ok, err := findDimension(c)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return c.String(http.StatusNotFound, "Unknown dimension")
+	}
+
+	rows, err := repo.PlaytimeBy(ctx, dimension, statsRange)
+	if err != nil {
+		return err
+	}
+	// only consoles, platforms and series have logos worth searching for
+	if !slices.Contains([]string{"consoles", "platforms", "serie"}, dimension.LinkedTable) {
+		for i := range rows {
+			rows[i].NoIcon = true
+		}
+	}
+	data := toBarChartItems(rows)
+	title := opts.Locale.T("Most played %s in %s", strings.ReplaceAll(dimension.ID, "_", " "), statsRange.String())
+	return writeBarChart(c, title, data, min(len(data), 9), opts)
 }
 
 func handleGetChart(c echo.Context) error {
 	chartType := c.Param("type")
-	yearStr := c.QueryParam("year")
-	if yearStr == "" {
-		yearStr = fmt.Sprintf("%d", time.Now().Year())
+	statsRange, err := rangeFromQuery(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid year")
 	}
+	yearStr := statsRange.String()
+	ctx := c.Request().Context()
 
-	orientationStr := c.QueryParam("orientation")
-	orientation := imagegen.Vertical
-	if orientationStr == "horizontal" {
-		orientation = imagegen.Horizontal
+	opts, invalid := renderOptionsFromQuery(c)
+	if invalid != "" {
+		return c.String(http.StatusBadRequest, invalid)
 	}
 
+	l := opts.Locale
 	var title string
 	var data []imagegen.BarChartItem
 	var limit int
+	// chart is set by the charts that are not bar charts
+	var chart imagegen.Chart
 
 	switch chartType {
+	case "status-share":
+		rows, err := repo.GamesByStatus(ctx, statsRange)
+		if err != nil {
+			return err
+		}
+		chart = imagegen.DonutChart{Title: l.T("Games by status in %s", yearStr), Items: toBarChartItems(rows), Label: l.T("games")}
+	case "platform-share":
+		rows, err := repo.MostPlayedPlatforms(ctx, statsRange)
+		if err != nil {
+			return err
+		}
+		chart = imagegen.DonutChart{Title: l.T("Playtime by platform in %s", yearStr), Items: toBarChartItems(rows), Unit: l.T("h"), Label: l.T("played")}
+	case "calendar":
+		games, err := repo.Playthroughs(ctx, statsRange)
+		if err != nil {
+			return err
+		}
+		daily := stats.DailyPlaytime(statsRange.Year, games)
+		chart = imagegen.CalendarHeatmap{Title: l.T("Playtime per day in %s", yearStr), Year: statsRange.Year, Hours: daily}
+	case "cumulative":
+		games, err := repo.Playthroughs(ctx, statsRange)
+		if err != nil {
+			return err
+		}
+		points := stats.CumulativePlaytime(statsRange.Year, stats.DailyPlaytime(statsRange.Year, games), l)
+		chart = imagegen.LineChart{Title: l.T("Hours played over %s", yearStr), Points: points, Unit: l.T("h")}
+	case "timeline":
+		games, err := repo.Playthroughs(ctx, statsRange)
+		if err != nil {
+			return err
+		}
+		chart = imagegen.Timeline{Title: l.T("Playthroughs of %s", yearStr), Year: statsRange.Year, Playthroughs: games}
+	case "summary":
+		s, err := summary.Build(ctx, repo, statsRange, l)
+		if err != nil {
+			return err
+		}
+		chart = s
+	case "collage":
+		order, err := imagegen.ParseCollageOrder(c.QueryParam("order"))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Invalid collage order")
+		}
+		games, err := repo.Playthroughs(ctx, statsRange)
+		if err != nil {
+			return err
+		}
+		chart = imagegen.Collage{
+			Title:        l.T("Games played in %s", yearStr),
+			Playthroughs: games,
+			Order:        order,
+			Captions:     c.QueryParam("captions") == "true",
+			Badges:       c.QueryParam("badges") == "true",
+		}
 	case "consoles":
-		title = "Most played consoles in " + yearStr
-		var rows []imagegen.MostPlayedByPlaytime
-		err := db.Select(&rows, imagegen.QueryMostPlayedConsoles, yearStr)
+		title = l.T("Most played consoles in %s", yearStr)
+		rows, err := repo.MostPlayedConsoles(ctx, statsRange)
 		if err != nil {
 			return err
 		}
 		data = toBarChartItems(rows)
 		limit = len(data)
 	case "platforms":
-		title = "Most played platform in " + yearStr
-		var rows []imagegen.MostPlayedByPlaytime
-		err := db.Select(&rows, imagegen.QueryMostPlayedPlatforms, yearStr)
+		title = l.T("Most played platform in %s", yearStr)
+		rows, err := repo.MostPlayedPlatforms(ctx, statsRange)
 		if err != nil {
 			return err
 		}
 		data = toBarChartItems(rows)
 		limit = 9
 	case "games":
-		title = "Most played games in " + yearStr
-		var rows []imagegen.MostPlayedGame
-		err := db.Select(&rows, imagegen.QueryMostPlayedGames, yearStr)
+		title = l.T("Most played games in %s", yearStr)
+		rows, err := repo.MostPlayedGames(ctx, statsRange)
 		if err != nil {
 			return err
 		}
 		data = toBarChartItems(rows)
 		limit = 8
 	case "series":
-		title = "Most played game serie in " + yearStr
-		var rows []imagegen.MostPlayedByPlaytime
-		err := db.Select(&rows, imagegen.QueryMostPlayedSeries, yearStr)
+		title = l.T("Most played game serie in %s", yearStr)
+		rows, err := repo.MostPlayedSeries(ctx, statsRange)
 		if err != nil {
 			return err
 		}
 		data = toBarChartItems(rows)
 		limit = 8
 	case "status":
-		title = "Games beaten in " + yearStr
-		var rows []imagegen.MostPlayedByNumGames
-		err := db.Select(&rows, imagegen.QueryGamesByStatus, yearStr)
+		title = l.T("Games beaten in %s", yearStr)
+		rows, err := repo.GamesByStatus(ctx, statsRange)
 		if err != nil {
 			return err
 		}
 		data = toBarChartItems(rows)
 		limit = len(data)
 	case "months":
-		title = "Busiest months in " + yearStr
-		var rows []imagegen.MostPlayedByPlaytime
-		err := db.Select(&rows, imagegen.QueryBusiestMonths, yearStr)
+		title = l.T("Busiest months in %s", yearStr)
+		rows, err := repo.BusiestMonths(ctx, statsRange)
 		if err != nil {
 			return err
 		}
-		data = make([]imagegen.BarChartItem, len(rows))
-		for i, d := range rows {
-			monthNum, _ := strconv.ParseInt(d.Title, 10, 64)
-			d.Title = time.Month(int(monthNum)).String()
-			d.NoIcon = true
-			data[i] = d
+		data = toBarChartItems(rows)
+		limit = len(data)
+	case "completion":
+		title = l.T("Completion rate until %s", yearStr)
+		rows, err := repo.CompletionRate(ctx, statsRange)
+		if err != nil {
+			return err
+		}
+		data = toBarChartItems(rows)
+		limit = len(data)
+	case "time-to-beat":
+		title = l.T("Days to finish in %s", yearStr)
+		rows, err := repo.TimeToBeat(ctx, statsRange)
+		if err != nil {
+			return err
 		}
+		data = toBarChartItems(rows)
+		limit = len(data)
+	case "abandoned":
+		title = l.T("Abandoned games by platform in %s", yearStr)
+		rows, err := repo.AbandonmentRate(ctx, statsRange)
+		if err != nil {
+			return err
+		}
+		data = toBarChartItems(rows)
+		limit = 9
+	case "backlog":
+		title = l.T("Backlog size in %s", yearStr)
+		rows, err := repo.Backlog(ctx, statsRange)
+		if err != nil {
+			return err
+		}
+		data = toBarChartItems(rows)
+		limit = len(data)
+	case "highlights":
+		n := 6
+		if opts.Canvas.Portrait() {
+			n = 5
+		}
+		facts, err := highlights.DefaultEngine().Top(ctx, repo, statsRange, l, n)
+		if err != nil {
+			return err
+		}
+		chart = imagegen.FactCards{Title: l.T("Highlights of %s", yearStr), Cards: highlights.Cards(facts)}
+	case "household":
+		household, err := stats.CollectHousehold(ctx, repo, stats.Year(statsRange.Year))
+		if err != nil {
+			return err
+		}
+		chart = imagegen.FactCards{Title: l.T("Household in %s", yearStr), Cards: household.Cards(l)}
+	case "burndown":
+		title = l.T("Backlog burn-down in %s", yearStr)
+		rows, err := repo.Backlog(ctx, statsRange)
+		if err != nil {
+			return err
+		}
+		data = toBarChartItems(stats.BurnDown(rows))
+		limit = len(data)
+	case "ratings-platforms":
+		title = l.T("Best rated platforms in %s", yearStr)
+		rows, err := repo.AverageRatingByPlatform(ctx, statsRange)
+		if err != nil {
+			return err
+		}
+		data = toBarChartItems(rows)
+		limit = 9
+	case "ratings-series":
+		title = l.T("Best rated game series in %s", yearStr)
+		rows, err := repo.AverageRatingBySeries(ctx, statsRange)
+		if err != nil {
+			return err
+		}
+		data = toBarChartItems(rows)
+		limit = 8
+	case "ratings-years":
+		title = l.T("Average rating until %s", yearStr)
+		rows, err := repo.AverageRatingByYear(ctx, statsRange)
+		if err != nil {
+			return err
+		}
+		data = toBarChartItems(rows)
+		limit = len(data)
+	case "best-rated":
+		title = l.T("Best rated games in %s", yearStr)
+		rows, err := repo.BestRatedGames(ctx, statsRange)
+		if err != nil {
+			return err
+		}
+		data = toBarChartItems(rows)
+		limit = 8
+	case "hidden-gems":
+		title = l.T("Hidden gems of %s", yearStr)
+		rows, err := repo.HiddenGems(ctx, statsRange)
+		if err != nil {
+			return err
+		}
+		data = toBarChartItems(rows)
+		limit = 8
+	case "rating-vs-playtime":
+		title = l.T("Playtime by rating in %s", yearStr)
+		correlation, err := repo.RatingVsPlaytime(ctx, statsRange)
+		if err != nil {
+			return err
+		}
+		data = toBarChartItems(correlation.ByRating)
+		limit = len(data)
+	default:
+		def, ok := findCustomStat(chartType)
+		if !ok {
+			return c.String(http.StatusBadRequest, "Invalid chart type")
+		}
+		title = def.RenderTitle(statsRange)
+		rows, err := repo.Custom(ctx, def, statsRange)
+		if err != nil {
+			return err
+		}
+		data = def.ChartItems(rows)
+		limit = def.ChartLimit(len(data))
+	}
+
+	if chart != nil {
+		if c.QueryParam("animate") != "" {
+			return c.String(http.StatusBadRequest, "Only bar charts can be animated")
+		}
+		return writeDrawing(c, chart.Render(ctx, opts))
+	}
+	return writeBarChart(c, title, data, limit, opts)
+}
+
+func handleGetHighlights(c echo.Context) error {
+	statsRange, err := rangeFromQuery(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid year")
+	}
+	limit := 5
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Invalid limit")
+		}
+	}
+
+	l, err := i18n.Parse(c.QueryParam("lang"))
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid language")
+	}
+
+	facts, err := highlights.DefaultEngine().Top(c.Request().Context(), repo, statsRange, l, limit)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, facts)
+}
+
+func handleGetYearbook(c echo.Context) error {
+	statsRange, err := rangeFromQuery(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid year")
+	}
+	opts, invalid := renderOptionsFromQuery(c)
+	if invalid != "" {
+		return c.String(http.StatusBadRequest, invalid)
+	}
+	// the yearbook is printed on A4 pages, unless a canvas was asked for
+	if c.QueryParam("canvas") == "" && c.QueryParam("orientation") == "" {
+		opts.Canvas = imagegen.Canvas{}
+	}
+
+	doc, err := yearbook.Render(c.Request().Context(), repo, statsRange, customStats, opts)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, imagegen.PDF.ContentType())
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("inline; filename=\"yearbook_%s.pdf\"", statsRange))
+	return doc.Encode(c.Response().Writer)
+}
+
+func handleGetComparison(c echo.Context) error {
+	from, to, err := comparisonRangesFromQuery(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid year")
+	}
+
+	comparison, err := stats.Compare(c.Request().Context(), repo, from, to, customStats...)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, comparison)
+}
+
+func handleGetComparisonChart(c echo.Context) error {
+	chartType := c.Param("type")
+	from, to, err := comparisonRangesFromQuery(c)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid year")
+	}
+
+	opts, invalid := renderOptionsFromQuery(c)
+	if invalid != "" {
+		return c.String(http.StatusBadRequest, invalid)
+	}
+
+	comparison, err := stats.Compare(c.Request().Context(), repo, from, to, customStats...)
+	if err != nil {
+		return err
+	}
+
+	l := opts.Locale
+	var title string
+	var data []imagegen.ComparisonItem
+	var limit int
+
+	switch chartType {
+	case "consoles":
+		title = l.T("Most played consoles %s vs %s", from, to)
+		data = comparison.MostPlayedConsoles
+		limit = len(data)
+	case "platforms":
+		title = l.T("Most played platform %s vs %s", from, to)
+		data = comparison.MostPlayedPlatform
+		limit = 9
+	case "games":
+		title = l.T("Most played games %s vs %s", from, to)
+		data = comparison.MostPlayedGames
+		limit = 8
+	case "series":
+		title = l.T("Most played game serie %s vs %s", from, to)
+		data = comparison.MostPlayedSeries
+		limit = 8
+	case "status":
+		title = l.T("Games beaten %s vs %s", from, to)
+		data = comparison.GamesByStatus
+		limit = len(data)
+	case "months":
+		title = l.T("Busiest months %s vs %s", from, to)
+		data = comparison.BusiestMonths
+		limit = len(data)
+	case "time-to-beat":
+		title = l.T("Days to finish %s vs %s", from, to)
+		data = comparison.TimeToBeat
+		limit = len(data)
+	case "abandoned":
+		title = l.T("Abandoned games %s vs %s", from, to)
+		data = comparison.AbandonmentRate
+		limit = 9
+	case "backlog":
+		title = l.T("Backlog size %s vs %s", from, to)
+		data = comparison.Backlog
 		limit = len(data)
 	default:
-		return c.String(http.StatusBadRequest, "Invalid chart type")
+		def, ok := findCustomStat(chartType)
+		if !ok {
+			return c.String(http.StatusBadRequest, "Invalid chart type")
+		}
+		title = def.RenderComparisonTitle(l, from, to)
+		data = comparison.Custom[def.ID]
+		limit = def.ChartLimit(len(data))
+	}
+
+	drawing := imagegen.RenderComparisonWrapped(c.Request().Context(), title, from.String(), to.String(), data, limit, opts)
+
+	return writeDrawing(c, drawing)
+}
+
+// findDimension looks up the dimension param among the link fields
+// currently in the schema, so new fields in Airtable show up without
+// restarting.
+func findDimension(c echo.Context) (stats.Dimension, bool, error) {
+	dimensions, err := repo.Dimensions(c.Request().Context())
+	if err != nil {
+		return stats.Dimension{}, false, err
 	}
+	dimension, ok := stats.FindDimension(dimensions, c.Param("dimension"))
+	return dimension, ok, nil
+}
 
-	drawing := imagegen.RenderMostPlayedWrapped(title, data, limit, orientation, serperAPIKey)
+func findCustomStat(id string) (stats.Definition, bool) {
+	for _, def := range customStats {
+		if def.ID == id {
+			return def, true
+		}
+	}
+	return stats.Definition{}, false
+}
 
-	c.Response().Header().Set(echo.HeaderContentType, "image/png")
-	return drawing.EncodePNG(c.Response().Writer)
+func comparisonRangesFromQuery(c echo.Context) (stats.Range, stats.Range, error) {
+	to := stats.CurrentYear()
+	if toStr := c.QueryParam("to"); toStr != "" {
+		year, err := strconv.Atoi(toStr)
+		if err != nil {
+			return stats.Range{}, stats.Range{}, err
+		}
+		to = stats.Year(year)
+	}
+	from := stats.Year(to.Year - 1)
+	if fromStr := c.QueryParam("from"); fromStr != "" {
+		year, err := strconv.Atoi(fromStr)
+		if err != nil {
+			return stats.Range{}, stats.Range{}, err
+		}
+		from = stats.Year(year)
+	}
+	return playerRange(c, from.Year), playerRange(c, to.Year), nil
+}
+
+// renderOptionsFromQuery reads the canvas, the theme, the format and the
+// language of a chart. When they are invalid it returns the reason instead. The
+// orientation parameter is still read for the clients that predate canvases.
+func renderOptionsFromQuery(c echo.Context) (imagegen.Options, string) {
+	opts := imagegen.Options{Canvas: imagegen.CanvasVertical}
+	if c.QueryParam("orientation") == "horizontal" {
+		opts.Canvas = imagegen.CanvasHorizontal
+	}
+	if spec := c.QueryParam("canvas"); spec != "" {
+		canvas, err := imagegen.ParseCanvas(spec)
+		if err != nil {
+			return opts, "Invalid canvas"
+		}
+		opts.Canvas = canvas
+	}
+	if name := c.QueryParam("theme"); name != "" {
+		theme, ok := imagegen.FindTheme(name)
+		if !ok {
+			return opts, "Unknown theme"
+		}
+		opts.Theme = theme
+	}
+	if f := c.QueryParam("format"); f != "" {
+		format, err := imagegen.ParseFormat(f)
+		if err != nil {
+			return opts, "Invalid format"
+		}
+		opts.Format = format
+	}
+	locale, err := i18n.Parse(c.QueryParam("lang"))
+	if err != nil {
+		return opts, "Invalid language"
+	}
+	opts.Locale = locale
+	return opts, ""
+}
+
+// animationFromQuery reads how to animate a bar chart. Charts are static
+// when the animate param is not set.
+func animationFromQuery(c echo.Context) (imagegen.AnimationFormat, imagegen.Animation, string) {
+	anim := imagegen.DefaultAnimation
+	spec := c.QueryParam("animate")
+	if spec == "" {
+		return "", anim, ""
+	}
+	format, err := imagegen.ParseAnimationFormat(spec)
+	if err != nil || format == imagegen.Frames {
+		return "", anim, "Invalid animation format"
+	}
+	if frames := c.QueryParam("frames"); frames != "" {
+		n, err := strconv.Atoi(frames)
+		if err != nil {
+			return "", anim, "Invalid frames"
+		}
+		anim.Frames = n
+	}
+	if duration := c.QueryParam("duration"); duration != "" {
+		d, err := time.ParseDuration(duration)
+		if err != nil {
+			return "", anim, "Invalid duration"
+		}
+		anim.Duration = d
+	}
+	if hold := c.QueryParam("hold"); hold != "" {
+		d, err := time.ParseDuration(hold)
+		if err != nil {
+			return "", anim, "Invalid hold"
+		}
+		anim.Hold = d
+	}
+	if easing := c.QueryParam("easing"); easing != "" {
+		anim.Easing = imagegen.Easing(easing)
+	}
+	if err := anim.Validate(); err != nil {
+		return "", anim, "Invalid animation: " + err.Error()
+	}
+	return format, anim, ""
+}
+
+// writeBarChart renders the bar chart, animated when asked to.
+func writeBarChart(c echo.Context, title string, data []imagegen.BarChartItem, limit int, opts imagegen.Options) error {
+	ctx := c.Request().Context()
+	format, anim, invalid := animationFromQuery(c)
+	if invalid != "" {
+		return c.String(http.StatusBadRequest, invalid)
+	}
+	if format == "" {
+		return writeDrawing(c, imagegen.RenderMostPlayedWrapped(ctx, title, data, limit, opts))
+	}
+	animated, err := imagegen.AnimateMostPlayedWrapped(ctx, title, data, limit, opts, anim)
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set(echo.HeaderContentType, format.ContentType())
+	return animated.Encode(c.Response().Writer, format)
+}
+
+func writeDrawing(c echo.Context, drawing imagegen.SaveableDrawing) error {
+	c.Response().Header().Set(echo.HeaderContentType, drawing.Format().ContentType())
+	return drawing.Encode(c.Response().Writer)
+}
+
+func rangeFromQuery(c echo.Context) (stats.Range, error) {
+	yearStr := c.QueryParam("year")
+	if yearStr == "" {
+		return playerRange(c, stats.CurrentYear().Year), nil
+	}
+	year, err := strconv.Atoi(yearStr)
+	if err != nil {
+		return stats.Range{}, err
+	}
+	return playerRange(c, year), nil
 }
 
 func toBarChartItems[T imagegen.BarChartItem](arr []T) []imagegen.BarChartItem {
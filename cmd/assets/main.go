@@ -0,0 +1,341 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/icons"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/imagegen"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/stats"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/util"
+	"github.com/joho/godotenv"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+const usage = `usage: assets [-dsn DSN] [-stats FOLDER] <command> [flags] [args]
+
+commands:
+  list -year YEAR [-player NAME] [-missing]   logos and box art used by the stats of a year
+  candidates [-kind KIND] [-save DIR] NAME     images each provider finds for a name
+  pin [-kind KIND] NAME URL|FILE               use this image for a name, never replacing it
+  unpin [-kind KIND] NAME                      allow the image for a name to be replaced again
+  bad [-kind KIND] NAME                        flag the image for a name as a bad match
+  refetch -year YEAR [-player NAME]            look up the missing images for the stats of a year
+  export [FILE]                                write the manifest, to stdout by default
+  import FILE                                  read a manifest, downloading the missing images
+  gc [-start YEAR -end YEAR]                   remove unreferenced files, and the images not used in those years
+
+KIND is logo or box_art, box_art by default.
+`
+
+var (
+	mysqlDSN    = "root:@/gaming_journal?parseTime=true"
+	statsFolder = "./stats/"
+)
+
+func main() {
+	err := godotenv.Load()
+	if err != nil {
+		log.Printf("failed to read .env: %v \n", err)
+	}
+
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.StringVar(&mysqlDSN, "dsn", mysqlDSN, "mysql server with the journal")
+	flag.StringVar(&statsFolder, "stats", statsFolder, "folder with user defined stats")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := icons.ConfigFromEnv(imagegen.AssetsFolder)
+	store, err := cfg.Store()
+	if err != nil {
+		log.Fatalf("failed to open assets store: %v", err)
+	}
+	logos, boxArt, err := cfg.ChainsWithStore(store)
+	if err != nil {
+		log.Fatalf("failed to configure icon providers: %v", err)
+	}
+	imagegen.SetIconProviders(logos, boxArt)
+
+	ctx := context.Background()
+	cmd, args := flag.Arg(0), flag.Args()[1:]
+	switch cmd {
+	case "list":
+		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+		year := fs.Int("year", 0, "year of the stats")
+		player := fs.String("player", "", "only the playthroughs of this player")
+		missing := fs.Bool("missing", false, "only the ones without an image")
+		fs.Parse(args)
+		refs := collectIcons(ctx, stats.Year(*year).ForPlayer(*player))
+		list(store, refs, *missing)
+	case "candidates":
+		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+		kind := kindFlag(fs)
+		save := fs.String("save", "", "folder to save the candidates to")
+		fs.Parse(args)
+		chain := boxArt
+		if *kind == icons.Logo {
+			chain = logos
+		}
+		candidates(ctx, chain, store, nameArg(fs, 1), *kind, *save)
+	case "pin":
+		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+		kind := kindFlag(fs)
+		fs.Parse(args)
+		if fs.NArg() != 2 {
+			log.Fatalf("usage: assets pin [-kind KIND] NAME URL|FILE")
+		}
+		pin(ctx, store, fs.Arg(0), *kind, fs.Arg(1))
+	case "unpin":
+		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+		kind := kindFlag(fs)
+		fs.Parse(args)
+		name := nameArg(fs, 1)
+		if err := store.SetPinned(name, *kind, false); err != nil {
+			log.Fatalf("failed to unpin %s for %s: %v", *kind, name, err)
+		}
+	case "bad":
+		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+		kind := kindFlag(fs)
+		fs.Parse(args)
+		name := nameArg(fs, 1)
+		if err := store.MarkBadMatch(name, *kind); err != nil {
+			log.Fatalf("failed to flag %s for %s: %v", *kind, name, err)
+		}
+		fmt.Println("flagged", *kind, "for", name, "as a bad match, run refetch to look for another one")
+	case "refetch":
+		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+		year := fs.Int("year", 0, "year of the stats")
+		player := fs.String("player", "", "only the playthroughs of this player")
+		fs.Parse(args)
+		refs := collectIcons(ctx, stats.Year(*year).ForPlayer(*player))
+		refetch(ctx, store, refs)
+	case "export":
+		out := os.Stdout
+		if len(args) > 0 {
+			out, err = os.Create(args[0])
+			if err != nil {
+				log.Fatalf("failed to create %s: %v", args[0], err)
+			}
+			defer out.Close()
+		}
+		if err := store.Export(out); err != nil {
+			log.Fatalf("failed to export assets manifest: %v", err)
+		}
+	case "import":
+		if len(args) != 1 {
+			log.Fatalf("usage: assets import FILE")
+		}
+		in, err := os.Open(args[0])
+		if err != nil {
+			log.Fatalf("failed to open %s: %v", args[0], err)
+		}
+		defer in.Close()
+		skipped, err := store.Import(ctx, in)
+		if err != nil {
+			log.Fatalf("failed to import assets manifest: %v", err)
+		}
+		for _, name := range skipped {
+			fmt.Println("skipped", name)
+		}
+	case "gc":
+		fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+		start := fs.Int("start", 0, "first year of the stats to keep the images of")
+		end := fs.Int("end", 0, "last year of the stats to keep the images of")
+		fs.Parse(args)
+		var keep func(icons.Asset) bool
+		if *start > 0 && *end >= *start {
+			used := map[string]bool{}
+			for year := *start; year <= *end; year++ {
+				for _, ref := range collectIcons(ctx, stats.Year(year)) {
+					used[assetKey(ref.Name, kindOf(ref))] = true
+				}
+			}
+			keep = func(a icons.Asset) bool {
+				kind, err := icons.ParseKind(a.Kind)
+				return err != nil || used[assetKey(a.Name, kind)]
+			}
+		}
+		removed, err := store.GC(keep)
+		if err != nil {
+			log.Fatalf("failed to clean up assets: %v", err)
+		}
+		for _, file := range removed {
+			fmt.Println("removed", file)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func kindFlag(fs *flag.FlagSet) *icons.Kind {
+	kind := icons.BoxArt
+	fs.Func("kind", "logo or box_art (default box_art)", func(s string) (err error) {
+		kind, err = icons.ParseKind(s)
+		return err
+	})
+	return &kind
+}
+
+func nameArg(fs *flag.FlagSet, n int) string {
+	if fs.NArg() != n {
+		log.Fatalf("usage: assets %s [-kind KIND] NAME", fs.Name())
+	}
+	return fs.Arg(0)
+}
+
+func kindOf(ref imagegen.IconRef) icons.Kind {
+	if ref.BoxArt {
+		return icons.BoxArt
+	}
+	return icons.Logo
+}
+
+func assetKey(name string, kind icons.Kind) string {
+	return kind.Slug() + "/" + util.ToSnakecase(name)
+}
+
+func collectIcons(ctx context.Context, rg stats.Range) []imagegen.IconRef {
+	if rg.Year == 0 {
+		log.Fatalf("missing -year")
+	}
+	db, err := sqlx.Connect("mysql", mysqlDSN)
+	if err != nil {
+		log.Fatal(err)
+	}
+	customStats, err := stats.LoadDefinitions(statsFolder)
+	if err != nil {
+		log.Fatalf("failed to load user defined stats: %v", err)
+	}
+	report, err := stats.Collect(ctx, stats.NewSQLRepository(db), rg, customStats...)
+	if err != nil {
+		log.Fatalf("failed to query stats for %s: %v", rg, err)
+	}
+	return report.Icons(customStats...)
+}
+
+func list(store *icons.Store, refs []imagegen.IconRef, missingOnly bool) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tSTATUS\tPROVIDER\tFETCHED\tSOURCE")
+	for _, ref := range refs {
+		kind := kindOf(ref)
+		asset, ok, err := store.Asset(ref.Name, kind)
+		if err != nil {
+			log.Fatalf("failed to read assets manifest: %v", err)
+		}
+		status := "ok"
+		switch {
+		case !ok && store.Missed(ref.Name, kind):
+			status = "not found"
+		case !ok:
+			status = "missing"
+		case asset.BadMatch:
+			status = "bad match"
+		case asset.Pinned:
+			status = "pinned"
+		}
+		if missingOnly && ok && !asset.BadMatch {
+			continue
+		}
+		fetched := ""
+		if ok {
+			fetched = asset.FetchedAt.Format("2006-01-02")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", kind.Slug(), ref.Name, status, asset.Provider, fetched, asset.SourceURL)
+	}
+	w.Flush()
+}
+
+func candidates(ctx context.Context, chain *icons.Chain, store *icons.Store, name string, kind icons.Kind, saveFolder string) {
+	if saveFolder != "" {
+		if err := os.MkdirAll(saveFolder, 0755); err != nil {
+			log.Fatalf("failed to create %s: %v", saveFolder, err)
+		}
+	}
+	asked := 0
+	for _, l := range chain.Links() {
+		if l.Provider.Name() == store.Name() {
+			continue
+		}
+		asked++
+		lookupCtx, cancel := ctx, context.CancelFunc(func() {})
+		if l.Timeout > 0 {
+			lookupCtx, cancel = context.WithTimeout(ctx, l.Timeout)
+		}
+		icon, err := l.Provider.FindIcon(lookupCtx, name, kind)
+		cancel()
+		if err != nil {
+			fmt.Printf("%s: %v\n", l.Provider.Name(), err)
+			continue
+		}
+		size := "unknown size"
+		if cfg, _, err := image.DecodeConfig(bytes.NewReader(icon.Data)); err == nil {
+			size = fmt.Sprintf("%dx%d", cfg.Width, cfg.Height)
+		}
+		fmt.Printf("%s: %s (%s, %s)\n", l.Provider.Name(), icon.SourceURL, http.DetectContentType(icon.Data), size)
+		if saveFolder != "" {
+			file := filepath.Join(saveFolder, util.ToSnakecase(name)+"."+l.Provider.Name())
+			if err := os.WriteFile(file, icon.Data, 0644); err != nil {
+				log.Fatalf("failed to save candidate: %v", err)
+			}
+		}
+	}
+	if asked == 0 {
+		fmt.Println("no remote providers configured for", kind)
+	}
+}
+
+func pin(ctx context.Context, store *icons.Store, name string, kind icons.Kind, source string) {
+	var icon icons.Icon
+	var err error
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		icon, err = icons.Download(ctx, source)
+	} else {
+		icon.Data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		log.Fatalf("failed to read %s: %v", source, err)
+	}
+	if _, _, err := image.DecodeConfig(bytes.NewReader(icon.Data)); err != nil {
+		log.Fatalf("failed to decode %s: %v", source, err)
+	}
+	if err := store.Pin(name, kind, icon, "manual"); err != nil {
+		log.Fatalf("failed to pin %s for %s: %v", kind, name, err)
+	}
+	fmt.Println("pinned", kind, "for", name)
+}
+
+func refetch(ctx context.Context, store *icons.Store, refs []imagegen.IconRef) {
+	for _, ref := range refs {
+		kind := kindOf(ref)
+		asset, ok, err := store.Asset(ref.Name, kind)
+		if err != nil {
+			log.Fatalf("failed to read assets manifest: %v", err)
+		}
+		if ok && !asset.BadMatch {
+			continue
+		}
+		if err := store.Forget(ref.Name, kind); err != nil {
+			log.Fatalf("failed to update assets manifest: %v", err)
+		}
+		if _, err := imagegen.LoadIconForName(ctx, ref.Name, ref.BoxArt); err != nil {
+			fmt.Println("failed to fetch", kind, "for", ref.Name, err)
+			continue
+		}
+		fmt.Println("fetched", kind, "for", ref.Name)
+	}
+}
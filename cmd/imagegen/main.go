@@ -1,15 +1,22 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
-	"strconv"
-	"time"
+	"path/filepath"
+	"strings"
 
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/highlights"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/i18n"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/icons"
 	"github.com/alvarowolfx/gamer-journal-wrapped/src/imagegen"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/stats"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/summary"
 	"github.com/alvarowolfx/gamer-journal-wrapped/src/util"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/yearbook"
 	"github.com/joho/godotenv"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -21,11 +28,32 @@ const (
 )
 
 var (
-	mysqlDSN     = "root:@/gaming_journal?parseTime=true"
-	serperAPIKey string
-	startYear    int
-	endYear      int
-	outFolder    = "./out/"
+	mysqlDSN      = "root:@/gaming_journal?parseTime=true"
+	startYear     int
+	endYear       int
+	outFolder     = "./out/"
+	statsFolder   string
+	compare       bool
+	player        string
+	allPlayers    bool
+	themeName     string
+	themeFolder   string
+	theme         *imagegen.Theme
+	canvasSpecs   string
+	canvases      []imagegen.Canvas
+	formatName    string
+	format        imagegen.Format
+	pdf           bool
+	animateName   string
+	animate       imagegen.AnimationFormat
+	animation     imagegen.Animation
+	easingName    string
+	orderName     string
+	collage       imagegen.Collage
+	fontsFolder   string
+	fallbackFonts string
+	lang          string
+	locale        *i18n.Locale
 )
 
 func main() {
@@ -34,8 +62,11 @@ func main() {
 		log.Printf("failed to read .env: %v \n", err)
 	}
 
-	serperAPIKey = os.Getenv("SERPER_API_KEY")
-	imagegen.LoadFonts()
+	logos, boxArt, err := icons.ConfigFromEnv(imagegen.AssetsFolder).Chains()
+	if err != nil {
+		log.Fatalf("failed to configure icon providers: %v", err)
+	}
+	imagegen.SetIconProviders(logos, boxArt)
 
 	db, err := sqlx.Connect("mysql", mysqlDSN)
 	if err != nil {
@@ -45,81 +76,298 @@ func main() {
 	flag.IntVar(&startYear, "start", 2021, "start year to render gamer wrapped")
 	flag.IntVar(&endYear, "end", 2025, "end year to render gamer wrapped")
 	flag.StringVar(&outFolder, "out", "./out/", "output folder")
+	flag.StringVar(&statsFolder, "stats", "./stats/", "folder with user defined stats")
+	flag.BoolVar(&compare, "compare", false, "render year over year comparisons between consecutive years instead")
+	flag.StringVar(&player, "player", "", "render only the playthroughs of this player")
+	flag.BoolVar(&allPlayers, "players", false, "also render every player in its own output folder, plus a household comparison")
+	flag.StringVar(&themeName, "theme", "default", "theme to render with, by name or as a path to a JSON or YAML theme file")
+	flag.StringVar(&themeFolder, "themes", "./themes/", "folder with user defined themes")
+	flag.StringVar(&canvasSpecs, "canvas", "vertical,horizontal", "comma separated canvases to render, as presets ("+strings.Join(imagegen.CanvasNames(), ", ")+") or sizes like 1600x900")
+	flag.StringVar(&formatName, "format", "png", "file format of the charts, png, svg or pdf")
+	flag.BoolVar(&pdf, "pdf", false, "render a PDF yearbook for each year instead, with every chart, the highlights and the games played")
+	flag.StringVar(&animateName, "animate", "", "render the bar charts animated instead, as gif, apng or frames (a folder of PNG files)")
+	flag.IntVar(&animation.Frames, "frames", imagegen.DefaultAnimation.Frames, "frames it takes for animated charts to build up")
+	flag.DurationVar(&animation.Duration, "duration", imagegen.DefaultAnimation.Duration, "time it takes for animated charts to build up")
+	flag.DurationVar(&animation.Hold, "hold", imagegen.DefaultAnimation.Hold, "time the complete animated chart is shown before looping")
+	flag.StringVar(&easingName, "easing", string(imagegen.DefaultAnimation.Easing), "easing of animated charts, linear, ease-in, ease-out, ease-in-out or ease-out-back")
+	flag.StringVar(&orderName, "collage-order", string(imagegen.CollageByPlaytime), "order of the covers in the collage, playtime (the most played larger) or completion")
+	flag.BoolVar(&collage.Captions, "captions", false, "write the title and playtime over the covers in the collage")
+	flag.BoolVar(&collage.Badges, "badges", false, "mark the status of the games in the collage")
+	flag.StringVar(&fontsFolder, "fonts", imagegen.DefaultFontsDir, "folder with the fonts of the default theme, and the fallback fonts in its fallback folder")
+	flag.StringVar(&fallbackFonts, "fallback-fonts", "", "comma separated TrueType or OpenType files the characters missing from the theme fonts are taken from, like CJK or emoji fonts")
+	flag.StringVar(&lang, "lang", "en", "language of the charts, "+strings.Join(i18n.Tags(), ", "))
 	flag.Parse()
 
-	for year := startYear; year <= endYear; year++ {
-		yearStr := fmt.Sprintf("%d", year)
-		fmt.Println("Rendering wrapped for", yearStr)
+	fallbacks := []string{}
+	if fallbackFonts != "" {
+		fallbacks = strings.Split(fallbackFonts, ",")
+	}
+	if err := imagegen.LoadFonts(fontsFolder, fallbacks); err != nil {
+		log.Fatalf("failed to load fonts: %v", err)
+	}
+
+	locale, err = i18n.Parse(lang)
+	if err != nil {
+		log.Fatalf("failed to parse language: %v", err)
+	}
+
+	format, err = imagegen.ParseFormat(formatName)
+	if err != nil {
+		log.Fatalf("failed to parse format: %v", err)
+	}
+
+	collage.Order, err = imagegen.ParseCollageOrder(orderName)
+	if err != nil {
+		log.Fatalf("failed to parse collage order: %v", err)
+	}
 
-		mostPlayedConsoles := []imagegen.MostPlayedByPlaytime{}
-		err = db.Select(&mostPlayedConsoles, imagegen.QueryMostPlayedConsoles, yearStr)
+	if animateName != "" {
+		animate, err = imagegen.ParseAnimationFormat(animateName)
 		if err != nil {
-			log.Fatalf("failed to query most played console: %v", err)
+			log.Fatalf("failed to parse animation format: %v", err)
 		}
-		renderAndSaveAllMostPlayedWrapped("Most played consoles in "+yearStr, mostPlayedConsoles)
-
-		mostPlayedPlatform := []imagegen.MostPlayedByPlaytime{}
-		err = db.Select(&mostPlayedPlatform, imagegen.QueryMostPlayedPlatforms, yearStr)
+		animation.Easing, err = imagegen.ParseEasing(easingName)
 		if err != nil {
-			log.Fatalf("failed to query most played platform: %v", err)
+			log.Fatalf("failed to parse easing: %v", err)
+		}
+		if err := animation.Validate(); err != nil {
+			log.Fatalf("invalid animation: %v", err)
 		}
-		renderAndSaveNMostPlayedWrapped("Most played platform in "+yearStr, mostPlayedPlatform, 9)
+	}
 
-		mostPlayedGames := []imagegen.MostPlayedGame{}
-		err = db.Select(&mostPlayedGames, imagegen.QueryMostPlayedGames, yearStr)
+	for _, spec := range strings.Split(canvasSpecs, ",") {
+		canvas, err := imagegen.ParseCanvas(spec)
 		if err != nil {
-			log.Fatalf("failed to query most played games: %v", err)
+			log.Fatalf("failed to parse canvas: %v", err)
 		}
-		renderAndSaveNMostPlayedWrapped("Most played games in "+yearStr, mostPlayedGames, 8)
+		canvases = append(canvases, canvas)
+	}
 
-		mostPlayedGameSerie := []imagegen.MostPlayedByPlaytime{}
-		err = db.Select(&mostPlayedGameSerie, imagegen.QueryMostPlayedSeries, yearStr)
-		if err != nil {
-			log.Fatalf("failed to query most played game serie: %v", err)
+	if err := imagegen.LoadThemes(themeFolder); err != nil {
+		log.Fatalf("failed to load themes: %v", err)
+	}
+	theme, err = findTheme(themeName)
+	if err != nil {
+		log.Fatalf("failed to load theme: %v", err)
+	}
+
+	customStats, err := stats.LoadDefinitions(statsFolder)
+	if err != nil {
+		log.Fatalf("failed to load user defined stats: %v", err)
+	}
+
+	repo := stats.NewSQLRepository(db)
+	ctx := context.Background()
+
+	// the player is looked up once for every year
+	playerRange, err := stats.ResolvePlayer(ctx, repo, stats.Range{Player: player})
+	if err != nil {
+		log.Fatalf("failed to find player %s: %v", player, err)
+	}
+
+	if compare {
+		for year := startYear + 1; year <= endYear; year++ {
+			from, to := playerRange.InYear(year-1), playerRange.InYear(year)
+			fmt.Println("Rendering comparison for", from, "vs", to)
+
+			comparison, err := stats.Compare(ctx, repo, from, to, customStats...)
+			if err != nil {
+				log.Fatalf("failed to compare stats for %s vs %s: %v", from, to, err)
+			}
+
+			renderAndSaveComparison(ctx, outFolder, locale.T("Most played consoles %s vs %s", from, to), from, to, comparison.MostPlayedConsoles, len(comparison.MostPlayedConsoles))
+			renderAndSaveComparison(ctx, outFolder, locale.T("Most played platform %s vs %s", from, to), from, to, comparison.MostPlayedPlatform, 9)
+			renderAndSaveComparison(ctx, outFolder, locale.T("Most played games %s vs %s", from, to), from, to, comparison.MostPlayedGames, 8)
+			renderAndSaveComparison(ctx, outFolder, locale.T("Most played game serie %s vs %s", from, to), from, to, comparison.MostPlayedSeries, 8)
+			renderAndSaveComparison(ctx, outFolder, locale.T("Games beaten %s vs %s", from, to), from, to, comparison.GamesByStatus, len(comparison.GamesByStatus))
+			renderAndSaveComparison(ctx, outFolder, locale.T("Busiest months %s vs %s", from, to), from, to, comparison.BusiestMonths, len(comparison.BusiestMonths))
+			renderAndSaveComparison(ctx, outFolder, locale.T("Days to finish %s vs %s", from, to), from, to, comparison.TimeToBeat, len(comparison.TimeToBeat))
+			renderAndSaveComparison(ctx, outFolder, locale.T("Abandoned games %s vs %s", from, to), from, to, comparison.AbandonmentRate, 9)
+			renderAndSaveComparison(ctx, outFolder, locale.T("Backlog size %s vs %s", from, to), from, to, comparison.Backlog, len(comparison.Backlog))
+			for _, def := range customStats {
+				data := comparison.Custom[def.ID]
+				renderAndSaveComparison(ctx, outFolder, def.RenderComparisonTitle(locale, from, to), from, to, data, def.ChartLimit(len(data)))
+			}
 		}
-		renderAndSaveNMostPlayedWrapped("Most played game serie in "+yearStr, mostPlayedGameSerie, 8)
+		return
+	}
 
-		gamesByStatus := []imagegen.MostPlayedByNumGames{}
-		err = db.Select(&gamesByStatus, imagegen.QueryGamesByStatus, yearStr)
+	players := []stats.Player{}
+	playerRanges := []stats.Range{}
+	if allPlayers {
+		players, err = repo.Players(ctx)
 		if err != nil {
-			log.Fatalf("failed to query most played games: %v", err)
+			log.Fatalf("failed to list players: %v", err)
+		}
+		for _, p := range players {
+			r, err := stats.ResolvePlayer(ctx, repo, stats.Range{Player: p.Name})
+			if err != nil {
+				log.Fatalf("failed to find player %s: %v", p.Name, err)
+			}
+			playerRanges = append(playerRanges, r)
+		}
+	}
+
+	for year := startYear; year <= endYear; year++ {
+		statsRange := playerRange.InYear(year)
+		if pdf {
+			renderYearbook(ctx, repo, statsRange, customStats, outFolder)
+		} else {
+			renderWrapped(ctx, repo, statsRange, customStats, outFolder)
+		}
+
+		if len(players) == 0 {
+			continue
+		}
+		for i, p := range players {
+			folder := filepath.Join(outFolder, util.ToSnakecase(p.Name))
+			if err := os.MkdirAll(folder, 0755); err != nil {
+				log.Fatalf("failed to create output folder for %s: %v", p.Name, err)
+			}
+			if pdf {
+				renderYearbook(ctx, repo, playerRanges[i].InYear(year), customStats, folder)
+			} else {
+				renderWrapped(ctx, repo, playerRanges[i].InYear(year), customStats, folder)
+			}
+		}
+		if pdf {
+			continue
 		}
-		renderAndSaveAllMostPlayedWrapped("Games beaten in "+yearStr, gamesByStatus)
 
-		busiestMonth := []imagegen.MostPlayedByPlaytime{}
-		err = db.Select(&busiestMonth, imagegen.QueryBusiestMonths, yearStr)
+		household, err := stats.CollectHousehold(ctx, repo, stats.Year(year))
 		if err != nil {
-			log.Fatalf("failed to query busiest month: %v", err)
+			log.Fatalf("failed to query household stats for %d: %v", year, err)
 		}
-		busiestMonthData := make([]imagegen.BarChartItem, len(busiestMonth))
-		for i, d := range busiestMonth {
-			monthNum, _ := strconv.ParseInt(d.Title, 10, 64)
-			d.Title = time.Month(int(monthNum)).String()
-			d.NoIcon = true
-			busiestMonthData[i] = d
+		renderAndSaveFactCards(ctx, outFolder, locale.T("Household in %s", stats.Year(year)), household.Cards(locale))
+	}
+}
+
+func renderWrapped(ctx context.Context, repo stats.Repository, statsRange stats.Range, customStats []stats.Definition, folder string) {
+	yearStr := statsRange.String()
+	if statsRange.Player != "" {
+		fmt.Println("Rendering wrapped for", yearStr, "of", statsRange.Player)
+	} else {
+		fmt.Println("Rendering wrapped for", yearStr)
+	}
+
+	report, err := stats.Collect(ctx, repo, statsRange, customStats...)
+	if err != nil {
+		log.Fatalf("failed to query stats for %s: %v", yearStr, err)
+	}
+
+	for _, chart := range report.Charts(statsRange, locale, customStats...) {
+		renderAndSaveChart(ctx, folder, chart.Title, chart.Items, chart.Limit)
+	}
+
+	facts, err := highlights.DefaultEngine().Top(ctx, repo, statsRange, locale, 0)
+	if err != nil {
+		log.Fatalf("failed to compute highlights for %s: %v", yearStr, err)
+	}
+	renderAndSaveFactCards(ctx, folder, locale.T("Highlights of %s", yearStr), highlights.Cards(facts))
+
+	games, err := repo.Playthroughs(ctx, statsRange)
+	if err != nil {
+		log.Fatalf("failed to query playthroughs for %s: %v", yearStr, err)
+	}
+	renderAndSaveCollage(ctx, folder, locale.T("Games played in %s", yearStr), games)
+
+	s, err := summary.Build(ctx, repo, statsRange, locale)
+	if err != nil {
+		log.Fatalf("failed to collect summary for %s: %v", yearStr, err)
+	}
+	for _, canvas := range canvases {
+		s.Render(ctx, imagegen.Options{Canvas: canvas, Theme: theme, Format: format, Locale: locale}).
+			Save(chartFile(folder, canvas, s.Title))
+	}
+}
+
+// renderYearbook prints the yearbook on the first canvas given, when it was
+// set, or on A4 pages.
+func renderYearbook(ctx context.Context, repo stats.Repository, statsRange stats.Range, customStats []stats.Definition, folder string) {
+	yearStr := statsRange.String()
+	if statsRange.Player != "" {
+		fmt.Println("Rendering yearbook for", yearStr, "of", statsRange.Player)
+	} else {
+		fmt.Println("Rendering yearbook for", yearStr)
+	}
+
+	opts := imagegen.Options{Theme: theme, Locale: locale}
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "canvas" {
+			opts.Canvas = canvases[0]
 		}
-		renderAndSaveAllMostPlayedWrapped("Busiest months in "+yearStr, busiestMonthData)
+	})
+	doc, err := yearbook.Render(ctx, repo, statsRange, customStats, opts)
+	if err != nil {
+		log.Fatalf("failed to render yearbook for %s: %v", yearStr, err)
+	}
+	if err := doc.Save(fmt.Sprintf("%s/yearbook_%s.pdf", folder, yearStr)); err != nil {
+		log.Fatalf("failed to save yearbook for %s: %v", yearStr, err)
 	}
 }
 
-func renderAndSaveNMostPlayedWrapped[T imagegen.BarChartItem](title string, data []T, n int) {
-	for _, orientation := range []imagegen.Orientation{imagegen.Vertical, imagegen.Horizontal} {
-		imagegen.RenderMostPlayedWrapped(title, toBarChartItems(data), n, orientation, serperAPIKey).
-			SavePNG(fmt.Sprintf("%s/%s_%s.png", outFolder, orientation.String(), util.ToSnakecase(title)))
+func renderAndSaveChart(ctx context.Context, folder, title string, data []imagegen.BarChartItem, n int) {
+	for _, canvas := range canvases {
+		if animate != "" {
+			renderAndSaveAnimation(ctx, folder, canvas, title, data, n)
+			continue
+		}
+		imagegen.RenderMostPlayedWrapped(ctx, title, data, n, imagegen.Options{Canvas: canvas, Theme: theme, Format: format, Locale: locale}).
+			Save(chartFile(folder, canvas, title))
 	}
 }
 
-func renderAndSaveAllMostPlayedWrapped[T imagegen.BarChartItem](title string, data []T) {
-	for _, orientation := range []imagegen.Orientation{imagegen.Vertical, imagegen.Horizontal} {
-		imagegen.RenderMostPlayedWrapped(title, toBarChartItems(data), len(data), orientation, serperAPIKey).
-			SavePNG(fmt.Sprintf("%s/%s_%s.png", outFolder, orientation.String(), util.ToSnakecase(title)))
+func renderAndSaveAnimation(ctx context.Context, folder string, canvas imagegen.Canvas, title string, data []imagegen.BarChartItem, n int) {
+	animated, err := imagegen.AnimateMostPlayedWrapped(ctx, title, data, n, imagegen.Options{Canvas: canvas, Theme: theme, Locale: locale}, animation)
+	if err != nil {
+		log.Fatalf("failed to animate chart %s: %v", title, err)
+	}
+	path := fmt.Sprintf("%s/%s_%s%s", folder, canvas.String(), util.ToSnakecase(title), animate.Ext())
+	if err := animated.Save(path, animate); err != nil {
+		log.Fatalf("failed to save animated chart %s: %v", title, err)
 	}
 }
 
-func toBarChartItems[T imagegen.BarChartItem](arr []T) []imagegen.BarChartItem {
-	narr := make([]imagegen.BarChartItem, len(arr))
-	for i, d := range arr {
-		narr[i] = d
+func renderAndSaveFactCards(ctx context.Context, folder, title string, cards []imagegen.FactCard) {
+	for _, canvas := range canvases {
+		n := 6
+		if canvas.Portrait() {
+			n = 5
+		}
+		imagegen.RenderFactCards(ctx, title, cards[:min(n, len(cards))], imagegen.Options{Canvas: canvas, Theme: theme, Format: format, Locale: locale}).
+			Save(chartFile(folder, canvas, title))
+	}
+}
+
+func renderAndSaveCollage(ctx context.Context, folder, title string, games []imagegen.Playthrough) {
+	c := collage
+	c.Title, c.Playthroughs = title, games
+	for _, canvas := range canvases {
+		c.Render(ctx, imagegen.Options{Canvas: canvas, Theme: theme, Format: format, Locale: locale}).
+			Save(chartFile(folder, canvas, title))
+	}
+}
+
+func renderAndSaveComparison(ctx context.Context, folder, title string, from, to stats.Range, data []imagegen.ComparisonItem, n int) {
+	for _, canvas := range canvases {
+		imagegen.RenderComparisonWrapped(ctx, title, from.String(), to.String(), data, n, imagegen.Options{Canvas: canvas, Theme: theme, Format: format, Locale: locale}).
+			Save(chartFile(folder, canvas, title))
+	}
+}
+
+func chartFile(folder string, canvas imagegen.Canvas, title string) string {
+	return fmt.Sprintf("%s/%s_%s.%s", folder, canvas.String(), util.ToSnakecase(title), format)
+}
+
+// findTheme looks up a theme by name, or loads it from a file when the name
+// is a path to one.
+func findTheme(name string) (*imagegen.Theme, error) {
+	if _, err := os.Stat(name); err == nil {
+		return imagegen.LoadTheme(name)
+	}
+	theme, ok := imagegen.FindTheme(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown theme %q, available themes are %s", name, strings.Join(imagegen.ThemeNames(), ", "))
 	}
-	return narr
+	return theme, nil
 }
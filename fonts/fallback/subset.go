@@ -0,0 +1,1093 @@
+//go:build ignore
+
+// Subset makes the fallback fonts in this folder from the Noto fonts,
+// keeping the characters likely to show up in game titles:
+//
+//	go run fonts/fallback/subset.go -sans NotoSans-Regular.ttf -cjk NotoSansCJK-Bold.ttc -emoji NotoColorEmoji.ttf
+//
+// Every font is written as TrueType, which freetype and the PDF export can
+// read, so the CFF outlines of Noto Sans CJK are converted to quadratic
+// curves. Noto Color Emoji only has bitmaps, so each emoji is traced into a
+// single color outline, with the borders between its colors cut out.
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// The Latin, Greek and Cyrillic letters with their accents, and the
+// punctuation and symbols around them.
+var latinRanges = [][2]rune{
+	{0x00a0, 0x036f}, {0x0370, 0x03ff}, {0x0400, 0x04ff}, {0x1e00, 0x1eff},
+	{0x2000, 0x206f}, {0x20a0, 0x20cf}, {0x2100, 0x214f}, {0x2c60, 0x2c7f},
+	{0xa720, 0xa7ff},
+}
+
+// The Japanese punctuation, kana and full width forms, with the first level
+// kanji of JIS X 0208 listed by kanji.
+var cjkRanges = [][2]rune{
+	{0x3000, 0x303f}, {0x3040, 0x309f}, {0x30a0, 0x30ff}, {0x31f0, 0x31ff},
+	{0xff00, 0xffef},
+}
+
+// The emoji of the symbols, pictographs, emoticons, transport and
+// supplemental blocks. The ones also used as text, like © and ™, are left
+// to the other fonts.
+var emojiRanges = [][2]rune{
+	{0x2600, 0x27bf}, {0x2b00, 0x2bff}, {0x1f300, 0x1f5ff}, {0x1f600, 0x1f64f},
+	{0x1f680, 0x1f6ff}, {0x1f900, 0x1f9ff}, {0x1fa70, 0x1faff},
+}
+
+func main() {
+	sans := flag.String("sans", "", "Noto Sans font file")
+	cjk := flag.String("cjk", "", "Noto Sans CJK font collection")
+	emoji := flag.String("emoji", "", "Noto Color Emoji font file")
+	out := flag.String("out", "fonts/fallback", "folder to write the fonts to")
+	flag.Parse()
+
+	if *sans != "" {
+		if err := subsetOutlines(*sans, "Noto Sans", runes(latinRanges), filepath.Join(*out, "NotoSans-Regular-Subset.ttf")); err != nil {
+			log.Fatalf("failed to subset %s: %v", *sans, err)
+		}
+	}
+	if *cjk != "" {
+		if err := subsetOutlines(*cjk, "Noto Sans CJK JP", append(runes(cjkRanges), kanji()...), filepath.Join(*out, "NotoSansCJKjp-Bold-Subset.ttf")); err != nil {
+			log.Fatalf("failed to subset %s: %v", *cjk, err)
+		}
+	}
+	if *emoji != "" {
+		if err := traceEmoji(*emoji, runes(emojiRanges), filepath.Join(*out, "NotoColorEmoji-Mono-Subset.ttf")); err != nil {
+			log.Fatalf("failed to trace %s: %v", *emoji, err)
+		}
+	}
+}
+
+func runes(ranges [][2]rune) []rune {
+	rs := []rune{}
+	for _, r := range ranges {
+		for c := r[0]; c <= r[1]; c++ {
+			rs = append(rs, c)
+		}
+	}
+	return rs
+}
+
+// kanji are the first level kanji of JIS X 0208, rows 16 to 47.
+func kanji() []rune {
+	decoder := japanese.EUCJP.NewDecoder()
+	rs := []rune{}
+	for row := 16; row <= 47; row++ {
+		for cell := 1; cell <= 94; cell++ {
+			s, err := decoder.Bytes([]byte{byte(0xa0 + row), byte(0xa0 + cell)})
+			if err != nil {
+				continue
+			}
+			for _, r := range string(s) {
+				if r >= 0x4e00 && r <= 0x9fff {
+					rs = append(rs, r)
+				}
+			}
+		}
+	}
+	return rs
+}
+
+// openFont returns the font of the file, or the one of the collection with
+// the family name.
+func openFont(path, family string) (*sfnt.Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := sfnt.ParseCollection(data)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < c.NumFonts(); i++ {
+		f, err := c.Font(i)
+		if err != nil {
+			return nil, err
+		}
+		if name, _ := f.Name(nil, sfnt.NameIDFamily); name == family {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("no %s font", family)
+}
+
+type point struct {
+	x, y float64
+	on   bool
+}
+
+type glyph struct {
+	contours [][]point
+	advance  int
+}
+
+// subsetOutlines writes the glyphs of the runes the font has.
+func subsetOutlines(path, family string, rs []rune, out string) error {
+	f, err := openFont(path, family)
+	if err != nil {
+		return err
+	}
+	buf := &sfnt.Buffer{}
+	upem := int(f.UnitsPerEm())
+	ppem := fixed.I(upem)
+
+	info := fontInfo{upem: upem}
+	metrics, err := f.Metrics(buf, ppem, font.HintingNone)
+	if err != nil {
+		return err
+	}
+	info.ascent, info.descent = metrics.Ascent.Round(), metrics.Descent.Round()
+	info.lineGap = metrics.Height.Round() - info.ascent - info.descent
+	info.names = map[uint16]string{}
+	for _, id := range []sfnt.NameID{sfnt.NameIDCopyright, sfnt.NameIDFamily, sfnt.NameIDSubfamily, sfnt.NameIDLicense, sfnt.NameIDLicenseURL} {
+		if name, err := f.Name(buf, id); err == nil {
+			info.names[uint16(id)] = name
+		}
+	}
+
+	glyphs := []glyph{}
+	notdef, err := loadGlyph(f, buf, 0, ppem)
+	if err != nil {
+		return err
+	}
+	glyphs = append(glyphs, notdef)
+	cmap := map[rune]int{}
+	byIndex := map[sfnt.GlyphIndex]int{}
+	for _, r := range rs {
+		i, err := f.GlyphIndex(buf, r)
+		if err != nil || i == 0 {
+			continue
+		}
+		if g, ok := byIndex[i]; ok {
+			cmap[r] = g
+			continue
+		}
+		g, err := loadGlyph(f, buf, i, ppem)
+		if err != nil {
+			return err
+		}
+		byIndex[i] = len(glyphs)
+		cmap[r] = len(glyphs)
+		glyphs = append(glyphs, g)
+	}
+	return writeFont(out, info, glyphs, cmap)
+}
+
+// loadGlyph reads the outline in font units, with y going up, turning the
+// cubic curves into quadratic ones.
+func loadGlyph(f *sfnt.Font, buf *sfnt.Buffer, i sfnt.GlyphIndex, ppem fixed.Int26_6) (glyph, error) {
+	advance, err := f.GlyphAdvance(buf, i, ppem, font.HintingNone)
+	if err != nil {
+		return glyph{}, err
+	}
+	segments, err := f.LoadGlyph(buf, i, ppem, nil)
+	if err != nil {
+		return glyph{}, err
+	}
+	pt := func(p fixed.Point26_6, on bool) point {
+		return point{float64(p.X) / 64, -float64(p.Y) / 64, on}
+	}
+	g := glyph{advance: advance.Round()}
+	var contour []point
+	var last point
+	for _, s := range segments {
+		switch s.Op {
+		case sfnt.SegmentOpMoveTo:
+			if len(contour) > 0 {
+				g.contours = append(g.contours, contour)
+			}
+			last = pt(s.Args[0], true)
+			contour = []point{last}
+		case sfnt.SegmentOpLineTo:
+			last = pt(s.Args[0], true)
+			contour = append(contour, last)
+		case sfnt.SegmentOpQuadTo:
+			last = pt(s.Args[1], true)
+			contour = append(contour, pt(s.Args[0], false), last)
+		case sfnt.SegmentOpCubeTo:
+			end := pt(s.Args[2], true)
+			contour = append(contour, cubicToQuads(last, pt(s.Args[0], false), pt(s.Args[1], false), end)...)
+			last = end
+		}
+	}
+	if len(contour) > 0 {
+		g.contours = append(g.contours, contour)
+	}
+	return g, nil
+}
+
+// cubicToQuads splits the curve from p0 in as few quadratic curves as keep
+// within half a unit of it.
+func cubicToQuads(p0, p1, p2, p3 point) []point {
+	for n := 1; ; n++ {
+		pieces := [][4]point{}
+		rest := [4]point{p0, p1, p2, p3}
+		for i := 0; i < n; i++ {
+			// each piece covers 1/n of the whole curve
+			head, tail := splitCubic(rest, 1/float64(n-i))
+			pieces = append(pieces, head)
+			rest = tail
+		}
+		maxErr := 0.0
+		for _, c := range pieces {
+			dx := c[3].x - 3*c[2].x + 3*c[1].x - c[0].x
+			dy := c[3].y - 3*c[2].y + 3*c[1].y - c[0].y
+			maxErr = math.Max(maxErr, math.Sqrt(3)/36*math.Hypot(dx, dy))
+		}
+		if maxErr > 0.5 && n < 16 {
+			continue
+		}
+		pts := []point{}
+		for _, c := range pieces {
+			q := point{(3*(c[1].x+c[2].x) - c[0].x - c[3].x) / 4, (3*(c[1].y+c[2].y) - c[0].y - c[3].y) / 4, false}
+			pts = append(pts, q, point{c[3].x, c[3].y, true})
+		}
+		return pts
+	}
+}
+
+// splitCubic splits the curve at t with de Casteljau's algorithm.
+func splitCubic(c [4]point, t float64) (head, tail [4]point) {
+	lerp := func(a, b point) point { return point{a.x + (b.x-a.x)*t, a.y + (b.y-a.y)*t, false} }
+	a, b, d := lerp(c[0], c[1]), lerp(c[1], c[2]), lerp(c[2], c[3])
+	e, f := lerp(a, b), lerp(b, d)
+	m := lerp(e, f)
+	return [4]point{c[0], a, e, m}, [4]point{m, f, d, c[3]}
+}
+
+type fontInfo struct {
+	upem                     int
+	ascent, descent, lineGap int
+	names                    map[uint16]string
+}
+
+// writeFont writes a TrueType font with the glyphs, the first one being
+// the missing glyph, and the runes mapped to them.
+func writeFont(path string, info fontInfo, glyphs []glyph, cmap map[rune]int) error {
+	glyf := []byte{}
+	loca := []uint32{}
+	hmtx := []byte{}
+	xMin, yMin, xMax, yMax := math.MaxInt16, math.MaxInt16, math.MinInt16, math.MinInt16
+	maxPoints, maxContours, maxAdvance := 0, 0, 0
+	minLSB, minRSB, maxExtent := math.MaxInt16, math.MaxInt16, 0
+	for _, g := range glyphs {
+		data, bounds, points := encodeGlyph(g.contours)
+		loca = append(loca, uint32(len(glyf)))
+		glyf = append(glyf, data...)
+		for len(glyf)%4 != 0 {
+			glyf = append(glyf, 0)
+		}
+		lsb := 0
+		if points > 0 {
+			xMin, yMin = min(xMin, bounds[0]), min(yMin, bounds[1])
+			xMax, yMax = max(xMax, bounds[2]), max(yMax, bounds[3])
+			lsb = bounds[0]
+			minLSB = min(minLSB, lsb)
+			minRSB = min(minRSB, g.advance-bounds[2])
+			maxExtent = max(maxExtent, bounds[2])
+		}
+		maxPoints, maxContours = max(maxPoints, points), max(maxContours, len(g.contours))
+		maxAdvance = max(maxAdvance, g.advance)
+		hmtx = binary.BigEndian.AppendUint16(hmtx, uint16(g.advance))
+		hmtx = binary.BigEndian.AppendUint16(hmtx, uint16(int16(lsb)))
+	}
+	loca = append(loca, uint32(len(glyf)))
+	locaData := []byte{}
+	for _, o := range loca {
+		locaData = binary.BigEndian.AppendUint32(locaData, o)
+	}
+
+	family, subfamily := info.names[1], info.names[2]
+	names := map[uint16]string{}
+	for id, name := range info.names {
+		names[id] = name
+	}
+	names[3] = strings.ReplaceAll(family, " ", "") + "-" + subfamily + "-Subset"
+	names[4] = family + " " + subfamily
+	names[5] = "Version 1.000"
+	names[6] = strings.ReplaceAll(family, " ", "") + "-" + subfamily
+
+	runes := make([]rune, 0, len(cmap))
+	for r := range cmap {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+
+	tables := map[string][]byte{
+		"cmap": cmapTable(runes, cmap),
+		"glyf": glyf,
+		"head": headTable(info.upem, xMin, yMin, xMax, yMax),
+		"hhea": hheaTable(info, maxAdvance, minLSB, minRSB, maxExtent, len(glyphs)),
+		"hmtx": hmtx,
+		"loca": locaData,
+		"maxp": maxpTable(len(glyphs), maxPoints, maxContours),
+		"name": nameTable(names),
+		"OS/2": os2Table(info, glyphs, runes, subfamily),
+		"post": postTable(),
+	}
+	data := sfntFile(tables)
+	if _, err := sfnt.Parse(data); err != nil {
+		return fmt.Errorf("failed to read the font back: %v", err)
+	}
+	fmt.Printf("%s: %d runes, %d glyphs, %d bytes\n", path, len(runes), len(glyphs), len(data))
+	return os.WriteFile(path, data, 0o644)
+}
+
+// encodeGlyph writes a simple glyph, returning its bounds and number of
+// points.
+func encodeGlyph(contours [][]point) ([]byte, [4]int, int) {
+	type ipoint struct {
+		x, y int
+		on   bool
+	}
+	cs := [][]ipoint{}
+	for _, c := range contours {
+		pts := []ipoint{}
+		for _, p := range c {
+			ip := ipoint{int(math.Round(p.x)), int(math.Round(p.y)), p.on}
+			if n := len(pts); n > 0 && pts[n-1] == ip {
+				continue
+			}
+			pts = append(pts, ip)
+		}
+		// the contours are closed, so a last point on the first is dropped
+		for len(pts) > 1 && pts[len(pts)-1] == pts[0] {
+			pts = pts[:len(pts)-1]
+		}
+		if len(pts) >= 3 {
+			cs = append(cs, pts)
+		}
+	}
+	if len(cs) == 0 {
+		return nil, [4]int{}, 0
+	}
+
+	// the outer contours go clockwise, as TrueType expects
+	area := 0.0
+	for _, c := range cs {
+		for i := range c {
+			a, b := c[i], c[(i+1)%len(c)]
+			area += float64(a.x*b.y - b.x*a.y)
+		}
+	}
+	if area > 0 {
+		for _, c := range cs {
+			for i, j := 0, len(c)-1; i < j; i, j = i+1, j-1 {
+				c[i], c[j] = c[j], c[i]
+			}
+		}
+	}
+
+	bounds := [4]int{math.MaxInt32, math.MaxInt32, math.MinInt32, math.MinInt32}
+	var pointFlags, xs, ys []byte
+	ends := []byte{}
+	n, x, y := 0, 0, 0
+	for _, c := range cs {
+		for _, p := range c {
+			bounds[0], bounds[1] = min(bounds[0], p.x), min(bounds[1], p.y)
+			bounds[2], bounds[3] = max(bounds[2], p.x), max(bounds[3], p.y)
+			var flag byte
+			if p.on {
+				flag |= 0x01
+			}
+			dx, dy := p.x-x, p.y-y
+			switch {
+			case dx == 0:
+				flag |= 0x10
+			case dx > -256 && dx < 256:
+				flag |= 0x02
+				if dx > 0 {
+					flag |= 0x10
+				}
+				xs = append(xs, byte(abs(dx)))
+			default:
+				xs = binary.BigEndian.AppendUint16(xs, uint16(int16(dx)))
+			}
+			switch {
+			case dy == 0:
+				flag |= 0x20
+			case dy > -256 && dy < 256:
+				flag |= 0x04
+				if dy > 0 {
+					flag |= 0x20
+				}
+				ys = append(ys, byte(abs(dy)))
+			default:
+				ys = binary.BigEndian.AppendUint16(ys, uint16(int16(dy)))
+			}
+			pointFlags = append(pointFlags, flag)
+			x, y = p.x, p.y
+			n++
+		}
+		ends = binary.BigEndian.AppendUint16(ends, uint16(n-1))
+	}
+
+	// runs of the same flag are written once, with a repeat count
+	flags := []byte{}
+	for i := 0; i < len(pointFlags); {
+		j := i + 1
+		for j < len(pointFlags) && pointFlags[j] == pointFlags[i] && j-i <= 255 {
+			j++
+		}
+		if j-i > 1 {
+			flags = append(flags, pointFlags[i]|0x08, byte(j-i-1))
+		} else {
+			flags = append(flags, pointFlags[i])
+		}
+		i = j
+	}
+
+	out := binary.BigEndian.AppendUint16(nil, uint16(len(cs)))
+	for _, b := range bounds {
+		out = binary.BigEndian.AppendUint16(out, uint16(int16(b)))
+	}
+	out = append(out, ends...)
+	out = binary.BigEndian.AppendUint16(out, 0)
+	out = append(out, flags...)
+	out = append(out, xs...)
+	out = append(out, ys...)
+	return out, bounds, n
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func u16(b []byte, vs ...int) []byte {
+	for _, v := range vs {
+		b = binary.BigEndian.AppendUint16(b, uint16(v))
+	}
+	return b
+}
+
+func u32(b []byte, vs ...uint32) []byte {
+	for _, v := range vs {
+		b = binary.BigEndian.AppendUint32(b, v)
+	}
+	return b
+}
+
+// cmapTable maps the runes of the Basic Multilingual Plane with a format 4
+// subtable, and every rune with a format 12 one.
+func cmapTable(runes []rune, cmap map[rune]int) []byte {
+	// groups of consecutive runes with consecutive glyphs
+	type group struct{ start, end rune }
+	groups := []group{}
+	for _, r := range runes {
+		if n := len(groups); n > 0 && groups[n-1].end == r-1 && cmap[r-1] == cmap[r]-1 {
+			groups[n-1].end = r
+			continue
+		}
+		groups = append(groups, group{r, r})
+	}
+
+	bmp := []group{}
+	for _, g := range groups {
+		if g.start <= 0xfffe {
+			bmp = append(bmp, group{g.start, min(g.end, 0xfffe)})
+		}
+	}
+	bmp = append(bmp, group{0xffff, 0xffff})
+	segments := len(bmp)
+	searchRange := 2
+	for searchRange*2 <= segments*2 {
+		searchRange *= 2
+	}
+	entrySelector := int(math.Log2(float64(searchRange / 2)))
+	format4 := u16(nil, 4, 16+8*segments, 0, 2*segments, searchRange, entrySelector, 2*segments-searchRange)
+	for _, g := range bmp {
+		format4 = u16(format4, int(g.end))
+	}
+	format4 = u16(format4, 0)
+	for _, g := range bmp {
+		format4 = u16(format4, int(g.start))
+	}
+	for _, g := range bmp {
+		delta := 1
+		if g.start != 0xffff {
+			delta = cmap[g.start] - int(g.start)
+		}
+		format4 = u16(format4, delta&0xffff)
+	}
+	for range bmp {
+		format4 = u16(format4, 0)
+	}
+
+	format12 := u16(nil, 12, 0)
+	format12 = u32(format12, uint32(16+12*len(groups)), 0, uint32(len(groups)))
+	for _, g := range groups {
+		format12 = u32(format12, uint32(g.start), uint32(g.end), uint32(cmap[g.start]))
+	}
+
+	out := u16(nil, 0, 2)
+	out = u16(out, 3, 1)
+	out = u32(out, 4+8*2)
+	out = u16(out, 3, 10)
+	out = u32(out, uint32(4+8*2+len(format4)))
+	out = append(out, format4...)
+	return append(out, format12...)
+}
+
+func headTable(upem, xMin, yMin, xMax, yMax int) []byte {
+	out := u32(nil, 0x00010000, 0x00010000, 0, 0x5f0f3cf5)
+	out = u16(out, 0x000b, upem)
+	// no creation and modification dates, so the files are reproducible
+	out = u32(out, 0, 0, 0, 0)
+	out = u16(out, xMin, yMin, xMax, yMax)
+	// mac style, smallest readable size, mixed directions, long offsets
+	return u16(out, 0, 8, 2, 1, 0)
+}
+
+func hheaTable(info fontInfo, maxAdvance, minLSB, minRSB, maxExtent, glyphs int) []byte {
+	out := u32(nil, 0x00010000)
+	out = u16(out, info.ascent, -info.descent, info.lineGap, maxAdvance, minLSB, minRSB, maxExtent)
+	// upright caret, reserved fields and the metrics format
+	out = u16(out, 1, 0, 0, 0, 0, 0, 0, 0)
+	return u16(out, glyphs)
+}
+
+func maxpTable(glyphs, maxPoints, maxContours int) []byte {
+	out := u32(nil, 0x00010000)
+	out = u16(out, glyphs, maxPoints, maxContours, 0, 0, 2)
+	return u16(out, 0, 0, 0, 0, 0, 0, 0, 0)
+}
+
+// nameTable writes the names for Windows, and the ASCII ones for Mac too.
+func nameTable(names map[uint16]string) []byte {
+	ids := []int{}
+	for id := range names {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+	type record struct {
+		platform, encoding, language, id int
+		data                             []byte
+	}
+	records := []record{}
+	for _, id := range ids {
+		name := names[uint16(id)]
+		ascii := true
+		for _, r := range name {
+			ascii = ascii && r < 0x80
+		}
+		if ascii {
+			records = append(records, record{1, 0, 0, id, []byte(name)})
+		}
+	}
+	for _, id := range ids {
+		data := []byte{}
+		for _, r := range names[uint16(id)] {
+			if r > 0xffff {
+				r = '?'
+			}
+			data = u16(data, int(r))
+		}
+		records = append(records, record{3, 1, 0x409, id, data})
+	}
+	out := u16(nil, 0, len(records), 6+12*len(records))
+	strs := []byte{}
+	for _, r := range records {
+		out = u16(out, r.platform, r.encoding, r.language, r.id, len(r.data), len(strs))
+		strs = append(strs, r.data...)
+	}
+	return append(out, strs...)
+}
+
+func os2Table(info fontInfo, glyphs []glyph, runes []rune, subfamily string) []byte {
+	total, count := 0, 0
+	for _, g := range glyphs[1:] {
+		if g.advance > 0 {
+			total += g.advance
+			count++
+		}
+	}
+	weight, selection := 400, 0x40
+	if strings.Contains(subfamily, "Bold") {
+		weight, selection = 700, 0x20
+	}
+	upem := info.upem
+	out := u16(nil, 4, total/max(count, 1), weight, 5, 0)
+	// subscript and superscript sizes and offsets, strikeout
+	out = u16(out, upem*65/100, upem*60/100, 0, upem*75/1000)
+	out = u16(out, upem*65/100, upem*60/100, 0, upem*35/100)
+	out = u16(out, upem*5/100, upem*30/100, 0)
+	out = append(out, make([]byte, 10)...)
+	out = u32(out, 0, 0, 0, 0)
+	out = append(out, "NONE"...)
+	first, last := min(runes[0], 0xffff), min(runes[len(runes)-1], 0xffff)
+	out = u16(out, selection|0x80, int(first), int(last))
+	out = u16(out, info.ascent, -info.descent, info.lineGap, info.ascent, info.descent)
+	out = u32(out, 0, 0)
+	// x height, cap height, default and break characters, context
+	return u16(out, upem/2, upem*7/10, 0, ' ', 0)
+}
+
+func postTable() []byte {
+	return u32(nil, 0x00030000, 0, 0xff9c0032, 0, 0, 0, 0, 0)
+}
+
+func checksum(data []byte) uint32 {
+	sum := uint32(0)
+	for i := 0; i < len(data); i += 4 {
+		word := [4]byte{}
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
+
+// sfntFile puts the tables together, by tag.
+func sfntFile(tables map[string][]byte) []byte {
+	tags := []string{}
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	searchRange, entrySelector := 1, 0
+	for searchRange*2 <= len(tags) {
+		searchRange *= 2
+		entrySelector++
+	}
+	out := u32(nil, 0x00010000)
+	out = u16(out, len(tags), searchRange*16, entrySelector, len(tags)*16-searchRange*16)
+	offset := len(out) + 16*len(tags)
+	body := []byte{}
+	headOffset := 0
+	for _, tag := range tags {
+		data := tables[tag]
+		if tag == "head" {
+			headOffset = offset + len(body)
+		}
+		out = append(out, tag...)
+		out = u32(out, checksum(data), uint32(offset+len(body)), uint32(len(data)))
+		body = append(body, data...)
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+	}
+	out = append(out, body...)
+	binary.BigEndian.PutUint32(out[headOffset+8:], 0xb1b0afba-checksum(out))
+	return out
+}
+
+// readTables reads the table directory of a font file.
+func readTables(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 {
+		return nil, errors.New("not a font file")
+	}
+	n := int(binary.BigEndian.Uint16(data[4:]))
+	tables := map[string][]byte{}
+	for i := 0; i < n; i++ {
+		rec := data[12+16*i:]
+		offset, length := binary.BigEndian.Uint32(rec[8:]), binary.BigEndian.Uint32(rec[12:])
+		if int(offset+length) > len(data) {
+			return nil, fmt.Errorf("table %s is out of the file", rec[:4])
+		}
+		tables[string(rec[:4])] = data[offset : offset+length]
+	}
+	return tables, nil
+}
+
+// readCmap reads the format 12 subtable of a cmap table.
+func readCmap(cmap []byte) (map[rune]int, error) {
+	n := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < n; i++ {
+		offset := binary.BigEndian.Uint32(cmap[4+8*i+4:])
+		sub := cmap[offset:]
+		if binary.BigEndian.Uint16(sub) != 12 {
+			continue
+		}
+		groups := int(binary.BigEndian.Uint32(sub[12:]))
+		runes := map[rune]int{}
+		for g := 0; g < groups; g++ {
+			rec := sub[16+12*g:]
+			start, end, glyph := binary.BigEndian.Uint32(rec), binary.BigEndian.Uint32(rec[4:]), binary.BigEndian.Uint32(rec[8:])
+			for r := start; r <= end; r++ {
+				runes[rune(r)] = int(glyph + r - start)
+			}
+		}
+		return runes, nil
+	}
+	return nil, errors.New("no format 12 cmap")
+}
+
+// bitmap is the image of a glyph in a color bitmap strike, placed with its
+// metrics in pixels.
+type bitmap struct {
+	img                         image.Image
+	bearingX, bearingY, advance int
+}
+
+// readBitmaps reads the PNG images of the largest strike of the CBLC and
+// CBDT tables.
+func readBitmaps(cblc, cbdt []byte) (map[int]bitmap, int, error) {
+	sizes := int(binary.BigEndian.Uint32(cblc[4:]))
+	best, ppem := -1, 0
+	for i := 0; i < sizes; i++ {
+		if p := int(cblc[8+48*i+45]); p > ppem {
+			best, ppem = i, p
+		}
+	}
+	if best < 0 {
+		return nil, 0, errors.New("no bitmap strikes")
+	}
+	size := cblc[8+48*best:]
+	arrayOffset := int(binary.BigEndian.Uint32(size))
+	subtables := int(binary.BigEndian.Uint32(size[8:]))
+
+	bitmaps := map[int]bitmap{}
+	for i := 0; i < subtables; i++ {
+		entry := cblc[arrayOffset+8*i:]
+		first, last := int(binary.BigEndian.Uint16(entry)), int(binary.BigEndian.Uint16(entry[2:]))
+		sub := cblc[arrayOffset+int(binary.BigEndian.Uint32(entry[4:])):]
+		indexFormat, imageFormat := binary.BigEndian.Uint16(sub), binary.BigEndian.Uint16(sub[2:])
+		imageOffset := int(binary.BigEndian.Uint32(sub[4:]))
+		if imageFormat != 17 {
+			return nil, 0, fmt.Errorf("unsupported image format %d", imageFormat)
+		}
+		for g := first; g <= last; g++ {
+			var start, end int
+			switch indexFormat {
+			case 1:
+				start = int(binary.BigEndian.Uint32(sub[8+4*(g-first):]))
+				end = int(binary.BigEndian.Uint32(sub[8+4*(g-first+1):]))
+			case 3:
+				start = int(binary.BigEndian.Uint16(sub[8+2*(g-first):]))
+				end = int(binary.BigEndian.Uint16(sub[8+2*(g-first+1):]))
+			default:
+				return nil, 0, fmt.Errorf("unsupported index format %d", indexFormat)
+			}
+			if end == start {
+				continue
+			}
+			data := cbdt[imageOffset+start:]
+			// small glyph metrics: height, width, bearings and advance
+			length := binary.BigEndian.Uint32(data[5:])
+			img, err := png.Decode(bytes.NewReader(data[9 : 9+length]))
+			if err != nil {
+				return nil, 0, fmt.Errorf("failed to decode glyph %d: %v", g, err)
+			}
+			bitmaps[g] = bitmap{img, int(int8(data[2])), int(int8(data[3])), int(data[4])}
+		}
+	}
+	return bitmaps, ppem, nil
+}
+
+// traceEmoji writes the emoji of the runes as single color outlines.
+func traceEmoji(path string, rs []rune, out string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	tables, err := readTables(data)
+	if err != nil {
+		return err
+	}
+	runes, err := readCmap(tables["cmap"])
+	if err != nil {
+		return err
+	}
+	bitmaps, ppem, err := readBitmaps(tables["CBLC"], tables["CBDT"])
+	if err != nil {
+		return err
+	}
+	upem := int(binary.BigEndian.Uint16(tables["head"][18:]))
+	hhea := tables["hhea"]
+	info := fontInfo{
+		upem:    upem,
+		ascent:  int(int16(binary.BigEndian.Uint16(hhea[4:]))),
+		descent: -int(int16(binary.BigEndian.Uint16(hhea[6:]))),
+		lineGap: int(int16(binary.BigEndian.Uint16(hhea[8:]))),
+		names:   readNames(tables["name"]),
+	}
+	info.names[1] = "Noto Color Emoji Mono"
+	info.names[2] = "Regular"
+
+	scale := float64(upem) / float64(ppem)
+	glyphs := []glyph{{advance: upem}}
+	cmap := map[rune]int{}
+	for _, r := range rs {
+		b, ok := bitmaps[runes[r]]
+		if runes[r] == 0 || !ok {
+			continue
+		}
+		g := glyph{advance: int(math.Round(float64(b.advance) * scale))}
+		for _, loop := range traceBitmap(b.img) {
+			contour := []point{}
+			for _, p := range loop {
+				contour = append(contour, point{(float64(b.bearingX) + p.x) * scale, (float64(b.bearingY) - p.y) * scale, true})
+			}
+			g.contours = append(g.contours, contour)
+		}
+		if len(g.contours) == 0 {
+			continue
+		}
+		cmap[r] = len(glyphs)
+		glyphs = append(glyphs, g)
+	}
+	return writeFont(out, info, glyphs, cmap)
+}
+
+// readNames reads the copyright and license of a name table.
+func readNames(name []byte) map[uint16]string {
+	names := map[uint16]string{}
+	count, strs := int(binary.BigEndian.Uint16(name[2:])), int(binary.BigEndian.Uint16(name[4:]))
+	for i := 0; i < count; i++ {
+		rec := name[6+12*i:]
+		platform, id := binary.BigEndian.Uint16(rec), binary.BigEndian.Uint16(rec[6:])
+		length, offset := int(binary.BigEndian.Uint16(rec[8:])), int(binary.BigEndian.Uint16(rec[10:]))
+		if platform != 3 || (id != 0 && id != 13 && id != 14) {
+			continue
+		}
+		s := []rune{}
+		for j := 0; j+1 < length; j += 2 {
+			s = append(s, rune(binary.BigEndian.Uint16(name[strs+offset+j:])))
+		}
+		names[id] = string(s)
+	}
+	return names
+}
+
+// The emoji are cut along the borders between colors that differ by more
+// than borderContrast, summing the channels, and the cuts are widened by
+// borderRadius pixels. Specks smaller than minArea pixels are dropped.
+const (
+	borderContrast = 150
+	borderRadius   = 1
+	minArea        = 12
+)
+
+// traceBitmap returns the outlines of the opaque pixels of the image, less
+// the borders between its colors, in pixels with y going down.
+func traceBitmap(img image.Image) [][]point {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	colors := make([][4]int, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, bl, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			if a > 0 {
+				// straight colors, so the edges blend in less
+				r, g, bl = r*0xffff/a, g*0xffff/a, bl*0xffff/a
+			}
+			colors[y*w+x] = [4]int{int(r >> 8), int(g >> 8), int(bl >> 8), int(a >> 8)}
+		}
+	}
+	opaque := func(x, y int) bool {
+		return x >= 0 && y >= 0 && x < w && y < h && colors[y*w+x][3] >= 128
+	}
+
+	border := make([]bool, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if !opaque(x, y) {
+				continue
+			}
+			c := colors[y*w+x]
+			for _, d := range [][2]int{{1, 0}, {0, 1}, {1, 1}, {1, -1}} {
+				if !opaque(x+d[0], y+d[1]) {
+					continue
+				}
+				o := colors[(y+d[1])*w+x+d[0]]
+				if abs(c[0]-o[0])+abs(c[1]-o[1])+abs(c[2]-o[2]) > borderContrast {
+					border[y*w+x] = true
+				}
+			}
+		}
+	}
+	ink := make([]bool, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			ink[y*w+x] = opaque(x, y)
+			for dy := -borderRadius; dy <= borderRadius && ink[y*w+x]; dy++ {
+				for dx := -borderRadius; dx <= borderRadius; dx++ {
+					xx, yy := x+dx, y+dy
+					if dx*dx+dy*dy <= borderRadius*borderRadius && xx >= 0 && yy >= 0 && xx < w && yy < h && border[yy*w+xx] {
+						ink[y*w+x] = false
+						break
+					}
+				}
+			}
+		}
+	}
+	removeSpecks(ink, w, h, true)
+	removeSpecks(ink, w, h, false)
+	return simplifyLoops(traceLoops(ink, w, h))
+}
+
+// removeSpecks clears the small groups of ink pixels, or fills the small
+// holes when ink is false.
+func removeSpecks(pixels []bool, w, h int, ink bool) {
+	seen := make([]bool, w*h)
+	for start := range pixels {
+		if seen[start] || pixels[start] != ink {
+			continue
+		}
+		group := []int{start}
+		seen[start] = true
+		edge := false
+		for i := 0; i < len(group); i++ {
+			x, y := group[i]%w, group[i]/w
+			edge = edge || x == 0 || y == 0 || x == w-1 || y == h-1
+			for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+				xx, yy := x+d[0], y+d[1]
+				if xx < 0 || yy < 0 || xx >= w || yy >= h {
+					continue
+				}
+				if j := yy*w + xx; !seen[j] && pixels[j] == ink {
+					seen[j] = true
+					group = append(group, j)
+				}
+			}
+		}
+		// the background around the emoji is not a hole
+		if len(group) < minArea && (ink || !edge) {
+			for _, i := range group {
+				pixels[i] = !ink
+			}
+		}
+	}
+}
+
+// traceLoops follows the edges between ink and blank pixels, with the ink
+// on the right, into closed loops of pixel corners.
+func traceLoops(ink []bool, w, h int) [][][2]int {
+	at := func(x, y int) bool { return x >= 0 && y >= 0 && x < w && y < h && ink[y*w+x] }
+	type edge struct{ from, to [2]int }
+	edges := []edge{}
+	from := map[[2]int][]int{}
+	add := func(x0, y0, x1, y1 int) {
+		from[[2]int{x0, y0}] = append(from[[2]int{x0, y0}], len(edges))
+		edges = append(edges, edge{[2]int{x0, y0}, [2]int{x1, y1}})
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if !at(x, y) {
+				continue
+			}
+			if !at(x, y-1) {
+				add(x, y, x+1, y)
+			}
+			if !at(x+1, y) {
+				add(x+1, y, x+1, y+1)
+			}
+			if !at(x, y+1) {
+				add(x+1, y+1, x, y+1)
+			}
+			if !at(x-1, y) {
+				add(x, y+1, x, y)
+			}
+		}
+	}
+
+	used := make([]bool, len(edges))
+	loops := [][][2]int{}
+	for start := range edges {
+		if used[start] {
+			continue
+		}
+		loop := [][2]int{}
+		e := start
+		for !used[e] {
+			used[e] = true
+			loop = append(loop, edges[e].from)
+			next := -1
+			for _, n := range from[edges[e].to] {
+				if !used[n] {
+					next = n
+					// at a corner shared by two pixels, turn right to keep
+					// the loops apart
+					d0 := [2]int{edges[e].to[0] - edges[e].from[0], edges[e].to[1] - edges[e].from[1]}
+					d1 := [2]int{edges[n].to[0] - edges[n].from[0], edges[n].to[1] - edges[n].from[1]}
+					if d0[0]*d1[1]-d0[1]*d1[0] > 0 {
+						break
+					}
+				}
+			}
+			if next < 0 {
+				break
+			}
+			e = next
+		}
+		loops = append(loops, loop)
+	}
+	return loops
+}
+
+// simplifyLoops smooths the steps of the pixel loops into straight lines.
+func simplifyLoops(loops [][][2]int) [][]point {
+	out := [][]point{}
+	for _, loop := range loops {
+		pts := make([]point, len(loop))
+		for i, p := range loop {
+			pts[i] = point{float64(p[0]), float64(p[1]), true}
+		}
+		if len(pts) < 4 {
+			continue
+		}
+		// split the loop at its two farthest points
+		far, dist := 0, 0.0
+		for i, p := range pts {
+			if d := math.Hypot(p.x-pts[0].x, p.y-pts[0].y); d > dist {
+				far, dist = i, d
+			}
+		}
+		first := douglasPeucker(pts[:far+1], 0.75)
+		second := douglasPeucker(append(pts[far:], pts[0]), 0.75)
+		simple := append(first[:len(first)-1], second[:len(second)-1]...)
+		if len(simple) >= 3 {
+			out = append(out, simple)
+		}
+	}
+	return out
+}
+
+func douglasPeucker(pts []point, epsilon float64) []point {
+	if len(pts) < 3 {
+		return append([]point{}, pts...)
+	}
+	a, b := pts[0], pts[len(pts)-1]
+	far, dist := 0, 0.0
+	for i := 1; i < len(pts)-1; i++ {
+		p := pts[i]
+		var d float64
+		if l := math.Hypot(b.x-a.x, b.y-a.y); l > 0 {
+			d = math.Abs((b.x-a.x)*(a.y-p.y)-(a.x-p.x)*(b.y-a.y)) / l
+		} else {
+			d = math.Hypot(p.x-a.x, p.y-a.y)
+		}
+		if d > dist {
+			far, dist = i, d
+		}
+	}
+	if dist <= epsilon {
+		return []point{a, b}
+	}
+	left := douglasPeucker(pts[:far+1], epsilon)
+	right := douglasPeucker(pts[far:], epsilon)
+	return append(left[:len(left)-1], right...)
+}
@@ -90,8 +90,13 @@ func (p *Provider) databaseFromAirtableBase(sctx *sql.Context, client *airtable.
 		return nil, err
 	}
 
+	tableNames := map[string]string{}
 	for _, ts := range airtables.Tables {
-		table := NewTable(base, ts, p, p.recordCacheTTL)
+		tableNames[ts.ID] = util.ToSnakecase(ts.Name)
+	}
+
+	for _, ts := range airtables.Tables {
+		table := NewTable(base, ts, tableNames, p, p.recordCacheTTL)
 		db.AddTable(table.Name(), table)
 	}
 
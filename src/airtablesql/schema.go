@@ -11,9 +11,16 @@ import (
 
 const (
 	recordIDFieldName = "record_id"
+
+	// LinkedTableComment prefixes the linked table name on the comment of
+	// link columns.
+	LinkedTableComment = "linked table: "
 )
 
-func tableSchemaFromAirtable(tableSchema *airtable.TableSchema) sql.Schema {
+// tableSchemaFromAirtable converts the airtable fields to columns. The
+// tableNames map from airtable table ID to table name is used to record
+// which table a link field points to on the column comment.
+func tableSchemaFromAirtable(tableSchema *airtable.TableSchema, tableNames map[string]string) sql.Schema {
 	tableName := util.ToSnakecase(tableSchema.Name)
 	schema := sql.Schema{
 		&sql.Column{
@@ -25,10 +32,16 @@ func tableSchemaFromAirtable(tableSchema *airtable.TableSchema) sql.Schema {
 		},
 	}
 	for _, field := range tableSchema.Fields {
+		comment := fmt.Sprintf("airtable type: %s; airtable field: %s", field.Type, field.Name)
+		if linkedTableID, ok := field.Options["linkedTableId"].(string); ok {
+			if linkedTable, ok := tableNames[linkedTableID]; ok {
+				comment = fmt.Sprintf("%s; %s%s", comment, LinkedTableComment, linkedTable)
+			}
+		}
 		col := &sql.Column{
 			Name:       util.ToSnakecase(field.Name),
 			Type:       fromAirtableType(field.Type),
-			Comment:    fmt.Sprintf("airtable type: %s; airtable field: %s", field.Type, field.Name),
+			Comment:    comment,
 			Nullable:   true,
 			Source:     tableName,
 			PrimaryKey: field.ID == tableSchema.PrimaryFieldID,
@@ -42,7 +55,7 @@ func fromAirtableType(ftype string) sql.Type {
 	switch ftype {
 	case "date":
 		return types.Date
-	case "autoNumber":
+	case "autoNumber", "rating":
 		return types.Float64
 	case "singleSelect", "multilineText", "singleLineText":
 		return types.Text
@@ -27,8 +27,8 @@ type table struct {
 
 var _ sql.Table = &table{}
 
-func NewTable(base *airtable.Base, ts *airtable.TableSchema, provider *Provider, recordCacheTTL time.Duration) sql.Table {
-	schema := tableSchemaFromAirtable(ts)
+func NewTable(base *airtable.Base, ts *airtable.TableSchema, tableNames map[string]string, provider *Provider, recordCacheTTL time.Duration) sql.Table {
+	schema := tableSchemaFromAirtable(ts, tableNames)
 	cache := expirable.NewLRU[string, airtable.Records](cachedPages, nil, recordCacheTTL)
 	return &table{
 		name:        util.ToSnakecase(ts.Name),
@@ -0,0 +1,226 @@
+package highlights
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"text/template"
+	"time"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/i18n"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/imagegen"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/stats"
+)
+
+// Fact is a notable fact derived from the playthroughs of a year, like the
+// longest playthrough or the longest play streak.
+type Fact struct {
+	ID      string  `json:"id"`
+	Heading string  `json:"heading"`
+	Value   string  `json:"value"`
+	Text    string  `json:"text"`
+	Subject string  `json:"subject,omitempty"`
+	BoxArt  bool    `json:"-"`
+	Score   float64 `json:"score"`
+}
+
+func (f Fact) Card() imagegen.FactCard {
+	return imagegen.FactCard{
+		Heading: f.Heading,
+		Value:   f.Value,
+		Text:    f.Text,
+		Subject: f.Subject,
+		BoxArt:  f.BoxArt,
+	}
+}
+
+func Cards(facts []Fact) []imagegen.FactCard {
+	cards := make([]imagegen.FactCard, len(facts))
+	for i, f := range facts {
+		cards[i] = f.Card()
+	}
+	return cards
+}
+
+// Input is what each rule gets to derive its fact from.
+type Input struct {
+	Year int
+	// Playthroughs started in the year, ordered by start date.
+	Playthroughs []imagegen.Playthrough
+	// History has all playthroughs started up to the end of the year,
+	// ordered by start date.
+	History []imagegen.Playthrough
+	// Locale is the language of the facts, English when nil.
+	Locale *i18n.Locale
+}
+
+func (in Input) locale() *i18n.Locale {
+	if in.Locale == nil {
+		return i18n.English
+	}
+	return in.Locale
+}
+
+// Rule derives a single fact, returning false when there is nothing
+// notable to say. The Template is rendered with the Finding data, and is
+// looked up in the language of the input by its English text, like the
+// Heading.
+type Rule struct {
+	ID       string
+	Heading  string
+	Template string
+	Derive   func(in Input) (Finding, bool)
+}
+
+type Finding struct {
+	Data    map[string]any
+	Value   string
+	Subject string
+	BoxArt  bool
+	Score   float64
+}
+
+// Engine evaluates rules and ranks the resulting facts by score.
+type Engine struct {
+	rules []Rule
+	tmpls map[string]*template.Template
+}
+
+func NewEngine(rules ...Rule) (*Engine, error) {
+	e := &Engine{rules: rules, tmpls: map[string]*template.Template{}}
+	for _, r := range rules {
+		t, err := template.New(r.ID).Parse(r.Template)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template for %s: %v", r.ID, err)
+		}
+		e.tmpls[r.ID] = t
+	}
+	return e, nil
+}
+
+// DefaultEngine has all the built-in rules.
+func DefaultEngine() *Engine {
+	e, err := NewEngine(DefaultRules...)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// Facts returns all facts that apply to the input, best scored first.
+func (e *Engine) Facts(in Input) []Fact {
+	l := in.locale()
+	facts := []Fact{}
+	for _, r := range e.rules {
+		finding, ok := r.Derive(in)
+		if !ok {
+			continue
+		}
+		buf := bytes.Buffer{}
+		if err := e.template(r, l).Execute(&buf, finding.Data); err != nil {
+			continue
+		}
+		facts = append(facts, Fact{
+			ID:      r.ID,
+			Heading: l.T(r.Heading),
+			Value:   finding.Value,
+			Text:    buf.String(),
+			Subject: finding.Subject,
+			BoxArt:  finding.BoxArt,
+			Score:   math.Round(finding.Score*100) / 100,
+		})
+	}
+	sort.SliceStable(facts, func(i, j int) bool {
+		return facts[i].Score > facts[j].Score
+	})
+	return facts
+}
+
+// template is the template of the rule in the language, or the English one
+// when its translation doesn't parse.
+func (e *Engine) template(r Rule, l *i18n.Locale) *template.Template {
+	text := l.T(r.Template)
+	if text == r.Template {
+		return e.tmpls[r.ID]
+	}
+	t, err := template.New(r.ID).Parse(text)
+	if err != nil {
+		fmt.Println("failed to parse translated template: ", r.ID, err)
+		return e.tmpls[r.ID]
+	}
+	return t
+}
+
+// Top loads the playthroughs from the repository and returns the n best
+// scored facts of the year, in the language. n <= 0 returns all of them.
+func (e *Engine) Top(ctx context.Context, repo stats.Repository, r stats.Range, l *i18n.Locale, n int) ([]Fact, error) {
+	history, err := repo.PlaythroughsUntil(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	in := Input{Year: r.Year, History: history, Locale: l}
+	for _, p := range history {
+		if p.Year == r.String() {
+			in.Playthroughs = append(in.Playthroughs, p)
+		}
+	}
+	facts := e.Facts(in)
+	if n > 0 && len(facts) > n {
+		facts = facts[:n]
+	}
+	return facts, nil
+}
+
+// activeDays returns every day of the year with a playthrough in progress,
+// from its start date until its end date, or until today when it's still
+// going.
+func activeDays(in Input) map[time.Time]bool {
+	startOfYear := time.Date(in.Year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	endOfYear := time.Date(in.Year, time.December, 31, 0, 0, 0, 0, time.UTC)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	days := map[time.Time]bool{}
+	for _, p := range in.History {
+		if p.StartDate == nil {
+			continue
+		}
+		start := p.StartDate.UTC().Truncate(24 * time.Hour)
+		end := start
+		if p.EndDate != nil {
+			end = p.EndDate.UTC().Truncate(24 * time.Hour)
+		} else if p.Status == "Playing" {
+			end = today
+		}
+		if start.Before(startOfYear) {
+			start = startOfYear
+		}
+		if end.After(endOfYear) {
+			end = endOfYear
+		}
+		for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+			days[d] = true
+		}
+	}
+	return days
+}
+
+// longestRun returns the length and first day of the longest sequence of
+// days in the set, each stepDays apart.
+func longestRun(set map[time.Time]bool, stepDays int) (int, time.Time) {
+	var bestStart time.Time
+	best := 0
+	for day := range set {
+		if set[day.AddDate(0, 0, -stepDays)] {
+			continue
+		}
+		length := 0
+		for cur := day; set[cur]; cur = cur.AddDate(0, 0, stepDays) {
+			length++
+		}
+		if length > best || (length == best && day.Before(bestStart)) {
+			best, bestStart = length, day
+		}
+	}
+	return best, bestStart
+}
@@ -0,0 +1,275 @@
+package highlights
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/imagegen"
+)
+
+var DefaultRules = []Rule{
+	{
+		ID:       "longest-playthrough",
+		Heading:  "Longest playthrough",
+		Template: "{{.Game}} kept you busy for {{.Hours}} in a single playthrough.",
+		Derive:   longestPlaythrough,
+	},
+	{
+		ID:       "daily-streak",
+		Heading:  "Longest daily streak",
+		Template: "You had a game going for {{.Days}} in a row, starting on {{.Start}}.",
+		Derive:   dailyStreak,
+	},
+	{
+		ID:       "weekly-streak",
+		Heading:  "Longest weekly streak",
+		Template: "You played something for {{.Weeks}} in a row.",
+		Derive:   weeklyStreak,
+	},
+	{
+		ID:       "busiest-start-month",
+		Heading:  "Most games started",
+		Template: "You started {{.Games}} in {{.Month}}.",
+		Derive:   busiestStartMonth,
+	},
+	{
+		ID:       "first-game",
+		Heading:  "First game of the year",
+		Template: "You kicked off the year with {{.Game}} on {{.Date}}.",
+		Derive:   firstGame,
+	},
+	{
+		ID:       "last-game",
+		Heading:  "Last game of the year",
+		Template: "The last game you started was {{.Game}} on {{.Date}}.",
+		Derive:   lastGame,
+	},
+	{
+		ID:       "quickest-completion",
+		Heading:  "Quickest completion",
+		Template: "You went from start to finish on {{.Game}} in {{.Days}}.",
+		Derive:   quickestCompletion,
+	},
+	{
+		ID:       "most-replayed",
+		Heading:  "Most replayed game",
+		Template: "You came back to {{.Game}} {{.Times}} so far.",
+		Derive:   mostReplayed,
+	},
+	{
+		ID:       "new-series",
+		Heading:  "New series discovered",
+		Template: "First time playing {{.Series}}.",
+		Derive:   newSeries,
+	},
+}
+
+func longestPlaythrough(in Input) (Finding, bool) {
+	var longest *imagegen.Playthrough
+	for i, p := range in.Playthroughs {
+		if longest == nil || p.Playtime > longest.Playtime {
+			longest = &in.Playthroughs[i]
+		}
+	}
+	if longest == nil || longest.Playtime <= 0 {
+		return Finding{}, false
+	}
+	l := in.locale()
+	hours := int(math.Round(longest.Playtime))
+	return Finding{
+		Data:    map[string]any{"Game": longest.Title, "Hours": l.N(hours, "%s hour", "%s hours")},
+		Value:   l.T("%sh", l.Int(hours)),
+		Subject: longest.Title,
+		BoxArt:  true,
+		Score:   longest.Playtime / 20,
+	}, true
+}
+
+func dailyStreak(in Input) (Finding, bool) {
+	days, start := longestRun(activeDays(in), 1)
+	if days < 3 {
+		return Finding{}, false
+	}
+	l := in.locale()
+	return Finding{
+		Data:  map[string]any{"Days": l.N(days, "%s day", "%s days"), "Start": l.Day(start)},
+		Value: l.N(days, "%s day", "%s days"),
+		Score: float64(days) / 10,
+	}, true
+}
+
+func weeklyStreak(in Input) (Finding, bool) {
+	weeks := map[time.Time]bool{}
+	for day := range activeDays(in) {
+		offset := (int(day.Weekday()) + 6) % 7
+		weeks[day.AddDate(0, 0, -offset)] = true
+	}
+	count, _ := longestRun(weeks, 7)
+	if count < 2 {
+		return Finding{}, false
+	}
+	l := in.locale()
+	return Finding{
+		Data:  map[string]any{"Weeks": l.N(count, "%s week", "%s weeks")},
+		Value: l.N(count, "%s week", "%s weeks"),
+		Score: float64(count) / 4,
+	}, true
+}
+
+func busiestStartMonth(in Input) (Finding, bool) {
+	counts := map[time.Month]int{}
+	for _, p := range in.Playthroughs {
+		if p.StartDate != nil {
+			counts[p.StartDate.Month()]++
+		}
+	}
+	best, bestMonth := 0, time.January
+	for m := time.January; m <= time.December; m++ {
+		if counts[m] > best {
+			best, bestMonth = counts[m], m
+		}
+	}
+	if best < 2 {
+		return Finding{}, false
+	}
+	l := in.locale()
+	return Finding{
+		Data:  map[string]any{"Games": l.N(best, "%s game", "%s games"), "Month": l.Month(bestMonth)},
+		Value: l.N(best, "%s game", "%s games"),
+		Score: float64(best),
+	}, true
+}
+
+func startedPlaythroughs(in Input) []imagegen.Playthrough {
+	started := []imagegen.Playthrough{}
+	for _, p := range in.Playthroughs {
+		if p.StartDate != nil {
+			started = append(started, p)
+		}
+	}
+	sort.SliceStable(started, func(i, j int) bool {
+		return started[i].StartDate.Before(*started[j].StartDate)
+	})
+	return started
+}
+
+func firstGame(in Input) (Finding, bool) {
+	started := startedPlaythroughs(in)
+	if len(started) == 0 {
+		return Finding{}, false
+	}
+	first := started[0]
+	date := in.locale().Day(*first.StartDate)
+	return Finding{
+		Data:    map[string]any{"Game": first.Title, "Date": date},
+		Value:   date,
+		Subject: first.Title,
+		BoxArt:  true,
+		Score:   1.5,
+	}, true
+}
+
+func lastGame(in Input) (Finding, bool) {
+	started := startedPlaythroughs(in)
+	if len(started) < 2 {
+		return Finding{}, false
+	}
+	last := started[len(started)-1]
+	date := in.locale().Day(*last.StartDate)
+	return Finding{
+		Data:    map[string]any{"Game": last.Title, "Date": date},
+		Value:   date,
+		Subject: last.Title,
+		BoxArt:  true,
+		Score:   1.2,
+	}, true
+}
+
+func quickestCompletion(in Input) (Finding, bool) {
+	var quickest *imagegen.Playthrough
+	for i, p := range in.Playthroughs {
+		if p.Days() < 0 || p.Status == "Abandoned" {
+			continue
+		}
+		if quickest == nil || p.Days() < quickest.Days() {
+			quickest = &in.Playthroughs[i]
+		}
+	}
+	if quickest == nil {
+		return Finding{}, false
+	}
+	days := quickest.Days()
+	score := 3.0
+	if days > 7 {
+		score = 21 / float64(days)
+	}
+	l := in.locale()
+	return Finding{
+		Data:    map[string]any{"Game": quickest.Title, "Days": l.N(days, "%s day", "%s days")},
+		Value:   l.N(days, "%s day", "%s days"),
+		Subject: quickest.Title,
+		BoxArt:  true,
+		Score:   score,
+	}, true
+}
+
+func mostReplayed(in Input) (Finding, bool) {
+	playedThisYear := map[string]bool{}
+	for _, p := range in.Playthroughs {
+		playedThisYear[p.Title] = true
+	}
+	counts := map[string]int{}
+	for _, p := range in.History {
+		if playedThisYear[p.Title] {
+			counts[p.Title]++
+		}
+	}
+	best, bestGame := 0, ""
+	for game, count := range counts {
+		if count > best || (count == best && game < bestGame) {
+			best, bestGame = count, game
+		}
+	}
+	if best < 2 {
+		return Finding{}, false
+	}
+	l := in.locale()
+	return Finding{
+		Data:    map[string]any{"Game": bestGame, "Times": l.N(best, "%s time", "%s times")},
+		Value:   l.N(best, "%s time", "%s times"),
+		Subject: bestGame,
+		BoxArt:  true,
+		Score:   float64(best) * 1.5,
+	}, true
+}
+
+func newSeries(in Input) (Finding, bool) {
+	year := fmt.Sprintf("%d", in.Year)
+	playedBefore := map[string]bool{}
+	for _, p := range in.History {
+		if p.Serie != "" && p.Year != year {
+			playedBefore[p.Serie] = true
+		}
+	}
+	series := []string{}
+	seen := map[string]bool{}
+	for _, p := range in.Playthroughs {
+		if p.Serie == "" || playedBefore[p.Serie] || seen[p.Serie] {
+			continue
+		}
+		seen[p.Serie] = true
+		series = append(series, p.Serie)
+	}
+	if len(series) == 0 {
+		return Finding{}, false
+	}
+	return Finding{
+		Data:    map[string]any{"Series": strings.Join(series, ", ")},
+		Value:   in.locale().N(len(series), "%s new", "%s new"),
+		Subject: series[0],
+		Score:   float64(len(series)) * 1.5,
+	}, true
+}
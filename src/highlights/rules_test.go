@@ -0,0 +1,126 @@
+package highlights
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/i18n"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/imagegen"
+)
+
+func date(year int, month time.Month, day int) *time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+// testInput is a year with a replayed game, a long playthrough spanning
+// two months and a new series.
+func testInput() Input {
+	earlier := imagegen.Playthrough{Title: "Hades", Serie: "Hades", Year: "2022", Status: "Beaten", Playtime: 25, StartDate: date(2022, 6, 1), EndDate: date(2022, 6, 10)}
+	year := []imagegen.Playthrough{
+		{Title: "Hades", Serie: "Hades", Year: "2024", Status: "Beaten", Playtime: 30, StartDate: date(2024, 1, 5), EndDate: date(2024, 1, 7)},
+		{Title: "Zelda", Serie: "Zelda", Year: "2024", Status: "Beaten", Playtime: 120.4, StartDate: date(2024, 1, 20), EndDate: date(2024, 3, 10)},
+		{Title: "Celeste", Year: "2024", Status: "Abandoned", Playtime: 5, StartDate: date(2024, 3, 1), EndDate: date(2024, 3, 1)},
+		{Title: "Hades", Serie: "Hades", Year: "2024", Status: "Beaten", Playtime: 10, StartDate: date(2024, 11, 30), EndDate: date(2024, 12, 2)},
+	}
+	return Input{Year: 2024, Playthroughs: year, History: append([]imagegen.Playthrough{earlier}, year...)}
+}
+
+func TestRules(t *testing.T) {
+	rules := map[string]Rule{}
+	for _, r := range DefaultRules {
+		rules[r.ID] = r
+	}
+
+	tests := []struct {
+		id      string
+		value   string
+		subject string
+		data    map[string]any
+	}{
+		{"longest-playthrough", "120h", "Zelda", map[string]any{"Hours": "120 hours"}},
+		{"daily-streak", "51 days", "", map[string]any{"Start": "January 20"}},
+		{"weekly-streak", "8 weeks", "", nil},
+		{"busiest-start-month", "2 games", "", map[string]any{"Month": "January"}},
+		{"first-game", "January 5", "Hades", nil},
+		{"last-game", "November 30", "Hades", nil},
+		{"quickest-completion", "2 days", "Hades", nil},
+		{"most-replayed", "3 times", "Hades", nil},
+		{"new-series", "1 new", "Zelda", map[string]any{"Series": "Zelda"}},
+	}
+	if len(tests) != len(DefaultRules) {
+		t.Errorf("%d rules are tested, out of %d", len(tests), len(DefaultRules))
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			rule, ok := rules[tt.id]
+			if !ok {
+				t.Fatalf("no rule %s", tt.id)
+			}
+			finding, ok := rule.Derive(testInput())
+			if !ok {
+				t.Fatalf("no finding")
+			}
+			if finding.Value != tt.value || finding.Subject != tt.subject {
+				t.Errorf("finding = %q about %q, want %q about %q", finding.Value, finding.Subject, tt.value, tt.subject)
+			}
+			for k, v := range tt.data {
+				if finding.Data[k] != v {
+					t.Errorf("%s = %v, want %v", k, finding.Data[k], v)
+				}
+			}
+
+			if _, ok := rule.Derive(Input{Year: 2024}); ok {
+				t.Errorf("found something in a year without playthroughs")
+			}
+		})
+	}
+}
+
+func TestRulesThresholds(t *testing.T) {
+	single := imagegen.Playthrough{Title: "Hades", Serie: "Hades", Year: "2024", Status: "Abandoned", Playtime: 1, StartDate: date(2024, 5, 1), EndDate: date(2024, 5, 2)}
+	in := Input{Year: 2024, Playthroughs: []imagegen.Playthrough{single}, History: []imagegen.Playthrough{single}}
+
+	for _, r := range DefaultRules {
+		_, ok := r.Derive(in)
+		// a single short playthrough is the first game of the year, of a
+		// new series, and the longest one, but nothing else
+		want := r.ID == "first-game" || r.ID == "new-series" || r.ID == "longest-playthrough"
+		if ok != want {
+			t.Errorf("%s found = %v, want %v", r.ID, ok, want)
+		}
+	}
+}
+
+func TestFacts(t *testing.T) {
+	in := testInput()
+	facts := DefaultEngine().Facts(in)
+	if len(facts) != len(DefaultRules) {
+		t.Fatalf("got %d facts, want %d", len(facts), len(DefaultRules))
+	}
+	for i := 1; i < len(facts); i++ {
+		if facts[i].Score > facts[i-1].Score {
+			t.Errorf("%s scores more than %s before it", facts[i].ID, facts[i-1].ID)
+		}
+	}
+	if facts[0].ID != "longest-playthrough" {
+		t.Errorf("best fact is %s, want longest-playthrough", facts[0].ID)
+	}
+
+	tests := []struct {
+		locale  *i18n.Locale
+		heading string
+		text    string
+	}{
+		{i18n.English, "Longest playthrough", "Zelda kept you busy for 120 hours in a single playthrough."},
+		{i18n.Portuguese, "Jogatina mais longa", "Zelda te ocupou por 120 horas em uma única jogatina."},
+		{i18n.Spanish, "Partida más larga", "Zelda te tuvo ocupado 120 horas en una sola partida."},
+	}
+	for _, tt := range tests {
+		in.Locale = tt.locale
+		fact := DefaultEngine().Facts(in)[0]
+		if fact.Heading != tt.heading || fact.Text != tt.text {
+			t.Errorf("%s: fact = %q, %q, want %q, %q", tt.locale.Tag, fact.Heading, fact.Text, tt.heading, tt.text)
+		}
+	}
+}
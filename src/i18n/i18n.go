@@ -0,0 +1,142 @@
+// Package i18n translates the text of the charts, with the plural rules,
+// month names and number formats of each language.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale is a language the charts are written in. Messages are looked up
+// by their English text, and the ones without a translation are kept in
+// English.
+type Locale struct {
+	// Tag is the code of the language, like "pt".
+	Tag string
+	// messages are the translations of the English messages, with the
+	// plural forms of the ones given to N.
+	messages map[string][]string
+	// plural is the index of the plural form for the number.
+	plural      func(n int) int
+	months      [12]string
+	shortMonths [12]string
+	// day writes the day of a month, like "%[2]s %[1]d" for "January 2".
+	day string
+	// decimal and group separate the decimals and the thousands, which
+	// are only grouped from minGroup digits on.
+	decimal  string
+	group    string
+	minGroup int
+}
+
+var locales = map[string]*Locale{}
+
+func register(l *Locale) *Locale {
+	locales[l.Tag] = l
+	return l
+}
+
+// Parse finds the locale of a language code, like "pt" or "es-MX", by its
+// language. An empty code is English.
+func Parse(tag string) (*Locale, error) {
+	if tag == "" {
+		return English, nil
+	}
+	lang, _, _ := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+	if l, ok := locales[strings.ToLower(lang)]; ok {
+		return l, nil
+	}
+	return nil, fmt.Errorf("unknown language %q, use %s", tag, strings.Join(Tags(), ", "))
+}
+
+// Tags are the codes of the languages there are charts in.
+func Tags() []string {
+	tags := make([]string, 0, len(locales))
+	for tag := range locales {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// T translates the message, and formats it with the args like
+// fmt.Sprintf.
+func (l *Locale) T(msg string, args ...any) string {
+	if forms, ok := l.messages[msg]; ok {
+		msg = forms[0]
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// N translates the message in the plural form for n, given by its English
+// forms for one and for other numbers. The forms are formatted with n, as
+// a number of the language, followed by the args, like "%s games", or
+// leave the number out, like "games".
+func (l *Locale) N(n int, one, other string, args ...any) string {
+	forms := []string{one, other}
+	if translated, ok := l.messages[one]; ok {
+		forms = translated
+	}
+	form := forms[min(l.plural(n), len(forms)-1)]
+	if !strings.Contains(form, "%") {
+		return form
+	}
+	return fmt.Sprintf(form, append([]any{l.Int(n)}, args...)...)
+}
+
+func (l *Locale) Month(m time.Month) string {
+	if m < time.January || m > time.December {
+		return m.String()
+	}
+	return l.months[m-1]
+}
+
+// ShortMonth is the abbreviated name of the month, like "Jan".
+func (l *Locale) ShortMonth(m time.Month) string {
+	if m < time.January || m > time.December {
+		return m.String()
+	}
+	return l.shortMonths[m-1]
+}
+
+// Day is the day of the date with its month, like "January 2".
+func (l *Locale) Day(t time.Time) string {
+	return fmt.Sprintf(l.day, t.Day(), l.Month(t.Month()))
+}
+
+// Int writes the number with the thousands grouped, like "1,234".
+func (l *Locale) Int(n int) string {
+	return l.Float(float64(n), 0)
+}
+
+// Float writes the number with as many decimals, like "1,234.5".
+func (l *Locale) Float(f float64, decimals int) string {
+	s := strconv.FormatFloat(f, 'f', decimals, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, fraction, _ := strings.Cut(s, ".")
+	if len(whole) >= l.minGroup {
+		grouped := whole[:(len(whole)-1)%3+1]
+		for i := len(grouped); i < len(whole); i += 3 {
+			grouped += l.group + whole[i:i+3]
+		}
+		whole = grouped
+	}
+	if fraction != "" {
+		return sign + whole + l.decimal + fraction
+	}
+	return sign + whole
+}
+
+// Separators are the decimal and thousands separators of the numbers.
+func (l *Locale) Separators() (decimal, group string) {
+	return l.decimal, l.group
+}
@@ -0,0 +1,83 @@
+package i18n
+
+import "testing"
+
+func TestN(t *testing.T) {
+	tests := []struct {
+		locale     *Locale
+		n          int
+		one, other string
+		args       []any
+		want       string
+	}{
+		{English, 1, "%s game", "%s games", nil, "1 game"},
+		{English, 0, "%s game", "%s games", nil, "0 games"},
+		{English, 1234, "%s game", "%s games", nil, "1,234 games"},
+		{English, 2, "game", "games", nil, "games"},
+		{English, 2, "%[2]s (%[1]s game)", "%[2]s (%[1]s games)", []any{"Hades"}, "Hades (2 games)"},
+		{Portuguese, 0, "%s game", "%s games", nil, "0 jogo"},
+		{Portuguese, 1, "%s game", "%s games", nil, "1 jogo"},
+		{Portuguese, 2, "%s game", "%s games", nil, "2 jogos"},
+		{Portuguese, 1234, "%s game", "%s games", nil, "1.234 jogos"},
+		{Portuguese, 3, "%[2]s (%[1]s game)", "%[2]s (%[1]s games)", []any{"Hades"}, "Hades (3 jogos)"},
+		{Portuguese, 2, "%s untranslated", "%s untranslateds", nil, "2 untranslateds"},
+		{Spanish, 0, "%s game", "%s games", nil, "0 juegos"},
+		{Spanish, 1, "%s game", "%s games", nil, "1 juego"},
+		{Spanish, 1234, "%s game", "%s games", nil, "1234 juegos"},
+		{Spanish, 12345, "%s game", "%s games", nil, "12.345 juegos"},
+	}
+	for _, tt := range tests {
+		if got := tt.locale.N(tt.n, tt.one, tt.other, tt.args...); got != tt.want {
+			t.Errorf("%s: N(%d, %q) = %q, want %q", tt.locale.Tag, tt.n, tt.one, got, tt.want)
+		}
+	}
+}
+
+func TestFloat(t *testing.T) {
+	tests := []struct {
+		locale   *Locale
+		f        float64
+		decimals int
+		want     string
+	}{
+		{English, 0, 0, "0"},
+		{English, 999, 0, "999"},
+		{English, 1000, 0, "1,000"},
+		{English, 1234.5, 1, "1,234.5"},
+		{English, 1234.56, 1, "1,234.6"},
+		{English, -1234567.891, 2, "-1,234,567.89"},
+		{English, 12.5, 3, "12.500"},
+		{Portuguese, 1234.5, 1, "1.234,5"},
+		{Portuguese, -0.25, 2, "-0,25"},
+		{Spanish, 1234, 0, "1234"},
+		{Spanish, 12345.6, 1, "12.345,6"},
+		{Spanish, -123456, 0, "-123.456"},
+	}
+	for _, tt := range tests {
+		if got := tt.locale.Float(tt.f, tt.decimals); got != tt.want {
+			t.Errorf("%s: Float(%v, %d) = %q, want %q", tt.locale.Tag, tt.f, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want *Locale
+	}{
+		{"", English},
+		{"en", English},
+		{"pt-BR", Portuguese},
+		{"pt_br", Portuguese},
+		{"ES-mx", Spanish},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.tag)
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q) = %v, %v, want %s", tt.tag, got, err, tt.want.Tag)
+		}
+	}
+	if _, err := Parse("fr"); err == nil {
+		t.Errorf("Parse(\"fr\") didn't fail")
+	}
+}
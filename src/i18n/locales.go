@@ -0,0 +1,262 @@
+package i18n
+
+// English is the language of the messages, so it has no translations.
+var English = register(&Locale{
+	Tag:    "en",
+	plural: pluralOne,
+	months: [12]string{"January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December"},
+	shortMonths: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+	day:         "%[2]s %[1]d",
+	decimal:     ".",
+	group:       ",",
+	minGroup:    4,
+})
+
+// Portuguese is the Brazilian one, where zero is singular too.
+var Portuguese = register(&Locale{
+	Tag: "pt",
+	plural: func(n int) int {
+		if n == 0 || n == 1 {
+			return 0
+		}
+		return 1
+	},
+	months: [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho",
+		"julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+	shortMonths: [12]string{"jan", "fev", "mar", "abr", "mai", "jun", "jul", "ago", "set", "out", "nov", "dez"},
+	day:         "%[1]d de %[2]s",
+	decimal:     ",",
+	group:       ".",
+	minGroup:    4,
+	messages:    portuguese,
+})
+
+// Spanish only groups the thousands from five digits on, like "1234" and
+// "12.345".
+var Spanish = register(&Locale{
+	Tag:    "es",
+	plural: pluralOne,
+	months: [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio",
+		"julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+	shortMonths: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+	day:         "%[1]d de %[2]s",
+	decimal:     ",",
+	group:       ".",
+	minGroup:    5,
+	messages:    spanish,
+})
+
+func pluralOne(n int) int {
+	if n == 1 {
+		return 0
+	}
+	return 1
+}
+
+// portuguese has the messages in Portuguese, with the plural ones keyed by
+// their English form for one.
+var portuguese = map[string][]string{
+	// titles
+	"Most played consoles in %s":        {"Consoles mais jogados em %s"},
+	"Most played platform in %s":        {"Plataformas mais jogadas em %s"},
+	"Most played games in %s":           {"Jogos mais jogados em %s"},
+	"Most played game serie in %s":      {"Séries mais jogadas em %s"},
+	"Most played %s in %s":              {"%s mais jogados em %s"},
+	"Games beaten in %s":                {"Jogos zerados em %s"},
+	"Busiest months in %s":              {"Meses mais movimentados em %s"},
+	"Completion rate until %s":          {"Taxa de conclusão até %s"},
+	"Days to finish in %s":              {"Dias para zerar em %s"},
+	"Abandoned games by platform in %s": {"Jogos abandonados por plataforma em %s"},
+	"Backlog size in %s":                {"Tamanho do backlog em %s"},
+	"Backlog burn-down in %s":           {"Queima do backlog em %s"},
+	"Best rated platforms in %s":        {"Plataformas mais bem avaliadas em %s"},
+	"Best rated game series in %s":      {"Séries mais bem avaliadas em %s"},
+	"Average rating until %s":           {"Nota média até %s"},
+	"Best rated games in %s":            {"Jogos mais bem avaliados em %s"},
+	"Hidden gems of %s":                 {"Joias escondidas de %s"},
+	"Playtime by rating in %s":          {"Tempo de jogo por nota em %s"},
+	"Games by status in %s":             {"Jogos por status em %s"},
+	"Playtime by platform in %s":        {"Tempo de jogo por plataforma em %s"},
+	"Playtime per day in %s":            {"Tempo de jogo por dia em %s"},
+	"Hours played over %s":              {"Horas jogadas ao longo de %s"},
+	"Playthroughs of %s":                {"Jogatinas de %s"},
+	"Games played in %s":                {"Jogos jogados em %s"},
+	"Highlights of %s":                  {"Destaques de %s"},
+	"Household in %s":                   {"A casa em %s"},
+	"Most played consoles %s vs %s":     {"Consoles mais jogados %s vs %s"},
+	"Most played platform %s vs %s":     {"Plataformas mais jogadas %s vs %s"},
+	"Most played games %s vs %s":        {"Jogos mais jogados %s vs %s"},
+	"Most played game serie %s vs %s":   {"Séries mais jogadas %s vs %s"},
+	"Games beaten %s vs %s":             {"Jogos zerados %s vs %s"},
+	"Busiest months %s vs %s":           {"Meses mais movimentados %s vs %s"},
+	"Days to finish %s vs %s":           {"Dias para zerar %s vs %s"},
+	"Abandoned games %s vs %s":          {"Jogos abandonados %s vs %s"},
+	"Backlog size %s vs %s":             {"Tamanho do backlog %s vs %s"},
+	"%s %s vs %s":                       {"%s %s vs %s"},
+	"%s vs %s":                          {"%s vs %s"},
+	"My %s in games":                    {"Meu %s em jogos"},
+	"%s's %s in games":                  {"O %[2]s de %[1]s em jogos"},
+	"Gaming yearbook":                   {"Anuário de jogos"},
+	"%s of %s":                          {"%s de %s"},
+
+	// labels
+	"%s (%s) on %s":               {"%s (%s) em %s"},
+	"%[2]s (%[1]s game)":          {"%[2]s (%[1]s jogo)", "%[2]s (%[1]s jogos)"},
+	"%[2]s (%[3]s of %[1]s game)": {"%[2]s (%[3]s de %[1]s jogo)", "%[2]s (%[3]s de %[1]s jogos)"},
+	"Other":                       {"Outros"},
+	"games":                       {"jogos"},
+	"played":                      {"jogadas"},
+	"game":                        {"jogo", "jogos"},
+	"beaten":                      {"zerado", "zerados"},
+	"Top console":                 {"Console favorito"},
+	"Busiest month":               {"Mês mais movimentado"},
+	"Top game":                    {"Jogo favorito"},
+	"new":                         {"novo"},
+	"dropped":                     {"saiu"},
+	"%s, up %s":                   {"%s, subiu %s"},
+	"%s, down %s":                 {"%s, caiu %s"},
+	"%s day played":               {"%s dia jogado", "%s dias jogados"},
+	"%s, %s in total":             {"%s, %s no total"},
+	"More":                        {"Mais"},
+	"Less":                        {"Menos"},
+	"Finished":                    {"Zerado"},
+	"Abandoned":                   {"Abandonado"},
+	"Playing":                     {"Jogando"},
+	"Game":                        {"Jogo"},
+	"Played on":                   {"Jogado em"},
+	"Status":                      {"Status"},
+	"Playtime":                    {"Tempo de jogo"},
+	"%s game played":              {"%s jogo jogado", "%s jogos jogados"},
+	"%s, mostly %s":               {"%s, principalmente %s"},
+	"%s game finished":            {"%s jogo zerado", "%s jogos zerados"},
+	"%s hour":                     {"%s hora", "%s horas"},
+
+	// highlights
+	"Longest playthrough":    {"Jogatina mais longa"},
+	"Longest daily streak":   {"Maior sequência diária"},
+	"Longest weekly streak":  {"Maior sequência semanal"},
+	"Most games started":     {"Mais jogos começados"},
+	"First game of the year": {"Primeiro jogo do ano"},
+	"Last game of the year":  {"Último jogo do ano"},
+	"Quickest completion":    {"Zerada mais rápida"},
+	"Most replayed game":     {"Jogo mais rejogado"},
+	"New series discovered":  {"Séries novas descobertas"},
+	"{{.Game}} kept you busy for {{.Hours}} in a single playthrough.":      {"{{.Game}} te ocupou por {{.Hours}} em uma única jogatina."},
+	"You had a game going for {{.Days}} in a row, starting on {{.Start}}.": {"Você teve um jogo em andamento por {{.Days}} seguidos, a partir de {{.Start}}."},
+	"You played something for {{.Weeks}} in a row.":                        {"Você jogou algo por {{.Weeks}} seguidas."},
+	"You started {{.Games}} in {{.Month}}.":                                {"Você começou {{.Games}} em {{.Month}}."},
+	"You kicked off the year with {{.Game}} on {{.Date}}.":                 {"Você começou o ano com {{.Game}} em {{.Date}}."},
+	"The last game you started was {{.Game}} on {{.Date}}.":                {"O último jogo que você começou foi {{.Game}} em {{.Date}}."},
+	"You went from start to finish on {{.Game}} in {{.Days}}.":             {"Você zerou {{.Game}} do começo ao fim em {{.Days}}."},
+	"You came back to {{.Game}} {{.Times}} so far.":                        {"Você voltou a {{.Game}} {{.Times}} até agora."},
+	"First time playing {{.Series}}.":                                      {"Primeira vez jogando {{.Series}}."},
+	"%s day":                                                               {"%s dia", "%s dias"},
+	"%s week":                                                              {"%s semana", "%s semanas"},
+	"%s game":                                                              {"%s jogo", "%s jogos"},
+	"%s time":                                                              {"%s vez", "%s vezes"},
+	"%s new":                                                               {"%s nova", "%s novas"},
+}
+
+// spanish has the messages in Spanish, keyed like portuguese.
+var spanish = map[string][]string{
+	// titles
+	"Most played consoles in %s":        {"Consolas más jugadas en %s"},
+	"Most played platform in %s":        {"Plataformas más jugadas en %s"},
+	"Most played games in %s":           {"Juegos más jugados en %s"},
+	"Most played game serie in %s":      {"Sagas más jugadas en %s"},
+	"Most played %s in %s":              {"%s más jugados en %s"},
+	"Games beaten in %s":                {"Juegos terminados en %s"},
+	"Busiest months in %s":              {"Meses con más juego en %s"},
+	"Completion rate until %s":          {"Tasa de finalización hasta %s"},
+	"Days to finish in %s":              {"Días para terminar en %s"},
+	"Abandoned games by platform in %s": {"Juegos abandonados por plataforma en %s"},
+	"Backlog size in %s":                {"Tamaño del backlog en %s"},
+	"Backlog burn-down in %s":           {"Reducción del backlog en %s"},
+	"Best rated platforms in %s":        {"Plataformas mejor valoradas en %s"},
+	"Best rated game series in %s":      {"Sagas mejor valoradas en %s"},
+	"Average rating until %s":           {"Nota media hasta %s"},
+	"Best rated games in %s":            {"Juegos mejor valorados en %s"},
+	"Hidden gems of %s":                 {"Joyas ocultas de %s"},
+	"Playtime by rating in %s":          {"Tiempo de juego por nota en %s"},
+	"Games by status in %s":             {"Juegos por estado en %s"},
+	"Playtime by platform in %s":        {"Tiempo de juego por plataforma en %s"},
+	"Playtime per day in %s":            {"Tiempo de juego por día en %s"},
+	"Hours played over %s":              {"Horas jugadas a lo largo de %s"},
+	"Playthroughs of %s":                {"Partidas de %s"},
+	"Games played in %s":                {"Juegos jugados en %s"},
+	"Highlights of %s":                  {"Lo más destacado de %s"},
+	"Household in %s":                   {"La casa en %s"},
+	"Most played consoles %s vs %s":     {"Consolas más jugadas %s vs %s"},
+	"Most played platform %s vs %s":     {"Plataformas más jugadas %s vs %s"},
+	"Most played games %s vs %s":        {"Juegos más jugados %s vs %s"},
+	"Most played game serie %s vs %s":   {"Sagas más jugadas %s vs %s"},
+	"Games beaten %s vs %s":             {"Juegos terminados %s vs %s"},
+	"Busiest months %s vs %s":           {"Meses con más juego %s vs %s"},
+	"Days to finish %s vs %s":           {"Días para terminar %s vs %s"},
+	"Abandoned games %s vs %s":          {"Juegos abandonados %s vs %s"},
+	"Backlog size %s vs %s":             {"Tamaño del backlog %s vs %s"},
+	"%s %s vs %s":                       {"%s %s vs %s"},
+	"%s vs %s":                          {"%s vs %s"},
+	"My %s in games":                    {"Mi %s en juegos"},
+	"%s's %s in games":                  {"El %[2]s de %[1]s en juegos"},
+	"Gaming yearbook":                   {"Anuario de juegos"},
+	"%s of %s":                          {"%s de %s"},
+
+	// labels
+	"%s (%s) on %s":               {"%s (%s) en %s"},
+	"%[2]s (%[1]s game)":          {"%[2]s (%[1]s juego)", "%[2]s (%[1]s juegos)"},
+	"%[2]s (%[3]s of %[1]s game)": {"%[2]s (%[3]s de %[1]s juego)", "%[2]s (%[3]s de %[1]s juegos)"},
+	"Other":                       {"Otros"},
+	"games":                       {"juegos"},
+	"played":                      {"jugadas"},
+	"game":                        {"juego", "juegos"},
+	"beaten":                      {"terminado", "terminados"},
+	"Top console":                 {"Consola favorita"},
+	"Busiest month":               {"Mes con más juego"},
+	"Top game":                    {"Juego favorito"},
+	"new":                         {"nuevo"},
+	"dropped":                     {"salió"},
+	"%s, up %s":                   {"%s, sube %s"},
+	"%s, down %s":                 {"%s, baja %s"},
+	"%s day played":               {"%s día jugado", "%s días jugados"},
+	"%s, %s in total":             {"%s, %s en total"},
+	"More":                        {"Más"},
+	"Less":                        {"Menos"},
+	"Finished":                    {"Terminado"},
+	"Abandoned":                   {"Abandonado"},
+	"Playing":                     {"Jugando"},
+	"Game":                        {"Juego"},
+	"Played on":                   {"Jugado en"},
+	"Status":                      {"Estado"},
+	"Playtime":                    {"Tiempo de juego"},
+	"%s game played":              {"%s juego jugado", "%s juegos jugados"},
+	"%s, mostly %s":               {"%s, sobre todo %s"},
+	"%s game finished":            {"%s juego terminado", "%s juegos terminados"},
+	"%s hour":                     {"%s hora", "%s horas"},
+
+	// highlights
+	"Longest playthrough":    {"Partida más larga"},
+	"Longest daily streak":   {"Racha diaria más larga"},
+	"Longest weekly streak":  {"Racha semanal más larga"},
+	"Most games started":     {"Más juegos empezados"},
+	"First game of the year": {"Primer juego del año"},
+	"Last game of the year":  {"Último juego del año"},
+	"Quickest completion":    {"Final más rápido"},
+	"Most replayed game":     {"Juego más rejugado"},
+	"New series discovered":  {"Sagas nuevas descubiertas"},
+	"{{.Game}} kept you busy for {{.Hours}} in a single playthrough.":      {"{{.Game}} te tuvo ocupado {{.Hours}} en una sola partida."},
+	"You had a game going for {{.Days}} in a row, starting on {{.Start}}.": {"Tuviste un juego en marcha {{.Days}} seguidos, desde el {{.Start}}."},
+	"You played something for {{.Weeks}} in a row.":                        {"Jugaste a algo {{.Weeks}} seguidas."},
+	"You started {{.Games}} in {{.Month}}.":                                {"Empezaste {{.Games}} en {{.Month}}."},
+	"You kicked off the year with {{.Game}} on {{.Date}}.":                 {"Empezaste el año con {{.Game}} el {{.Date}}."},
+	"The last game you started was {{.Game}} on {{.Date}}.":                {"El último juego que empezaste fue {{.Game}} el {{.Date}}."},
+	"You went from start to finish on {{.Game}} in {{.Days}}.":             {"Terminaste {{.Game}} de principio a fin en {{.Days}}."},
+	"You came back to {{.Game}} {{.Times}} so far.":                        {"Volviste a {{.Game}} {{.Times}} hasta ahora."},
+	"First time playing {{.Series}}.":                                      {"Primera vez jugando a {{.Series}}."},
+	"%s day":                                                               {"%s día", "%s días"},
+	"%s week":                                                              {"%s semana", "%s semanas"},
+	"%s game":                                                              {"%s juego", "%s juegos"},
+	"%s time":                                                              {"%s vez", "%s veces"},
+	"%s new":                                                               {"%s nueva", "%s nuevas"},
+}
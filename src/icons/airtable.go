@@ -0,0 +1,66 @@
+package icons
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mehanizm/airtable"
+)
+
+// AirtableAttachments uses the first attachment of a field on the record
+// with the same name, looking in each table in order.
+type AirtableAttachments struct {
+	Client    *airtable.Client
+	BaseID    string
+	Tables    []string
+	NameField string
+	Field     string
+	// HTTPClient downloads the attachments.
+	HTTPClient *http.Client
+}
+
+var _ IconProvider = &AirtableAttachments{}
+
+func (a *AirtableAttachments) Name() string {
+	return "airtable"
+}
+
+func (a *AirtableAttachments) FindIcon(ctx context.Context, name string, kind Kind) (Icon, error) {
+	params := url.Values{}
+	params.Set("filterByFormula", fmt.Sprintf(`{%s} = "%s"`, a.NameField, strings.ReplaceAll(name, `"`, `\"`)))
+	params.Set("maxRecords", "1")
+	params.Add("fields[]", a.Field)
+
+	for _, tableName := range a.Tables {
+		records, err := a.Client.GetTable(a.BaseID, tableName).GetRecordsWithParamsContext(ctx, params)
+		if err != nil {
+			return Icon{}, err
+		}
+		for _, rec := range records.Records {
+			if url := attachmentURL(rec.Fields[a.Field]); url != "" {
+				return download(ctx, a.HTTPClient, url)
+			}
+		}
+	}
+	return Icon{}, ErrNotFound
+}
+
+func attachmentURL(field any) string {
+	attachments, ok := field.([]any)
+	if !ok {
+		return ""
+	}
+	for _, attachment := range attachments {
+		a, ok := attachment.(map[string]any)
+		if !ok {
+			continue
+		}
+		if url, ok := a["url"].(string); ok && url != "" {
+			return url
+		}
+	}
+	return ""
+}
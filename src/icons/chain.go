@@ -0,0 +1,116 @@
+package icons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Link is a provider in a chain, with how long to wait for it before
+// moving on to the next one. Zero waits as long as the caller does.
+type Link struct {
+	Provider IconProvider
+	Timeout  time.Duration
+}
+
+// Storer keeps the images found by the chain, so they don't need to be
+// looked up again, and the lookups that failed, so they are not retried
+// too often.
+type Storer interface {
+	Store(name string, kind Kind, icon Icon, provider string) error
+	StoreMiss(name string, kind Kind, err error) error
+	Missed(name string, kind Kind) bool
+}
+
+// Chain asks each provider in order, returning the first image found.
+type Chain struct {
+	links []Link
+	store Storer
+}
+
+var _ IconProvider = &Chain{}
+
+func NewChain(links ...Link) *Chain {
+	return &Chain{links: links}
+}
+
+// WithStore saves every image found by a provider other than the store
+// itself, and skips the lookups that failed recently.
+func (c *Chain) WithStore(s Storer) *Chain {
+	c.store = s
+	return c
+}
+
+// Links returns the providers of the chain, in order.
+func (c *Chain) Links() []Link {
+	return c.links
+}
+
+func (c *Chain) Name() string {
+	names := make([]string, len(c.links))
+	for i, l := range c.links {
+		names[i] = l.Provider.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (c *Chain) FindIcon(ctx context.Context, name string, kind Kind) (Icon, error) {
+	if c.store != nil && c.store.Missed(name, kind) {
+		return Icon{}, fmt.Errorf("%w for %s, failed recently", ErrNotFound, name)
+	}
+
+	errs := []error{}
+	// remoteMisses counts the providers other than the store that have no
+	// image for the name
+	remoteMisses := 0
+	for _, l := range c.links {
+		remote := any(l.Provider) != any(c.store)
+		icon, err := c.find(ctx, l, name, kind)
+		if errors.Is(err, ErrNotFound) {
+			if remote {
+				remoteMisses++
+			}
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", l.Provider.Name(), err))
+			continue
+		}
+		if c.store != nil && remote {
+			err := c.store.Store(name, kind, icon, l.Provider.Name())
+			if errors.Is(err, ErrBadMatch) {
+				remoteMisses++
+				continue
+			}
+			if err != nil {
+				fmt.Println("failed to store icon for:", name, err)
+			}
+		}
+		return icon, nil
+	}
+
+	err := fmt.Errorf("%w for %s", ErrNotFound, name)
+	if len(errs) > 0 {
+		err = fmt.Errorf("%w for %s: %v", ErrNotFound, name, errors.Join(errs...))
+	}
+	// only remember the misses when every provider answered and at least
+	// one of them looked remotely, a timeout or a failed request may work
+	// next time, and a chain with only the store may get providers later
+	if c.store != nil && len(errs) == 0 && remoteMisses > 0 && ctx.Err() == nil {
+		if serr := c.store.StoreMiss(name, kind, err); serr != nil {
+			fmt.Println("failed to store icon miss for:", name, serr)
+		}
+	}
+	return Icon{}, err
+}
+
+func (c *Chain) find(ctx context.Context, l Link, name string, kind Kind) (Icon, error) {
+	if l.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.Timeout)
+		defer cancel()
+	}
+	return l.Provider.FindIcon(ctx, name, kind)
+}
@@ -0,0 +1,94 @@
+package icons
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeProvider answers every lookup with the same image or error.
+type fakeProvider struct {
+	icon  Icon
+	err   error
+	calls int
+}
+
+func (p *fakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *fakeProvider) FindIcon(ctx context.Context, name string, kind Kind) (Icon, error) {
+	p.calls++
+	return p.icon, p.err
+}
+
+func TestChainMisses(t *testing.T) {
+	tests := []struct {
+		name   string
+		remote *fakeProvider
+		missed bool
+	}{
+		{"only the store", nil, false},
+		{"remote not found", &fakeProvider{err: ErrNotFound}, true},
+		{"remote failed", &fakeProvider{err: errors.New("503")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewStore(t.TempDir())
+			links := []Link{{Provider: store}}
+			if tt.remote != nil {
+				links = append(links, Link{Provider: tt.remote})
+			}
+			chain := NewChain(links...).WithStore(store)
+
+			if _, err := chain.FindIcon(context.Background(), "Hades", BoxArt); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("err = %v, want ErrNotFound", err)
+			}
+			if got := store.Missed("Hades", BoxArt); got != tt.missed {
+				t.Errorf("missed = %v, want %v", got, tt.missed)
+			}
+		})
+	}
+}
+
+func TestChainSkipsRecentMisses(t *testing.T) {
+	store := NewStore(t.TempDir())
+	remote := &fakeProvider{err: ErrNotFound}
+	chain := NewChain(Link{Provider: store}, Link{Provider: remote}).WithStore(store)
+
+	for range 2 {
+		if _, err := chain.FindIcon(context.Background(), "Hades", BoxArt); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("err = %v, want ErrNotFound", err)
+		}
+	}
+	if remote.calls != 1 {
+		t.Errorf("remote was asked %d times, want 1", remote.calls)
+	}
+}
+
+func TestChainStoresRemoteIcons(t *testing.T) {
+	store := NewStore(t.TempDir())
+	png := []byte("\x89PNG\r\n\x1a\n")
+	remote := &fakeProvider{icon: Icon{Data: png, SourceURL: "https://example.com/hades.png"}}
+	chain := NewChain(Link{Provider: store}, Link{Provider: remote}).WithStore(store)
+
+	for range 2 {
+		icon, err := chain.FindIcon(context.Background(), "Hades", BoxArt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(icon.Data) != string(png) {
+			t.Errorf("data = %q", icon.Data)
+		}
+	}
+	if remote.calls != 1 {
+		t.Errorf("remote was asked %d times, want 1", remote.calls)
+	}
+	asset, ok, err := store.Asset("Hades", BoxArt)
+	if err != nil || !ok {
+		t.Fatalf("asset = %v, %v", ok, err)
+	}
+	if asset.Provider != "fake" || asset.SourceURL != "https://example.com/hades.png" {
+		t.Errorf("asset = %+v", asset)
+	}
+}
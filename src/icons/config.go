@@ -0,0 +1,224 @@
+package icons
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mehanizm/airtable"
+)
+
+const (
+	DefaultLogoProviders   = "local,serper"
+	DefaultBoxArtProviders = "local,serper"
+	DefaultTimeout         = 10 * time.Second
+)
+
+// Config has the credentials and endpoints of every provider, and the
+// order they are chained for logos and box art. Each chain is a comma
+// separated list of provider names, each optionally followed by its own
+// timeout, like "local,steamgriddb:5s,serper".
+type Config struct {
+	AssetsFolder    string
+	LogoProviders   string
+	BoxArtProviders string
+	Timeout         time.Duration
+	// RetryAfter is how long lookups that failed are not retried.
+	RetryAfter time.Duration
+
+	SerperAPIKey  string
+	SerperBaseURL string
+
+	SteamGridDBAPIKey  string
+	SteamGridDBBaseURL string
+
+	IGDBClientID     string
+	IGDBAccessToken  string
+	IGDBBaseURL      string
+	IGDBImageBaseURL string
+
+	RAWGAPIKey  string
+	RAWGBaseURL string
+
+	AirtableAPIKey       string
+	AirtableBaseURL      string
+	AirtableBaseID       string
+	AirtableNameField    string
+	AirtableLogoTables   []string
+	AirtableLogoField    string
+	AirtableBoxArtTables []string
+	AirtableBoxArtField  string
+}
+
+// ConfigFromEnv reads the config from the environment, with defaults for
+// everything but the credentials.
+func ConfigFromEnv(assetsFolder string) Config {
+	timeout, err := time.ParseDuration(os.Getenv("ICON_PROVIDER_TIMEOUT"))
+	if err != nil {
+		timeout = DefaultTimeout
+	}
+	retryAfter, err := time.ParseDuration(os.Getenv("ICON_RETRY_AFTER"))
+	if err != nil {
+		retryAfter = DefaultRetryAfter
+	}
+	return Config{
+		AssetsFolder:    getenv("ASSETS_FOLDER", assetsFolder),
+		RetryAfter:      retryAfter,
+		LogoProviders:   getenv("ICON_LOGO_PROVIDERS", DefaultLogoProviders),
+		BoxArtProviders: getenv("ICON_BOXART_PROVIDERS", DefaultBoxArtProviders),
+		Timeout:         timeout,
+
+		SerperAPIKey:  os.Getenv("SERPER_API_KEY"),
+		SerperBaseURL: os.Getenv("SERPER_BASE_URL"),
+
+		SteamGridDBAPIKey:  os.Getenv("STEAMGRIDDB_API_KEY"),
+		SteamGridDBBaseURL: os.Getenv("STEAMGRIDDB_BASE_URL"),
+
+		IGDBClientID:     os.Getenv("IGDB_CLIENT_ID"),
+		IGDBAccessToken:  os.Getenv("IGDB_ACCESS_TOKEN"),
+		IGDBBaseURL:      os.Getenv("IGDB_BASE_URL"),
+		IGDBImageBaseURL: os.Getenv("IGDB_IMAGE_BASE_URL"),
+
+		RAWGAPIKey:  os.Getenv("RAWG_API_KEY"),
+		RAWGBaseURL: os.Getenv("RAWG_BASE_URL"),
+
+		AirtableAPIKey:       os.Getenv("AIRTABLE_API_KEY"),
+		AirtableBaseURL:      os.Getenv("AIRTABLE_BASE_URL"),
+		AirtableBaseID:       os.Getenv("AIRTABLE_ICONS_BASE_ID"),
+		AirtableNameField:    getenv("AIRTABLE_NAME_FIELD", "Name"),
+		AirtableLogoTables:   strings.Split(getenv("AIRTABLE_LOGO_TABLES", "Consoles,Platforms"), ","),
+		AirtableLogoField:    getenv("AIRTABLE_LOGO_FIELD", "Logo"),
+		AirtableBoxArtTables: strings.Split(getenv("AIRTABLE_BOXART_TABLES", "Games"), ","),
+		AirtableBoxArtField:  getenv("AIRTABLE_BOXART_FIELD", "Box Art"),
+	}
+}
+
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// Store opens the asset store in the assets folder.
+func (c Config) Store() (*Store, error) {
+	store, err := OpenStore(c.AssetsFolder)
+	if err != nil {
+		return nil, err
+	}
+	if c.RetryAfter > 0 {
+		store.RetryAfter = c.RetryAfter
+	}
+	return store, nil
+}
+
+// Chains builds the chains for logos and box art, sharing the asset store
+// where the images found remotely are saved.
+func (c Config) Chains() (logos *Chain, boxArt *Chain, err error) {
+	store, err := c.Store()
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.ChainsWithStore(store)
+}
+
+// ChainsWithStore builds the chains for logos and box art around a store
+// that is already open.
+func (c Config) ChainsWithStore(store *Store) (logos *Chain, boxArt *Chain, err error) {
+	logos, err = c.Chain(c.LogoProviders, Logo, store)
+	if err != nil {
+		return nil, nil, err
+	}
+	boxArt, err = c.Chain(c.BoxArtProviders, BoxArt, store)
+	if err != nil {
+		return nil, nil, err
+	}
+	return logos, boxArt, nil
+}
+
+// Chain builds a chain from its spec. Providers without credentials are
+// left out.
+func (c Config) Chain(spec string, kind Kind, store *Store) (*Chain, error) {
+	links := []Link{}
+	for _, entry := range strings.Split(spec, ",") {
+		name, timeoutStr, _ := strings.Cut(strings.TrimSpace(entry), ":")
+		if name == "" {
+			continue
+		}
+		timeout := c.Timeout
+		if timeoutStr != "" {
+			var err error
+			timeout, err = time.ParseDuration(timeoutStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout for icon provider %s: %v", name, err)
+			}
+		}
+		provider, err := c.newProvider(name, kind, store)
+		if err != nil {
+			return nil, err
+		}
+		if provider == nil {
+			log.Printf("skipping %s icon provider for %s: no credentials", name, kind)
+			continue
+		}
+		links = append(links, Link{Provider: provider, Timeout: timeout})
+	}
+	return NewChain(links...).WithStore(store), nil
+}
+
+func (c Config) newProvider(name string, kind Kind, store *Store) (IconProvider, error) {
+	switch name {
+	case "local":
+		return store, nil
+	case "serper":
+		if c.SerperAPIKey == "" {
+			return nil, nil
+		}
+		return &Serper{APIKey: c.SerperAPIKey, BaseURL: c.SerperBaseURL}, nil
+	case "steamgriddb":
+		if c.SteamGridDBAPIKey == "" {
+			return nil, nil
+		}
+		return &SteamGridDB{APIKey: c.SteamGridDBAPIKey, BaseURL: c.SteamGridDBBaseURL}, nil
+	case "igdb":
+		if c.IGDBClientID == "" || c.IGDBAccessToken == "" {
+			return nil, nil
+		}
+		return &IGDB{
+			ClientID:     c.IGDBClientID,
+			AccessToken:  c.IGDBAccessToken,
+			BaseURL:      c.IGDBBaseURL,
+			ImageBaseURL: c.IGDBImageBaseURL,
+		}, nil
+	case "rawg":
+		if c.RAWGAPIKey == "" {
+			return nil, nil
+		}
+		return &RAWG{APIKey: c.RAWGAPIKey, BaseURL: c.RAWGBaseURL}, nil
+	case "airtable":
+		if c.AirtableAPIKey == "" || c.AirtableBaseID == "" {
+			return nil, nil
+		}
+		client := airtable.NewClient(c.AirtableAPIKey)
+		if c.AirtableBaseURL != "" {
+			if err := client.SetBaseURL(c.AirtableBaseURL); err != nil {
+				return nil, fmt.Errorf("invalid airtable base url: %v", err)
+			}
+		}
+		tables, field := c.AirtableLogoTables, c.AirtableLogoField
+		if kind == BoxArt {
+			tables, field = c.AirtableBoxArtTables, c.AirtableBoxArtField
+		}
+		return &AirtableAttachments{
+			Client:    client,
+			BaseID:    c.AirtableBaseID,
+			Tables:    tables,
+			NameField: c.AirtableNameField,
+			Field:     field,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown icon provider %q", name)
+	}
+}
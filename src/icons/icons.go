@@ -0,0 +1,113 @@
+package icons
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Kind is the kind of image to look for.
+type Kind int
+
+const (
+	// Logo is used for consoles, platforms and game series.
+	Logo Kind = iota
+	// BoxArt is used for games.
+	BoxArt
+)
+
+// Slug is how the kind is written in the assets manifest.
+func (k Kind) Slug() string {
+	switch k {
+	case Logo:
+		return "logo"
+	case BoxArt:
+		return "box_art"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseKind reads a kind from its slug or its name.
+func ParseKind(s string) (Kind, error) {
+	switch strings.ReplaceAll(strings.ToLower(s), "-", "_") {
+	case "logo":
+		return Logo, nil
+	case "box_art", "boxart", "box art":
+		return BoxArt, nil
+	default:
+		return Logo, fmt.Errorf("unknown icon kind %q", s)
+	}
+}
+
+func (k Kind) String() string {
+	switch k {
+	case Logo:
+		return "logo"
+	case BoxArt:
+		return "box art"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrNotFound is returned by providers that have no image for a name, so
+// the chain moves on to the next one without reporting an error.
+var ErrNotFound = errors.New("icon not found")
+
+// Icon is an image encoded as it was downloaded.
+type Icon struct {
+	Data []byte
+	// SourceURL is where the image was downloaded from, empty for local
+	// images.
+	SourceURL string
+}
+
+// IconProvider finds the image for a console, platform or game by name.
+type IconProvider interface {
+	Name() string
+	FindIcon(ctx context.Context, name string, kind Kind) (Icon, error)
+}
+
+// Download fetches an image from a URL, like the ones picked by hand.
+func Download(ctx context.Context, url string) (Icon, error) {
+	return download(ctx, nil, url)
+}
+
+func download(ctx context.Context, client *http.Client, url string) (Icon, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Icon{}, err
+	}
+	data, err := do(client, req)
+	if err != nil {
+		return Icon{}, err
+	}
+	return Icon{Data: data, SourceURL: url}, nil
+}
+
+func do(client *http.Client, req *http.Request) ([]byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if res.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status %s from %s", res.Status, req.URL.Host)
+	}
+	return body, nil
+}
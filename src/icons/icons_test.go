@@ -0,0 +1,48 @@
+package icons
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// apiServer serves the provider API with api, and the images it links to
+// under /files/. "$SERVER" in the answers of api is replaced with the URL
+// of the server.
+func apiServer(t *testing.T, api func(r *http.Request) (int, string)) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/files/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(testPNG)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		status, body := api(r)
+		w.WriteHeader(status)
+		w.Write([]byte(strings.ReplaceAll(body, "$SERVER", "http://"+r.Host)))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// checkIcon checks the icon found against the error wanted, matched with
+// errors.Is for ErrNotFound and by its message otherwise.
+func checkIcon(t *testing.T, icon Icon, err error, want error) {
+	t.Helper()
+	switch {
+	case want == nil && err != nil:
+		t.Fatalf("failed to find icon: %v", err)
+	case want == nil:
+		if string(icon.Data) != string(testPNG) {
+			t.Errorf("data = %q, want %q", icon.Data, testPNG)
+		}
+		if !strings.Contains(icon.SourceURL, "/files/") {
+			t.Errorf("source = %q, want an image of the server", icon.SourceURL)
+		}
+	case errors.Is(want, ErrNotFound) && !errors.Is(err, ErrNotFound):
+		t.Errorf("err = %v, want ErrNotFound", err)
+	case err == nil || !strings.Contains(err.Error(), want.Error()):
+		t.Errorf("err = %v, want %v", err, want)
+	}
+}
@@ -0,0 +1,77 @@
+package icons
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	DefaultIGDBBaseURL      = "https://api.igdb.com/v4"
+	DefaultIGDBImageBaseURL = "https://images.igdb.com/igdb/image/upload"
+)
+
+// IGDB uses the game covers as box art and the platform logos otherwise,
+// authenticating with a Twitch client.
+type IGDB struct {
+	ClientID     string
+	AccessToken  string
+	BaseURL      string
+	ImageBaseURL string
+	Client       *http.Client
+}
+
+var _ IconProvider = &IGDB{}
+
+type igdbImage struct {
+	ImageID string `json:"image_id"`
+}
+
+type igdbResult struct {
+	Cover        *igdbImage `json:"cover"`
+	PlatformLogo *igdbImage `json:"platform_logo"`
+}
+
+func (i *IGDB) Name() string {
+	return "igdb"
+}
+
+func (i *IGDB) FindIcon(ctx context.Context, name string, kind Kind) (Icon, error) {
+	name = strings.ReplaceAll(name, `"`, `\"`)
+	endpoint := "/platforms"
+	query := fmt.Sprintf(`fields name,platform_logo.image_id; where name ~ "%s"; limit 1;`, name)
+	if kind == BoxArt {
+		endpoint = "/games"
+		query = fmt.Sprintf(`search "%s"; fields name,cover.image_id; limit 1;`, name)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL(i.BaseURL, DefaultIGDBBaseURL)+endpoint, strings.NewReader(query))
+	if err != nil {
+		return Icon{}, err
+	}
+	req.Header.Add("Client-ID", i.ClientID)
+	req.Header.Add("Authorization", "Bearer "+i.AccessToken)
+	body, err := do(i.Client, req)
+	if err != nil {
+		return Icon{}, err
+	}
+	results := []igdbResult{}
+	if err := json.Unmarshal(body, &results); err != nil {
+		return Icon{}, err
+	}
+	if len(results) == 0 {
+		return Icon{}, ErrNotFound
+	}
+
+	image, size, ext := results[0].PlatformLogo, "t_logo_med", "png"
+	if kind == BoxArt {
+		image, size, ext = results[0].Cover, "t_cover_big", "jpg"
+	}
+	if image == nil || image.ImageID == "" {
+		return Icon{}, ErrNotFound
+	}
+	url := fmt.Sprintf("%s/%s/%s.%s", baseURL(i.ImageBaseURL, DefaultIGDBImageBaseURL), size, image.ImageID, ext)
+	return download(ctx, i.Client, url)
+}
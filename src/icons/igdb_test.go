@@ -0,0 +1,65 @@
+package icons
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestIGDB(t *testing.T) {
+	tests := []struct {
+		name   string
+		kind   Kind
+		game   string
+		path   string
+		query  string
+		status int
+		body   string
+		err    error
+	}{
+		{"logo", Logo, "Nintendo Switch", "/platforms", `fields name,platform_logo.image_id; where name ~ "Nintendo Switch"; limit 1;`, http.StatusOK, `[{"platform_logo": {"image_id": "switch"}}]`, nil},
+		{"box art", BoxArt, `Hades "II"`, "/games", `search "Hades \"II\""; fields name,cover.image_id; limit 1;`, http.StatusOK, `[{"cover": {"image_id": "hades"}}]`, nil},
+		{"no results", BoxArt, "Hades", "/games", `search "Hades"; fields name,cover.image_id; limit 1;`, http.StatusOK, `[]`, ErrNotFound},
+		{"no cover", BoxArt, "Hades", "/games", `search "Hades"; fields name,cover.image_id; limit 1;`, http.StatusOK, `[{"platform_logo": {"image_id": "hades"}}]`, ErrNotFound},
+		{"bad token", Logo, "Nintendo Switch", "/platforms", `fields name,platform_logo.image_id; where name ~ "Nintendo Switch"; limit 1;`, http.StatusUnauthorized, `{"message": "Authorization Failure"}`, errors.New("unexpected status 401")},
+		{"too many requests", Logo, "Nintendo Switch", "/platforms", `fields name,platform_logo.image_id; where name ~ "Nintendo Switch"; limit 1;`, http.StatusTooManyRequests, ``, errors.New("unexpected status 429")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := apiServer(t, func(r *http.Request) (int, string) {
+				if r.Method != http.MethodPost || r.URL.Path != tt.path {
+					t.Errorf("request = %s %s, want POST %s", r.Method, r.URL.Path, tt.path)
+				}
+				if got := r.Header.Get("Client-ID"); got != "client" {
+					t.Errorf("Client-ID = %q, want client", got)
+				}
+				if got := r.Header.Get("Authorization"); got != "Bearer token" {
+					t.Errorf("Authorization = %q, want Bearer token", got)
+				}
+				query, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Errorf("failed to read request: %v", err)
+				}
+				if string(query) != tt.query {
+					t.Errorf("query = %s, want %s", query, tt.query)
+				}
+				return tt.status, tt.body
+			})
+
+			igdb := &IGDB{ClientID: "client", AccessToken: "token", BaseURL: srv.URL, ImageBaseURL: srv.URL + "/files"}
+			icon, err := igdb.FindIcon(context.Background(), tt.game, tt.kind)
+			checkIcon(t, icon, err, tt.err)
+			if err == nil {
+				want := srv.URL + "/files/t_logo_med/switch.png"
+				if tt.kind == BoxArt {
+					want = srv.URL + "/files/t_cover_big/hades.jpg"
+				}
+				if icon.SourceURL != want {
+					t.Errorf("source = %s, want %s", icon.SourceURL, want)
+				}
+			}
+		})
+	}
+}
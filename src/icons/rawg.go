@@ -0,0 +1,57 @@
+package icons
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+const DefaultRAWGBaseURL = "https://api.rawg.io/api"
+
+// RAWG uses the background image of a game as box art. It has no logos.
+type RAWG struct {
+	APIKey  string
+	BaseURL string
+	Client  *http.Client
+}
+
+var _ IconProvider = &RAWG{}
+
+type rawgGamesResponse struct {
+	Results []struct {
+		Name            string `json:"name"`
+		BackgroundImage string `json:"background_image"`
+	} `json:"results"`
+}
+
+func (r *RAWG) Name() string {
+	return "rawg"
+}
+
+func (r *RAWG) FindIcon(ctx context.Context, name string, kind Kind) (Icon, error) {
+	if kind != BoxArt {
+		return Icon{}, ErrNotFound
+	}
+	params := url.Values{}
+	params.Set("search", name)
+	params.Set("page_size", "1")
+	params.Set("key", r.APIKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL(r.BaseURL, DefaultRAWGBaseURL)+"/games?"+params.Encode(), nil)
+	if err != nil {
+		return Icon{}, err
+	}
+	body, err := do(r.Client, req)
+	if err != nil {
+		return Icon{}, err
+	}
+	res := rawgGamesResponse{}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return Icon{}, err
+	}
+	if len(res.Results) == 0 || res.Results[0].BackgroundImage == "" {
+		return Icon{}, ErrNotFound
+	}
+	return download(ctx, r.Client, res.Results[0].BackgroundImage)
+}
@@ -0,0 +1,49 @@
+package icons
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestRAWG(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     Kind
+		status   int
+		body     string
+		requests int
+		err      error
+	}{
+		{"box art", BoxArt, http.StatusOK, `{"results": [{"name": "Hades", "background_image": "$SERVER/files/hades.jpg"}]}`, 1, nil},
+		{"no logos", Logo, http.StatusOK, ``, 0, ErrNotFound},
+		{"no results", BoxArt, http.StatusOK, `{"results": []}`, 1, ErrNotFound},
+		{"no image", BoxArt, http.StatusOK, `{"results": [{"name": "Hades", "background_image": ""}]}`, 1, ErrNotFound},
+		{"bad key", BoxArt, http.StatusUnauthorized, `{"error": "The key parameter is not provided"}`, 1, errors.New("unexpected status 401")},
+		{"server error", BoxArt, http.StatusBadGateway, ``, 1, errors.New("unexpected status 502")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			srv := apiServer(t, func(r *http.Request) (int, string) {
+				requests++
+				if r.Method != http.MethodGet || r.URL.Path != "/games" {
+					t.Errorf("request = %s %s, want GET /games", r.Method, r.URL.Path)
+				}
+				query := r.URL.Query()
+				if query.Get("search") != "Hades II" || query.Get("page_size") != "1" || query.Get("key") != "key" {
+					t.Errorf("query = %s, want the search, page size and key", r.URL.RawQuery)
+				}
+				return tt.status, tt.body
+			})
+
+			rawg := &RAWG{APIKey: "key", BaseURL: srv.URL}
+			icon, err := rawg.FindIcon(context.Background(), "Hades II", tt.kind)
+			checkIcon(t, icon, err, tt.err)
+			if requests != tt.requests {
+				t.Errorf("requests = %d, want %d", requests, tt.requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package icons
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const DefaultSerperBaseURL = "https://google.serper.dev"
+
+// Serper searches Google Images, taking the first result that is not from
+// a wiki.
+type Serper struct {
+	APIKey  string
+	BaseURL string
+	Client  *http.Client
+}
+
+var _ IconProvider = &Serper{}
+
+type serperImagesResponse struct {
+	Images []serperImageResponse `json:"images"`
+}
+
+type serperImageResponse struct {
+	Title    string `json:"title"`
+	ImageURL string `json:"imageUrl"`
+}
+
+func (s *Serper) Name() string {
+	return "serper"
+}
+
+func (s *Serper) FindIcon(ctx context.Context, name string, kind Kind) (Icon, error) {
+	url, err := s.findImageURL(ctx, name, kind)
+	if err != nil {
+		return Icon{}, err
+	}
+	return download(ctx, s.Client, url)
+}
+
+func (s *Serper) findImageURL(ctx context.Context, name string, kind Kind) (string, error) {
+	query := name
+	if kind == BoxArt {
+		query = fmt.Sprintf("%s box art", name)
+	}
+	payload, err := json.Marshal(map[string]string{"q": query})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL(s.BaseURL, DefaultSerperBaseURL)+"/images", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("X-API-KEY", s.APIKey)
+	req.Header.Add("Content-Type", "application/json")
+
+	body, err := do(s.Client, req)
+	if err != nil {
+		return "", err
+	}
+	var res serperImagesResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return "", err
+	}
+	for _, img := range res.Images {
+		if strings.Contains(img.ImageURL, "wikia") || strings.Contains(img.ImageURL, "wikimedia") {
+			continue
+		}
+		return img.ImageURL, nil
+	}
+	return "", ErrNotFound
+}
+
+func baseURL(url, fallback string) string {
+	if url == "" {
+		return fallback
+	}
+	return strings.TrimSuffix(url, "/")
+}
@@ -0,0 +1,60 @@
+package icons
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestSerper(t *testing.T) {
+	tests := []struct {
+		name   string
+		kind   Kind
+		query  string
+		status int
+		body   string
+		err    error
+	}{
+		{"logo", Logo, "Nintendo Switch", http.StatusOK, `{"images": [{"imageUrl": "$SERVER/files/switch.png"}]}`, nil},
+		{"box art", BoxArt, "Hades box art", http.StatusOK, `{"images": [{"imageUrl": "$SERVER/files/hades.png"}]}`, nil},
+		{"skips wikis", Logo, "Nintendo Switch", http.StatusOK, `{"images": [{"imageUrl": "https://static.wikia.nocookie.net/switch.png"}, {"imageUrl": "$SERVER/files/switch.png"}]}`, nil},
+		{"only wikis", Logo, "Nintendo Switch", http.StatusOK, `{"images": [{"imageUrl": "https://upload.wikimedia.org/switch.png"}]}`, ErrNotFound},
+		{"no images", Logo, "Nintendo Switch", http.StatusOK, `{"images": []}`, ErrNotFound},
+		{"not found", Logo, "Nintendo Switch", http.StatusNotFound, `{}`, ErrNotFound},
+		{"bad key", Logo, "Nintendo Switch", http.StatusForbidden, `{"message": "Unauthorized"}`, errors.New("unexpected status 403")},
+		{"server error", Logo, "Nintendo Switch", http.StatusInternalServerError, ``, errors.New("unexpected status 500")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := apiServer(t, func(r *http.Request) (int, string) {
+				if r.Method != http.MethodPost || r.URL.Path != "/images" {
+					t.Errorf("request = %s %s, want POST /images", r.Method, r.URL.Path)
+				}
+				if got := r.Header.Get("X-API-KEY"); got != "key" {
+					t.Errorf("X-API-KEY = %q, want key", got)
+				}
+				if got := r.Header.Get("Content-Type"); got != "application/json" {
+					t.Errorf("Content-Type = %q, want application/json", got)
+				}
+				payload := map[string]string{}
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+				}
+				if payload["q"] != tt.query {
+					t.Errorf("q = %q, want %q", payload["q"], tt.query)
+				}
+				return tt.status, tt.body
+			})
+
+			serper := &Serper{APIKey: "key", BaseURL: srv.URL + "/"}
+			name := "Nintendo Switch"
+			if tt.kind == BoxArt {
+				name = "Hades"
+			}
+			icon, err := serper.FindIcon(context.Background(), name, tt.kind)
+			checkIcon(t, icon, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package icons
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const DefaultSteamGridDBBaseURL = "https://www.steamgriddb.com/api/v2"
+
+// SteamGridDB uses the vertical grids of a game as box art and its logo
+// otherwise.
+type SteamGridDB struct {
+	APIKey  string
+	BaseURL string
+	Client  *http.Client
+}
+
+var _ IconProvider = &SteamGridDB{}
+
+type steamGridDBResponse[T any] struct {
+	Success bool `json:"success"`
+	Data    []T  `json:"data"`
+}
+
+type steamGridDBGame struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type steamGridDBImage struct {
+	URL string `json:"url"`
+}
+
+func (s *SteamGridDB) Name() string {
+	return "steamgriddb"
+}
+
+func (s *SteamGridDB) FindIcon(ctx context.Context, name string, kind Kind) (Icon, error) {
+	games := steamGridDBResponse[steamGridDBGame]{}
+	if err := s.get(ctx, "/search/autocomplete/"+url.PathEscape(name), &games); err != nil {
+		return Icon{}, err
+	}
+	if len(games.Data) == 0 {
+		return Icon{}, ErrNotFound
+	}
+
+	path := fmt.Sprintf("/logos/game/%d", games.Data[0].ID)
+	if kind == BoxArt {
+		path = fmt.Sprintf("/grids/game/%d?dimensions=600x900", games.Data[0].ID)
+	}
+	images := steamGridDBResponse[steamGridDBImage]{}
+	if err := s.get(ctx, path, &images); err != nil {
+		return Icon{}, err
+	}
+	if len(images.Data) == 0 {
+		return Icon{}, ErrNotFound
+	}
+	return download(ctx, s.Client, images.Data[0].URL)
+}
+
+func (s *SteamGridDB) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL(s.BaseURL, DefaultSteamGridDBBaseURL)+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Authorization", "Bearer "+s.APIKey)
+	body, err := do(s.Client, req)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
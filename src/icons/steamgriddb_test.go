@@ -0,0 +1,66 @@
+package icons
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestSteamGridDB(t *testing.T) {
+	games := `{"success": true, "data": [{"id": 42, "name": "Hades"}]}`
+	images := `{"success": true, "data": [{"url": "$SERVER/files/hades.png"}]}`
+	empty := `{"success": true, "data": []}`
+
+	tests := []struct {
+		name   string
+		kind   Kind
+		path   string
+		status int
+		games  string
+		images string
+		err    error
+	}{
+		{"logo", Logo, "/logos/game/42", http.StatusOK, games, images, nil},
+		{"box art", BoxArt, "/grids/game/42?dimensions=600x900", http.StatusOK, games, images, nil},
+		{"no games", BoxArt, "", http.StatusOK, empty, images, ErrNotFound},
+		{"no images", BoxArt, "/grids/game/42?dimensions=600x900", http.StatusOK, games, empty, ErrNotFound},
+		{"not found", BoxArt, "", http.StatusNotFound, `{"success": false}`, images, ErrNotFound},
+		{"bad key", BoxArt, "", http.StatusUnauthorized, `{"success": false}`, images, errors.New("unexpected status 401")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := []string{}
+			srv := apiServer(t, func(r *http.Request) (int, string) {
+				requests = append(requests, r.URL.RequestURI())
+				if r.Method != http.MethodGet {
+					t.Errorf("method = %s, want GET", r.Method)
+				}
+				if got := r.Header.Get("Authorization"); got != "Bearer key" {
+					t.Errorf("Authorization = %q, want Bearer key", got)
+				}
+				if r.URL.Path == "/search/autocomplete/Hades II" {
+					return tt.status, tt.games
+				}
+				return tt.status, tt.images
+			})
+
+			steamGridDB := &SteamGridDB{APIKey: "key", BaseURL: srv.URL}
+			icon, err := steamGridDB.FindIcon(context.Background(), "Hades II", tt.kind)
+			checkIcon(t, icon, err, tt.err)
+
+			want := []string{"/search/autocomplete/Hades%20II"}
+			if tt.path != "" {
+				want = append(want, tt.path)
+			}
+			if len(requests) != len(want) {
+				t.Fatalf("requests = %v, want %v", requests, want)
+			}
+			for i := range want {
+				if requests[i] != want[i] {
+					t.Errorf("request %d = %s, want %s", i, requests[i], want[i])
+				}
+			}
+		})
+	}
+}
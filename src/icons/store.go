@@ -0,0 +1,570 @@
+package icons
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/util"
+)
+
+const (
+	ManifestFile       = "manifest.json"
+	DefaultRetryAfter  = 24 * time.Hour
+	legacyProviderName = "legacy"
+)
+
+// ErrBadMatch is returned when storing an image that was already flagged
+// as a bad match for the name, so the chain tries the next provider.
+var ErrBadMatch = errors.New("image flagged as a bad match")
+
+// Asset is an image in the store.
+type Asset struct {
+	Name        string    `json:"name"`
+	Kind        string    `json:"kind"`
+	File        string    `json:"file"`
+	ContentType string    `json:"content_type"`
+	SourceURL   string    `json:"source_url,omitempty"`
+	Provider    string    `json:"provider"`
+	SHA256      string    `json:"sha256"`
+	FetchedAt   time.Time `json:"fetched_at"`
+	// Pinned assets are never replaced or removed.
+	Pinned bool `json:"pinned,omitempty"`
+	// BadMatch assets are not used, and the same image is not stored again.
+	BadMatch bool `json:"bad_match,omitempty"`
+}
+
+// Miss is a lookup that no provider could answer.
+type Miss struct {
+	Name       string    `json:"name"`
+	Kind       string    `json:"kind"`
+	Error      string    `json:"error,omitempty"`
+	FailedAt   time.Time `json:"failed_at"`
+	RetryAfter time.Time `json:"retry_after"`
+}
+
+type manifest struct {
+	Assets map[string]*Asset `json:"assets"`
+	Misses map[string]*Miss  `json:"misses"`
+	// BadHashes are the checksums of the images flagged as bad matches for
+	// each name, kept after the asset is replaced.
+	BadHashes map[string][]string `json:"bad_hashes,omitempty"`
+}
+
+// Store keeps the images in a folder, with a JSON manifest recording where
+// each one came from. It's also the "local" provider of the chains.
+type Store struct {
+	dir string
+	// RetryAfter is how long failed lookups are not retried.
+	RetryAfter time.Duration
+
+	mu       sync.Mutex
+	loaded   bool
+	manifest manifest
+}
+
+var (
+	_ IconProvider = &Store{}
+	_ Storer       = &Store{}
+)
+
+// NewStore returns a store for the folder, which is read on first use.
+func NewStore(dir string) *Store {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return &Store{dir: dir, RetryAfter: DefaultRetryAfter}
+}
+
+// OpenStore returns a store for the folder, creating it if needed.
+func OpenStore(dir string) (*Store, error) {
+	s := NewStore(dir)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+func key(name string, kind Kind) string {
+	return kind.Slug() + "/" + util.ToSnakecase(name)
+}
+
+// load reads the manifest, importing the images from before the manifest
+// existed, named after the snake case name only, as both logo and box art.
+func (s *Store) load() error {
+	if s.loaded {
+		return nil
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create assets folder: %v", err)
+	}
+	s.manifest = manifest{Assets: map[string]*Asset{}, Misses: map[string]*Miss{}, BadHashes: map[string][]string{}}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, ManifestFile))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &s.manifest); err != nil {
+			return fmt.Errorf("failed to parse assets manifest: %v", err)
+		}
+		if s.manifest.Assets == nil {
+			s.manifest.Assets = map[string]*Asset{}
+		}
+		if s.manifest.Misses == nil {
+			s.manifest.Misses = map[string]*Miss{}
+		}
+		if s.manifest.BadHashes == nil {
+			s.manifest.BadHashes = map[string][]string{}
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		if err := s.importLegacy(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("failed to read assets manifest: %v", err)
+	}
+	s.loaded = true
+	return nil
+}
+
+func (s *Store) importLegacy() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to list assets folder: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".png" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(entry.Name(), ".png")
+		for _, kind := range []Kind{Logo, BoxArt} {
+			s.manifest.Assets[key(name, kind)] = &Asset{
+				Name:        name,
+				Kind:        kind.Slug(),
+				File:        entry.Name(),
+				ContentType: http.DetectContentType(data),
+				Provider:    legacyProviderName,
+				SHA256:      checksum(data),
+				FetchedAt:   info.ModTime().UTC(),
+			}
+		}
+	}
+	return s.save()
+}
+
+// save writes the manifest atomically. Must be called with the lock held.
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(s.dir, ManifestFile), data)
+}
+
+// writeFileAtomic writes to a temporary file in the same folder and then
+// renames it, so readers never see a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+var extensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+	"image/bmp":  ".bmp",
+}
+
+func isImageOrTemp(file string) bool {
+	if strings.HasPrefix(file, ".tmp-") {
+		return true
+	}
+	ext := strings.ToLower(filepath.Ext(file))
+	for _, e := range extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return ext == ".jpeg"
+}
+
+func (s *Store) Name() string {
+	return "local"
+}
+
+func (s *Store) FindIcon(ctx context.Context, name string, kind Kind) (Icon, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return Icon{}, err
+	}
+	asset, ok := s.manifest.Assets[key(name, kind)]
+	if !ok || asset.BadMatch {
+		return Icon{}, ErrNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, asset.File))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Icon{}, ErrNotFound
+	}
+	if err != nil {
+		return Icon{}, err
+	}
+	return Icon{Data: data, SourceURL: asset.SourceURL}, nil
+}
+
+// Store saves the image with an extension matching its content, unless
+// the current one is pinned.
+func (s *Store) Store(name string, kind Kind, icon Icon, provider string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	previous := s.manifest.Assets[key(name, kind)]
+	if previous != nil && previous.Pinned {
+		return nil
+	}
+	if slices.Contains(s.manifest.BadHashes[key(name, kind)], checksum(icon.Data)) {
+		return ErrBadMatch
+	}
+	return s.put(name, kind, icon, Asset{Provider: provider})
+}
+
+// Pin saves the image and pins it, replacing the current one even if it
+// was pinned.
+func (s *Store) Pin(name string, kind Kind, icon Icon, provider string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	return s.put(name, kind, icon, Asset{Provider: provider, Pinned: true})
+}
+
+// put writes the image and records it with the provider and flags of meta.
+// Must be called with the lock held.
+func (s *Store) put(name string, kind Kind, icon Icon, meta Asset) error {
+	contentType := http.DetectContentType(icon.Data)
+	ext, ok := extensions[contentType]
+	if !ok {
+		return fmt.Errorf("unsupported image type %s", contentType)
+	}
+	file := strings.ReplaceAll(util.ToSnakecase(name), "/", "_") + "." + kind.Slug() + ext
+	if err := writeFileAtomic(filepath.Join(s.dir, file), icon.Data); err != nil {
+		return err
+	}
+	k := key(name, kind)
+	s.manifest.Assets[k] = &Asset{
+		Name:        name,
+		Kind:        kind.Slug(),
+		File:        file,
+		ContentType: contentType,
+		SourceURL:   icon.SourceURL,
+		Provider:    meta.Provider,
+		SHA256:      checksum(icon.Data),
+		FetchedAt:   time.Now().UTC(),
+		Pinned:      meta.Pinned,
+		BadMatch:    meta.BadMatch,
+	}
+	if meta.BadMatch {
+		s.flagBadHash(k, s.manifest.Assets[k].SHA256)
+	}
+	delete(s.manifest.Misses, k)
+	return s.save()
+}
+
+// Asset returns the asset for the name, if there is one.
+func (s *Store) Asset(name string, kind Kind) (Asset, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return Asset{}, false, err
+	}
+	asset, ok := s.manifest.Assets[key(name, kind)]
+	if !ok {
+		return Asset{}, false, nil
+	}
+	return *asset, true, nil
+}
+
+// SetPinned pins or unpins the current asset for the name.
+func (s *Store) SetPinned(name string, kind Kind, pinned bool) error {
+	return s.update(name, kind, func(a *Asset) {
+		a.Pinned = pinned
+	})
+}
+
+// MarkBadMatch flags the current asset for the name as a bad match, so it
+// is not used and the same image is not saved again, even after it is
+// replaced by another one.
+func (s *Store) MarkBadMatch(name string, kind Kind) error {
+	return s.update(name, kind, func(a *Asset) {
+		a.BadMatch = true
+		a.Pinned = false
+		s.flagBadHash(key(name, kind), a.SHA256)
+	})
+}
+
+// flagBadHash adds the checksum to the bad matches of the key. Must be
+// called with the lock held.
+func (s *Store) flagBadHash(k, hash string) {
+	if !slices.Contains(s.manifest.BadHashes[k], hash) {
+		s.manifest.BadHashes[k] = append(s.manifest.BadHashes[k], hash)
+	}
+}
+
+func (s *Store) update(name string, kind Kind, fn func(a *Asset)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	asset, ok := s.manifest.Assets[key(name, kind)]
+	if !ok {
+		return fmt.Errorf("%w for %s", ErrNotFound, name)
+	}
+	fn(asset)
+	return s.save()
+}
+
+// Forget removes the failed lookup for the name, so it is retried.
+func (s *Store) Forget(name string, kind Kind) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	k := key(name, kind)
+	if _, ok := s.manifest.Misses[k]; !ok {
+		return nil
+	}
+	delete(s.manifest.Misses, k)
+	return s.save()
+}
+
+// Export writes the manifest.
+func (s *Store) Export(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s.manifest)
+}
+
+// Import reads a manifest written by Export, downloading again from their
+// source the images that are not in the store, and keeping their pinned
+// and bad match flags. It returns the names of the assets that could not
+// be imported, like the ones without a source URL.
+func (s *Store) Import(ctx context.Context, r io.Reader) ([]string, error) {
+	other := manifest{}
+	if err := json.NewDecoder(r).Decode(&other); err != nil {
+		return nil, fmt.Errorf("failed to parse assets manifest: %v", err)
+	}
+
+	skipped := []string{}
+	for _, a := range other.Assets {
+		kind, err := ParseKind(a.Kind)
+		if err != nil {
+			skipped = append(skipped, a.Name)
+			continue
+		}
+		current, ok, err := s.Asset(a.Name, kind)
+		if err != nil {
+			return skipped, err
+		}
+		if ok && current.SHA256 == a.SHA256 {
+			err := s.update(a.Name, kind, func(c *Asset) {
+				c.Pinned = a.Pinned
+				c.BadMatch = a.BadMatch
+				if a.BadMatch {
+					s.flagBadHash(key(a.Name, kind), c.SHA256)
+				}
+			})
+			if err != nil {
+				return skipped, err
+			}
+			continue
+		}
+		if a.SourceURL == "" {
+			skipped = append(skipped, a.Name)
+			continue
+		}
+		icon, err := download(ctx, nil, a.SourceURL)
+		if err != nil {
+			skipped = append(skipped, a.Name)
+			continue
+		}
+		s.mu.Lock()
+		err = s.put(a.Name, kind, icon, *a)
+		s.mu.Unlock()
+		if err != nil {
+			return skipped, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return skipped, err
+	}
+	for k, hashes := range other.BadHashes {
+		for _, hash := range hashes {
+			s.flagBadHash(k, hash)
+		}
+	}
+	sort.Strings(skipped)
+	return skipped, s.save()
+}
+
+func (s *Store) StoreMiss(name string, kind Kind, lookupErr error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	miss := &Miss{
+		Name:       name,
+		Kind:       kind.Slug(),
+		FailedAt:   now,
+		RetryAfter: now.Add(s.RetryAfter),
+	}
+	if lookupErr != nil {
+		miss.Error = lookupErr.Error()
+	}
+	s.manifest.Misses[key(name, kind)] = miss
+	return s.save()
+}
+
+func (s *Store) Missed(name string, kind Kind) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return false
+	}
+	miss, ok := s.manifest.Misses[key(name, kind)]
+	return ok && time.Now().Before(miss.RetryAfter)
+}
+
+// Assets returns a copy of every asset in the manifest, sorted by kind and
+// name.
+func (s *Store) Assets() ([]Asset, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	assets := make([]Asset, 0, len(s.manifest.Assets))
+	for _, a := range s.manifest.Assets {
+		assets = append(assets, *a)
+	}
+	sort.Slice(assets, func(i, j int) bool {
+		if assets[i].Kind != assets[j].Kind {
+			return assets[i].Kind < assets[j].Kind
+		}
+		return assets[i].Name < assets[j].Name
+	})
+	return assets, nil
+}
+
+// GC removes the images in the folder that are not in the manifest, and
+// the expired misses. When keep is given, the assets it rejects are
+// removed from the manifest first, except the pinned ones. It returns the
+// removed files.
+func (s *Store) GC(keep func(a Asset) bool) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	if keep != nil {
+		for k, a := range s.manifest.Assets {
+			if !a.Pinned && !keep(*a) {
+				delete(s.manifest.Assets, k)
+			}
+		}
+	}
+	now := time.Now()
+	for k, m := range s.manifest.Misses {
+		if now.After(m.RetryAfter) {
+			delete(s.manifest.Misses, k)
+		}
+	}
+	if err := s.save(); err != nil {
+		return nil, err
+	}
+
+	referenced := map[string]bool{ManifestFile: true}
+	for _, a := range s.manifest.Assets {
+		referenced[a.File] = true
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	removed := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() || referenced[entry.Name()] || !isImageOrTemp(entry.Name()) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, entry.Name())); err != nil {
+			return removed, err
+		}
+		removed = append(removed, entry.Name())
+	}
+	return removed, nil
+}
@@ -0,0 +1,193 @@
+package icons
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+var testPNG = []byte("\x89PNG\r\n\x1a\n")
+
+func TestStoreMisses(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter time.Duration
+		store      bool
+		missed     bool
+	}{
+		{"recent miss", time.Hour, false, true},
+		{"expired miss", -time.Second, false, false},
+		{"found after the miss", time.Hour, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			store := NewStore(dir)
+			store.RetryAfter = tt.retryAfter
+			if err := store.StoreMiss("Hades", BoxArt, errors.New("not found")); err != nil {
+				t.Fatal(err)
+			}
+			if tt.store {
+				if err := store.Store("Hades", BoxArt, Icon{Data: testPNG}, "fake"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := store.Missed("Hades", BoxArt); got != tt.missed {
+				t.Errorf("missed = %v, want %v", got, tt.missed)
+			}
+			if store.Missed("Hades", Logo) {
+				t.Errorf("the logo was missed too")
+			}
+			// the misses are kept in the manifest
+			if got := NewStore(dir).Missed("Hades", BoxArt); got != tt.missed {
+				t.Errorf("missed after reopening = %v, want %v", got, tt.missed)
+			}
+		})
+	}
+}
+
+func TestStoreGC(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+	for _, name := range []string{"Hades", "Celeste"} {
+		if err := store.Store(name, BoxArt, Icon{Data: testPNG}, "fake"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Pin("Zelda", Logo, Icon{Data: testPNG}, "manual"); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"old.boxart.png", ".tmp-123", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.RetryAfter = -time.Second
+	if err := store.StoreMiss("Expired", BoxArt, nil); err != nil {
+		t.Fatal(err)
+	}
+	store.RetryAfter = time.Hour
+	if err := store.StoreMiss("Recent", BoxArt, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	celeste, _, err := store.Asset("Celeste", BoxArt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only Hades is still used, but Zelda is pinned
+	removed, err := store.GC(func(a Asset) bool { return a.Name == "Hades" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(removed)
+	want := []string{".tmp-123", celeste.File, "old.boxart.png"}
+	slices.Sort(want)
+	if !slices.Equal(removed, want) {
+		t.Errorf("removed %v, want %v", removed, want)
+	}
+
+	reopened := NewStore(dir)
+	for _, tt := range []struct {
+		name string
+		kind Kind
+		want bool
+	}{
+		{"Hades", BoxArt, true},
+		{"Celeste", BoxArt, false},
+		{"Zelda", Logo, true},
+	} {
+		if _, ok, err := reopened.Asset(tt.name, tt.kind); err != nil || ok != tt.want {
+			t.Errorf("%s asset = %v, %v, want %v", tt.name, ok, err, tt.want)
+		}
+	}
+	if !reopened.Missed("Recent", BoxArt) {
+		t.Errorf("recent miss was removed")
+	}
+	if _, ok := reopened.manifest.Misses[key("Expired", BoxArt)]; ok {
+		t.Errorf("expired miss was kept")
+	}
+	for _, file := range []string{ManifestFile, "notes.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+			t.Errorf("%s was removed: %v", file, err)
+		}
+	}
+
+	// a second run has nothing left to remove
+	if removed, err := reopened.GC(nil); err != nil || len(removed) != 0 {
+		t.Errorf("second run removed %v, %v", removed, err)
+	}
+}
+
+func TestStoreBadMatches(t *testing.T) {
+	bad := Icon{Data: testPNG}
+	other := Icon{Data: append(slices.Clone(testPNG), "other"...)}
+
+	tests := []struct {
+		name  string
+		icons []Icon
+		err   error
+	}{
+		{"same image", []Icon{bad}, ErrBadMatch},
+		{"another image", []Icon{other}, nil},
+		{"back after it was replaced", []Icon{other, bad}, ErrBadMatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			store := NewStore(dir)
+			if err := store.Store("Hades", BoxArt, bad, "fake"); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.MarkBadMatch("Hades", BoxArt); err != nil {
+				t.Fatal(err)
+			}
+
+			// the flagged images are kept in the manifest
+			store = NewStore(dir)
+			var err error
+			for _, icon := range tt.icons {
+				err = store.Store("Hades", BoxArt, icon, "fake")
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+			// the flag is per name
+			if err := store.Store("Celeste", BoxArt, bad, "fake"); err != nil {
+				t.Errorf("failed to store the image for another name: %v", err)
+			}
+		})
+	}
+}
+
+func TestStoreImportBadMatches(t *testing.T) {
+	store := NewStore(t.TempDir())
+	if err := store.Store("Hades", BoxArt, Icon{Data: testPNG}, "fake"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.MarkBadMatch("Hades", BoxArt); err != nil {
+		t.Fatal(err)
+	}
+	exported := bytes.Buffer{}
+	if err := store.Export(&exported); err != nil {
+		t.Fatal(err)
+	}
+
+	imported := NewStore(t.TempDir())
+	skipped, err := imported.Import(context.Background(), &exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the image has no source to download it from, but stays flagged
+	if !slices.Equal(skipped, []string{"Hades"}) {
+		t.Errorf("skipped %v, want Hades", skipped)
+	}
+	if err := imported.Store("Hades", BoxArt, Icon{Data: testPNG}, "fake"); !errors.Is(err, ErrBadMatch) {
+		t.Errorf("err = %v, want ErrBadMatch", err)
+	}
+}
@@ -0,0 +1,443 @@
+package imagegen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/i18n"
+	"golang.org/x/sync/errgroup"
+)
+
+// frame is how far along the animation each part of a chart is, from 0 to
+// 1. Static charts are drawn at the last frame.
+type frame struct {
+	title float64
+	// bars is the progress of each bar, when nil they are all done
+	bars []float64
+}
+
+var lastFrame = frame{title: 1}
+
+func (f frame) bar(i int) float64 {
+	if f.bars == nil {
+		return 1
+	}
+	return f.bars[i]
+}
+
+type Easing string
+
+const (
+	Linear    Easing = "linear"
+	EaseIn    Easing = "ease-in"
+	EaseOut   Easing = "ease-out"
+	EaseInOut Easing = "ease-in-out"
+	// EaseOutBack overshoots the end a little before settling.
+	EaseOutBack Easing = "ease-out-back"
+)
+
+var easings = []Easing{Linear, EaseIn, EaseOut, EaseInOut, EaseOutBack}
+
+func ParseEasing(s string) (Easing, error) {
+	for _, e := range easings {
+		if string(e) == s {
+			return e, nil
+		}
+	}
+	names := make([]string, len(easings))
+	for i, e := range easings {
+		names[i] = string(e)
+	}
+	return "", fmt.Errorf("unknown easing %q, use one of %s", s, strings.Join(names, ", "))
+}
+
+// apply maps the linear progress t, from 0 to 1, to the eased one.
+func (e Easing) apply(t float64) float64 {
+	switch e {
+	case Linear:
+		return t
+	case EaseIn:
+		return t * t * t
+	case EaseInOut:
+		if t < 0.5 {
+			return 4 * t * t * t
+		}
+		return 1 - math.Pow(-2*t+2, 3)/2
+	case EaseOutBack:
+		const c1 = 1.70158
+		return 1 + (c1+1)*math.Pow(t-1, 3) + c1*math.Pow(t-1, 2)
+	default:
+		return 1 - math.Pow(1-t, 3)
+	}
+}
+
+// Animation is how a chart is animated. Zero frames, duration and easing
+// use the ones of DefaultAnimation, while a zero hold doesn't stop at the
+// end.
+type Animation struct {
+	// Frames is how many frames it takes for the chart to build up.
+	Frames int
+	// Duration is how long it takes for the chart to build up.
+	Duration time.Duration
+	// Hold is how long the complete chart is shown before looping.
+	Hold   time.Duration
+	Easing Easing
+}
+
+var DefaultAnimation = Animation{
+	Frames:   45,
+	Duration: 3 * time.Second,
+	Hold:     3 * time.Second,
+	Easing:   EaseOut,
+}
+
+func (a Animation) withDefaults() Animation {
+	d := DefaultAnimation
+	if a.Frames <= 0 {
+		a.Frames = d.Frames
+	}
+	if a.Duration <= 0 {
+		a.Duration = d.Duration
+	}
+	a.Hold = max(a.Hold, 0)
+	if a.Easing == "" {
+		a.Easing = d.Easing
+	}
+	return a
+}
+
+// Validate keeps the animations within what is reasonable to render on
+// request.
+func (a Animation) Validate() error {
+	a = a.withDefaults()
+	if a.Frames < 2 || a.Frames > 300 {
+		return fmt.Errorf("frames must be between 2 and 300")
+	}
+	if a.Duration > time.Minute || a.Hold > time.Minute {
+		return fmt.Errorf("duration and hold must be up to a minute")
+	}
+	_, err := ParseEasing(string(a.Easing))
+	return err
+}
+
+// frameAt is how far along each part is at time t of the animation, from 0
+// to 1. The title fades in first, then the bars grow one after the other,
+// overlapping.
+func (a Animation) frameAt(t float64, bars int) frame {
+	clamp := func(v float64) float64 {
+		return min(max(v, 0), 1)
+	}
+	f := frame{title: a.Easing.apply(clamp(t / 0.2)), bars: make([]float64, bars)}
+	for i := range f.bars {
+		start := 0.15 + 0.35*float64(i)/float64(bars)
+		f.bars[i] = a.Easing.apply(clamp((t - start) / 0.5))
+	}
+	return f
+}
+
+// errNoFrames is returned when encoding an animation without frames.
+var errNoFrames = errors.New("animation has no frames")
+
+// Animated is the frame sequence of an animated chart.
+type Animated struct {
+	Frames []image.Image
+	// Delay is how long each frame is shown, other than the last one.
+	Delay time.Duration
+	// Hold is how long the last frame is shown before looping.
+	Hold time.Duration
+}
+
+// AnimateMostPlayedWrapped renders the frames of the bar chart, with the
+// title fading in, the bars growing to their value and the metrics
+// counting up. The frames are always raster, whatever the format of the
+// options.
+func AnimateMostPlayedWrapped(ctx context.Context, title string, data []BarChartItem, n int, opts Options, a Animation) (*Animated, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	a = a.withDefaults()
+	data = data[:min(n, len(data))]
+	icons := prefetchIcons(ctx, data)
+	opts.Format, opts.Document = PNG, nil
+
+	frames := make([]image.Image, a.Frames)
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(runtime.NumCPU())
+	for k := range frames {
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			c := newChart(opts)
+			c.drawMostPlayed(title, data, n, icons, a.frameAt(float64(k)/float64(a.Frames-1), len(data)))
+			frames[k] = c.surface.(*raster).Image()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return &Animated{Frames: frames, Delay: a.Duration / time.Duration(a.Frames), Hold: a.Hold}, nil
+}
+
+type AnimationFormat string
+
+const (
+	GIF  AnimationFormat = "gif"
+	APNG AnimationFormat = "apng"
+	// Frames saves each frame as a PNG in a folder, to encode them with
+	// other tools like ffmpeg.
+	Frames AnimationFormat = "frames"
+)
+
+func ParseAnimationFormat(s string) (AnimationFormat, error) {
+	switch f := AnimationFormat(strings.ToLower(strings.TrimSpace(s))); f {
+	case GIF, APNG, Frames:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown animation format %q, use gif, apng or frames", s)
+	}
+}
+
+func (f AnimationFormat) ContentType() string {
+	if f == APNG {
+		return "image/apng"
+	}
+	return "image/gif"
+}
+
+// Ext is the extension of the animation files, APNG files are still PNG.
+func (f AnimationFormat) Ext() string {
+	switch f {
+	case APNG:
+		return ".png"
+	case Frames:
+		return ""
+	default:
+		return ".gif"
+	}
+}
+
+func (a *Animated) Encode(w io.Writer, format AnimationFormat) error {
+	switch format {
+	case GIF:
+		return a.EncodeGIF(w)
+	case APNG:
+		return a.EncodeAPNG(w)
+	default:
+		return fmt.Errorf("%s animations can not be encoded to a single file", format)
+	}
+}
+
+// Save writes the animation to the file, or to the folder for Frames.
+func (a *Animated) Save(path string, format AnimationFormat) error {
+	if format == Frames {
+		return a.SaveFrames(path)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return a.Encode(f, format)
+}
+
+// SaveFrames writes the frames as numbered PNG files, repeating the last
+// one for the hold, so they play at a constant frame rate.
+func (a *Animated) SaveFrames(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create frames folder: %v", err)
+	}
+	if len(a.Frames) == 0 {
+		return errNoFrames
+	}
+	frames := a.Frames
+	if a.Delay > 0 {
+		for range int(a.Hold / a.Delay) {
+			frames = append(frames, a.Frames[len(a.Frames)-1])
+		}
+	}
+	for i, img := range frames {
+		if err := saveFrame(filepath.Join(dir, fmt.Sprintf("frame_%04d.png", i+1)), img); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func saveFrame(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return png.Encode(f, img)
+}
+
+// EncodeGIF writes the frames with a palette of the colors of the complete
+// chart, dithering the ones that are not in it.
+func (a *Animated) EncodeGIF(w io.Writer) error {
+	if len(a.Frames) == 0 {
+		return errNoFrames
+	}
+	palette := paletteOf(a.Frames[len(a.Frames)-1])
+	out := &gif.GIF{
+		Image: make([]*image.Paletted, len(a.Frames)),
+		Delay: make([]int, len(a.Frames)),
+	}
+	var g errgroup.Group
+	g.SetLimit(runtime.NumCPU())
+	for i, img := range a.Frames {
+		g.Go(func() error {
+			out.Image[i] = quantize(img, palette)
+			return nil
+		})
+		// delays are in hundredths of a second, and browsers slow down
+		// anything under two
+		out.Delay[i] = max(2, int(a.Delay/(10*time.Millisecond)))
+	}
+	g.Wait()
+	out.Delay[len(a.Frames)-1] = max(2, int(a.Hold/(10*time.Millisecond)))
+	return gif.EncodeAll(w, out)
+}
+
+// paletteOf picks the 256 most used colors of the image, grouping close
+// ones, which keeps the flat colors of a chart exact.
+func paletteOf(img image.Image) color.Palette {
+	type bucket struct {
+		r, g, b, n int
+	}
+	buckets := map[uint32]*bucket{}
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			key := uint32(c.R>>3)<<10 | uint32(c.G>>3)<<5 | uint32(c.B>>3)
+			b, ok := buckets[key]
+			if !ok {
+				b = &bucket{}
+				buckets[key] = b
+			}
+			b.r += int(c.R)
+			b.g += int(c.G)
+			b.b += int(c.B)
+			b.n++
+		}
+	}
+	sorted := make([]*bucket, 0, len(buckets))
+	for _, b := range buckets {
+		sorted = append(sorted, b)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].n > sorted[j].n
+	})
+	palette := color.Palette{}
+	for _, b := range sorted[:min(256, len(sorted))] {
+		palette = append(palette, color.RGBA{R: uint8(b.r / b.n), G: uint8(b.g / b.n), B: uint8(b.b / b.n), A: 255})
+	}
+	return palette
+}
+
+// quantize draws the image with the palette, diffusing the error of the
+// colors that are not in it like draw.FloydSteinberg. The closest color of
+// each one is cached, which is most of the work, since charts have few.
+func quantize(img image.Image, palette color.Palette) *image.Paletted {
+	b := img.Bounds()
+	out := image.NewPaletted(b, palette)
+	closest := map[color.RGBA]uint8{}
+	// errors of the current and the next row, three channels per pixel
+	// with one pixel of padding on each side
+	current := make([]int32, 3*(b.Dx()+2))
+	next := make([]int32, 3*(b.Dx()+2))
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			i := 3 * (x - b.Min.X + 1)
+			var c color.RGBA
+			if rgba, ok := img.(*image.RGBA); ok {
+				c = rgba.RGBAAt(x, y)
+			} else {
+				c = color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			}
+			c = color.RGBA{
+				R: clampByte(int32(c.R) + current[i]/16),
+				G: clampByte(int32(c.G) + current[i+1]/16),
+				B: clampByte(int32(c.B) + current[i+2]/16),
+				A: 255,
+			}
+			index, ok := closest[c]
+			if !ok {
+				index = uint8(palette.Index(c))
+				closest[c] = index
+			}
+			out.SetColorIndex(x, y, index)
+
+			p := palette[index].(color.RGBA)
+			diff := [3]int32{int32(c.R) - int32(p.R), int32(c.G) - int32(p.G), int32(c.B) - int32(p.B)}
+			for k, e := range diff {
+				current[i+3+k] += 7 * e
+				next[i-3+k] += 3 * e
+				next[i+k] += 5 * e
+				next[i+3+k] += e
+			}
+		}
+		current, next = next, current
+		clear(next)
+	}
+	return out
+}
+
+func clampByte(v int32) uint8 {
+	return uint8(min(max(v, 0), 255))
+}
+
+// withAlpha multiplies the alpha of the hex color.
+func withAlpha(hex string, alpha float64) string {
+	if alpha >= 1 {
+		return hex
+	}
+	c, err := parseHexColor(hex)
+	if err != nil {
+		return hex
+	}
+	nc := c.(color.NRGBA)
+	return fmt.Sprintf("#%02x%02x%02x%02x", nc.R, nc.G, nc.B, uint8(float64(nc.A)*max(alpha, 0)))
+}
+
+// numberPattern matches the numbers written in the language, like "1,234"
+// or "1.234,5".
+func numberPattern(l *i18n.Locale) *regexp.Regexp {
+	decimal, group := l.Separators()
+	return regexp.MustCompile(`\d+(?:` + regexp.QuoteMeta(group) + `\d{3})*(?:` + regexp.QuoteMeta(decimal) + `\d+)?`)
+}
+
+// countUp scales the numbers in the rendered metric by the progress,
+// keeping their decimals, so "120h" is "60h" halfway through.
+func countUp(l *i18n.Locale, metric string, progress float64) string {
+	if progress == 1 {
+		return metric
+	}
+	decimal, group := l.Separators()
+	return numberPattern(l).ReplaceAllStringFunc(metric, func(number string) string {
+		whole, fraction, _ := strings.Cut(strings.ReplaceAll(number, group, ""), decimal)
+		v, err := strconv.ParseFloat(whole+"."+fraction, 64)
+		if err != nil {
+			return number
+		}
+		return l.Float(v*max(progress, 0), len(fraction))
+	})
+}
@@ -0,0 +1,116 @@
+package imagegen
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/i18n"
+)
+
+func TestAnimateMostPlayedWrappedFrames(t *testing.T) {
+	canvas, err := ParseCanvas("270x480")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []BarChartItem{
+		MostPlayedByPlaytime{Title: "Nintendo Switch", Playtime: 120, NoIcon: true},
+		MostPlayedByPlaytime{Title: "Steam Deck", Playtime: 40, NoIcon: true},
+	}
+	render := func(frames int) (*Animated, error) {
+		return AnimateMostPlayedWrapped(context.Background(), "Most played", data, len(data), Options{Canvas: canvas}, Animation{Frames: frames})
+	}
+
+	for _, frames := range []int{1, 301} {
+		if _, err := render(frames); err == nil {
+			t.Errorf("%d frames didn't fail", frames)
+		}
+	}
+
+	animated, err := render(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(animated.Frames) != 2 {
+		t.Fatalf("got %d frames, want 2", len(animated.Frames))
+	}
+	for _, format := range []AnimationFormat{GIF, APNG} {
+		if err := animated.Encode(&bytes.Buffer{}, format); err != nil {
+			t.Errorf("failed to encode %s: %v", format, err)
+		}
+	}
+}
+
+func TestEncodeWithoutFrames(t *testing.T) {
+	empty := &Animated{}
+	for _, format := range []AnimationFormat{GIF, APNG} {
+		if err := empty.Encode(&bytes.Buffer{}, format); !errors.Is(err, errNoFrames) {
+			t.Errorf("%s: err = %v, want errNoFrames", format, err)
+		}
+	}
+	if err := empty.SaveFrames(t.TempDir()); !errors.Is(err, errNoFrames) {
+		t.Errorf("frames: err = %v, want errNoFrames", err)
+	}
+}
+
+func TestFrameAt(t *testing.T) {
+	a := Animation{Easing: Linear}
+	for _, tt := range []float64{0, 0.5, 1} {
+		f := a.frameAt(tt, 3)
+		for i := range f.bars {
+			if math.IsNaN(f.bars[i]) || f.bars[i] < 0 || f.bars[i] > 1 {
+				t.Errorf("bar %d at %v = %v", i, tt, f.bars[i])
+			}
+		}
+	}
+	end := a.frameAt(1, 3)
+	if end.title != 1 || end.bar(0) != 1 || end.bar(2) != 1 {
+		t.Errorf("last frame = %+v", end)
+	}
+}
+
+func TestParseEasing(t *testing.T) {
+	for _, e := range easings {
+		got, err := ParseEasing(string(e))
+		if err != nil || got != e {
+			t.Errorf("ParseEasing(%q) = %q, %v", e, got, err)
+		}
+		if start, end := e.apply(0), e.apply(1); math.Abs(start) > 1e-9 || math.Abs(end-1) > 1e-9 {
+			t.Errorf("%s goes from %v to %v, want 0 to 1", e, start, end)
+		}
+	}
+	for _, s := range []string{"", "Linear", "bounce"} {
+		if _, err := ParseEasing(s); err == nil {
+			t.Errorf("ParseEasing(%q) didn't fail", s)
+		}
+	}
+}
+
+func TestCountUp(t *testing.T) {
+	tests := []struct {
+		locale   *i18n.Locale
+		metric   string
+		progress float64
+		want     string
+	}{
+		{i18n.English, "120h", 1, "120h"},
+		{i18n.English, "120h", 0.5, "60h"},
+		{i18n.English, "120h", 0, "0h"},
+		{i18n.English, "120h", -0.1, "0h"},
+		{i18n.English, "100h", 1.1, "110h"},
+		{i18n.English, "1,234h", 0.5, "617h"},
+		{i18n.English, "12.4", 0.5, "6.2"},
+		{i18n.English, "3 of 10 games", 0.5, "2 of 5 games"},
+		{i18n.English, "New", 0.5, "New"},
+		{i18n.Portuguese, "1.234,4h", 0.5, "617,2h"},
+		{i18n.Spanish, "12.345 juegos", 0.2, "2469 juegos"},
+		{i18n.Spanish, "1234 juegos", 0.5, "617 juegos"},
+	}
+	for _, tt := range tests {
+		if got := countUp(tt.locale, tt.metric, tt.progress); got != tt.want {
+			t.Errorf("%s: countUp(%q, %v) = %q, want %q", tt.locale.Tag, tt.metric, tt.progress, got, tt.want)
+		}
+	}
+}
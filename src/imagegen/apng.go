@@ -0,0 +1,125 @@
+package imagegen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image/png"
+	"io"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+type pngChunk struct {
+	kind string
+	data []byte
+}
+
+// EncodeAPNG writes the frames as an animated PNG, which keeps every color
+// of the charts. Each frame is encoded with image/png and its image data
+// moved into the animation chunks.
+func (a *Animated) EncodeAPNG(w io.Writer) error {
+	if len(a.Frames) == 0 {
+		return errNoFrames
+	}
+	chunks := []pngChunk{}
+	var ihdr []byte
+	sequence := uint32(0)
+	for i, img := range a.Frames {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return fmt.Errorf("failed to encode frame %d: %v", i, err)
+		}
+		frameChunks, err := readPNGChunks(buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("failed to read frame %d: %v", i, err)
+		}
+
+		delay := a.Delay
+		if i == len(a.Frames)-1 {
+			delay = a.Hold
+		}
+		b := img.Bounds()
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], sequence)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(b.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(b.Dy()))
+		// the offsets are zero, and the delay is in milliseconds
+		binary.BigEndian.PutUint16(fctl[20:], uint16(min(delay.Milliseconds(), 65535)))
+		binary.BigEndian.PutUint16(fctl[22:], 1000)
+		sequence++
+		chunks = append(chunks, pngChunk{"fcTL", fctl})
+
+		for _, c := range frameChunks {
+			switch c.kind {
+			case "IHDR":
+				if ihdr == nil {
+					ihdr = c.data
+				} else if !bytes.Equal(ihdr, c.data) {
+					return fmt.Errorf("frame %d has a different size or color type", i)
+				}
+			case "IDAT":
+				if i == 0 {
+					chunks = append(chunks, c)
+					continue
+				}
+				fdat := make([]byte, 4+len(c.data))
+				binary.BigEndian.PutUint32(fdat, sequence)
+				copy(fdat[4:], c.data)
+				sequence++
+				chunks = append(chunks, pngChunk{"fdAT", fdat})
+			}
+		}
+	}
+
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl, uint32(len(a.Frames)))
+	// zero plays loop forever
+	binary.BigEndian.PutUint32(actl[4:], 0)
+
+	if _, err := w.Write(pngSignature); err != nil {
+		return err
+	}
+	chunks = append([]pngChunk{{"IHDR", ihdr}, {"acTL", actl}}, chunks...)
+	chunks = append(chunks, pngChunk{"IEND", nil})
+	for _, c := range chunks {
+		if err := writePNGChunk(w, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readPNGChunks(data []byte) ([]pngChunk, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, fmt.Errorf("missing png signature")
+	}
+	data = data[len(pngSignature):]
+	chunks := []pngChunk{}
+	for len(data) >= 12 {
+		length := int(binary.BigEndian.Uint32(data))
+		if len(data) < 12+length {
+			return nil, fmt.Errorf("truncated %s chunk", data[4:8])
+		}
+		chunks = append(chunks, pngChunk{string(data[4:8]), data[8 : 8+length]})
+		data = data[12+length:]
+	}
+	return chunks, nil
+}
+
+func writePNGChunk(w io.Writer, c pngChunk) error {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(c.data)))
+	copy(header[4:], c.kind)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(c.data)
+	footer := binary.BigEndian.AppendUint32(nil, crc.Sum32())
+	for _, b := range [][]byte{header, c.data, footer} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
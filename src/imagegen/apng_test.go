@@ -0,0 +1,155 @@
+package imagegen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+)
+
+func TestWritePNGChunk(t *testing.T) {
+	tests := []struct {
+		chunk pngChunk
+		want  []byte
+	}{
+		{pngChunk{"IEND", nil}, []byte{0, 0, 0, 0, 'I', 'E', 'N', 'D', 0xae, 0x42, 0x60, 0x82}},
+		{pngChunk{"acTL", []byte{0, 0, 0, 2, 0, 0, 0, 0}}, nil},
+		{pngChunk{"fdAT", bytes.Repeat([]byte{7}, 300)}, nil},
+	}
+	for _, tt := range tests {
+		buf := bytes.Buffer{}
+		if err := writePNGChunk(&buf, tt.chunk); err != nil {
+			t.Fatal(err)
+		}
+		got := buf.Bytes()
+		if tt.want != nil && !bytes.Equal(got, tt.want) {
+			t.Errorf("%s = % x, want % x", tt.chunk.kind, got, tt.want)
+		}
+		if length := binary.BigEndian.Uint32(got); int(length) != len(tt.chunk.data) {
+			t.Errorf("%s length = %d, want %d", tt.chunk.kind, length, len(tt.chunk.data))
+		}
+		crc := binary.BigEndian.Uint32(got[len(got)-4:])
+		if want := crc32.ChecksumIEEE(got[4 : len(got)-4]); crc != want {
+			t.Errorf("%s crc = %x, want %x", tt.chunk.kind, crc, want)
+		}
+
+		chunks, err := readPNGChunks(append(append([]byte{}, pngSignature...), got...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chunks) != 1 || chunks[0].kind != tt.chunk.kind || !bytes.Equal(chunks[0].data, tt.chunk.data) {
+			t.Errorf("%s read back as %+v", tt.chunk.kind, chunks)
+		}
+	}
+}
+
+func TestReadPNGChunksErrors(t *testing.T) {
+	buf := bytes.Buffer{}
+	buf.Write(pngSignature)
+	writePNGChunk(&buf, pngChunk{"IDAT", []byte("data")})
+	data := buf.Bytes()
+
+	if _, err := readPNGChunks(data[1:]); err == nil {
+		t.Errorf("missing signature didn't fail")
+	}
+	if _, err := readPNGChunks(data[:len(data)-1]); err == nil {
+		t.Errorf("truncated chunk didn't fail")
+	}
+}
+
+func solidFrame(w, h int, c color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestEncodeAPNG(t *testing.T) {
+	colors := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}}
+	a := &Animated{Delay: 40 * time.Millisecond, Hold: 2 * time.Second}
+	for _, c := range colors {
+		a.Frames = append(a.Frames, solidFrame(8, 6, c))
+	}
+
+	buf := bytes.Buffer{}
+	if err := a.EncodeAPNG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := readPNGChunks(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := []string{}
+	for _, c := range chunks {
+		if len(kinds) == 0 || kinds[len(kinds)-1] != c.kind {
+			kinds = append(kinds, c.kind)
+		}
+	}
+	want := []string{"IHDR", "acTL", "fcTL", "IDAT", "fcTL", "fdAT", "fcTL", "fdAT", "IEND"}
+	if len(kinds) != len(want) {
+		t.Fatalf("chunks are %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("chunks are %v, want %v", kinds, want)
+		}
+	}
+
+	actl := chunks[1].data
+	if frames, plays := binary.BigEndian.Uint32(actl), binary.BigEndian.Uint32(actl[4:]); frames != 3 || plays != 0 {
+		t.Errorf("acTL has %d frames and %d plays, want 3 and 0", frames, plays)
+	}
+
+	// fcTL and fdAT share a sequence, without gaps
+	sequence, frame := uint32(0), 0
+	for _, c := range chunks {
+		switch c.kind {
+		case "fcTL":
+			if got := binary.BigEndian.Uint32(c.data); got != sequence {
+				t.Errorf("fcTL of frame %d has sequence %d, want %d", frame, got, sequence)
+			}
+			if w, h := binary.BigEndian.Uint32(c.data[4:]), binary.BigEndian.Uint32(c.data[8:]); w != 8 || h != 6 {
+				t.Errorf("frame %d is %dx%d, want 8x6", frame, w, h)
+			}
+			delay, unit := binary.BigEndian.Uint16(c.data[20:]), binary.BigEndian.Uint16(c.data[22:])
+			wantDelay := uint16(40)
+			if frame == len(colors)-1 {
+				wantDelay = 2000
+			}
+			if delay != wantDelay || unit != 1000 {
+				t.Errorf("frame %d delay is %d/%d, want %d/1000", frame, delay, unit, wantDelay)
+			}
+			sequence++
+			frame++
+		case "fdAT":
+			if got := binary.BigEndian.Uint32(c.data); got != sequence {
+				t.Errorf("fdAT has sequence %d, want %d", got, sequence)
+			}
+			sequence++
+		}
+	}
+
+	// viewers without APNG support show the first frame
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := color.RGBAModel.Convert(img.At(3, 3)); got != colors[0] {
+		t.Errorf("first frame is %v, want %v", got, colors[0])
+	}
+}
+
+func TestEncodeAPNGFrameSizes(t *testing.T) {
+	a := &Animated{Frames: []image.Image{solidFrame(8, 6, color.White), solidFrame(6, 8, color.White)}}
+	if err := a.EncodeAPNG(&bytes.Buffer{}); err == nil {
+		t.Errorf("frames of different sizes didn't fail")
+	}
+}
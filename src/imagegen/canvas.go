@@ -0,0 +1,130 @@
+package imagegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// baseSize is the shortest side, in points, the layout sizes like fonts
+// and margins are designed for.
+const baseSize = 540.0
+
+// Insets are the space kept clear on each side of a canvas, in pixels.
+type Insets struct {
+	Top    float64 `json:"top"`
+	Right  float64 `json:"right"`
+	Bottom float64 `json:"bottom"`
+	Left   float64 `json:"left"`
+}
+
+// Canvas is the size of a chart, in pixels. Scale multiplies the sizes of
+// the layout, and when zero it is picked from the shortest side. Nothing
+// is drawn over the safe area, other than the background.
+type Canvas struct {
+	Name     string  `json:"name"`
+	Width    int     `json:"width"`
+	Height   int     `json:"height"`
+	Scale    float64 `json:"scale,omitempty"`
+	SafeArea Insets  `json:"safe_area"`
+}
+
+var (
+	CanvasVertical   = Canvas{Name: "vertical", Width: 1080, Height: 1920}
+	CanvasHorizontal = Canvas{Name: "horizontal", Width: 1920, Height: 1080}
+	CanvasSquare     = Canvas{Name: "square", Width: 1080, Height: 1080}
+	// CanvasStory keeps clear the header and the reply box that stories
+	// show over the image.
+	CanvasStory     = Canvas{Name: "story", Width: 1080, Height: 1920, SafeArea: Insets{Top: 250, Bottom: 340}}
+	CanvasTwitter   = Canvas{Name: "twitter", Width: 1200, Height: 675}
+	CanvasWallpaper = Canvas{Name: "wallpaper", Width: 3840, Height: 2160}
+	// CanvasA4 is an A4 page at PrintDPI.
+	CanvasA4 = Canvas{Name: "a4", Width: 1240, Height: 1754}
+)
+
+var canvasPresets = []Canvas{
+	CanvasVertical,
+	CanvasHorizontal,
+	CanvasSquare,
+	CanvasStory,
+	CanvasTwitter,
+	CanvasWallpaper,
+	CanvasA4,
+}
+
+func CanvasNames() []string {
+	names := make([]string, len(canvasPresets))
+	for i, c := range canvasPresets {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// ParseCanvas reads the name of a preset, or a custom size like "1600x900",
+// optionally with its scale, like "1600x900@1.5".
+func ParseCanvas(spec string) (Canvas, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	for _, c := range canvasPresets {
+		if c.Name == spec {
+			return c, nil
+		}
+	}
+
+	size, scaleStr, hasScale := strings.Cut(spec, "@")
+	widthStr, heightStr, ok := strings.Cut(size, "x")
+	if !ok {
+		return Canvas{}, fmt.Errorf("unknown canvas %q, use one of %s or a size like 1600x900", spec, strings.Join(CanvasNames(), ", "))
+	}
+	width, err := strconv.Atoi(widthStr)
+	if err != nil {
+		return Canvas{}, fmt.Errorf("invalid canvas width %q", widthStr)
+	}
+	height, err := strconv.Atoi(heightStr)
+	if err != nil {
+		return Canvas{}, fmt.Errorf("invalid canvas height %q", heightStr)
+	}
+	if width < 100 || height < 100 || width > 8192 || height > 8192 {
+		return Canvas{}, fmt.Errorf("canvas size must be between 100 and 8192 pixels")
+	}
+	c := Canvas{Name: size, Width: width, Height: height}
+	if hasScale {
+		c.Scale, err = strconv.ParseFloat(scaleStr, 64)
+		if err != nil || c.Scale <= 0 || c.Scale > 16 {
+			return Canvas{}, fmt.Errorf("invalid canvas scale %q", scaleStr)
+		}
+		c.Name = spec
+	}
+	return c, nil
+}
+
+func (c Canvas) String() string {
+	return c.Name
+}
+
+func (c Canvas) scale() float64 {
+	if c.Scale > 0 {
+		return c.Scale
+	}
+	return float64(min(c.Width, c.Height)) / baseSize
+}
+
+// Portrait is true when the area charts are drawn in is taller than wide.
+func (c Canvas) Portrait() bool {
+	b := c.content()
+	return b.h > b.w
+}
+
+// box is an area of the canvas.
+type box struct {
+	x, y, w, h float64
+}
+
+// content is the canvas without the safe area.
+func (c Canvas) content() box {
+	return box{
+		x: c.SafeArea.Left,
+		y: c.SafeArea.Top,
+		w: float64(c.Width) - c.SafeArea.Left - c.SafeArea.Right,
+		h: float64(c.Height) - c.SafeArea.Top - c.SafeArea.Bottom,
+	}
+}
@@ -0,0 +1,143 @@
+package imagegen
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/icons"
+)
+
+func TestParseCanvas(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Canvas
+	}{
+		{"vertical", CanvasVertical},
+		{" Story ", CanvasStory},
+		{"a4", CanvasA4},
+		{"1600x900", Canvas{Name: "1600x900", Width: 1600, Height: 900}},
+		{"1600x900@1.5", Canvas{Name: "1600x900@1.5", Width: 1600, Height: 900, Scale: 1.5}},
+		{"100x8192", Canvas{Name: "100x8192", Width: 100, Height: 8192}},
+	}
+	for _, tt := range tests {
+		got, err := ParseCanvas(tt.spec)
+		if err != nil {
+			t.Errorf("ParseCanvas(%q) failed: %v", tt.spec, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseCanvas(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"", "portrait", "1600", "x900", "1600x", "wide x900", "99x900", "1600x8193", "1600x900@", "1600x900@0", "1600x900@-1", "1600x900@17"} {
+		if _, err := ParseCanvas(spec); err == nil {
+			t.Errorf("ParseCanvas(%q) didn't fail", spec)
+		}
+	}
+}
+
+func TestCanvasScale(t *testing.T) {
+	tests := []struct {
+		canvas   Canvas
+		scale    float64
+		portrait bool
+	}{
+		{CanvasVertical, 2, true},
+		{CanvasHorizontal, 2, false},
+		{CanvasSquare, 2, false},
+		{CanvasStory, 2, true},
+		{Canvas{Width: 1600, Height: 900, Scale: 1.5}, 1.5, false},
+		// the safe area leaves a wider box than the canvas
+		{Canvas{Width: 1000, Height: 1100, SafeArea: Insets{Top: 200}}, 1000 / baseSize, false},
+	}
+	for _, tt := range tests {
+		if got := tt.canvas.scale(); got != tt.scale {
+			t.Errorf("%+v scale = %v, want %v", tt.canvas, got, tt.scale)
+		}
+		if got := tt.canvas.Portrait(); got != tt.portrait {
+			t.Errorf("%+v portrait = %v, want %v", tt.canvas, got, tt.portrait)
+		}
+	}
+}
+
+// squareIcon gives the same icon for any name.
+type squareIcon struct{}
+
+func (squareIcon) Name() string { return "square" }
+
+func (squareIcon) FindIcon(ctx context.Context, name string, kind icons.Kind) (icons.Icon, error) {
+	buf := bytes.Buffer{}
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 64, 64))); err != nil {
+		return icons.Icon{}, err
+	}
+	return icons.Icon{Data: buf.Bytes()}, nil
+}
+
+// TestTinyCanvas draws full lists on the smallest canvas, where the rows
+// are too short for the icons, so the charts are drawn as without them.
+func TestTinyCanvas(t *testing.T) {
+	defer SetIconProviders(logoProvider, boxArtProvider)
+	SetIconProviders(squareIcon{}, squareIcon{})
+
+	canvas, err := ParseCanvas("100x100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{Canvas: canvas}
+	ctx := context.Background()
+	bars := func(noIcon bool) SaveableDrawing {
+		items := []BarChartItem{}
+		for i := range 10 {
+			items = append(items, MostPlayedByPlaytime{Title: fmt.Sprintf("Game %d", i), Playtime: float64(100 - i), Count: 1, NoIcon: noIcon})
+		}
+		return RenderMostPlayedWrapped(ctx, "Most played", items, len(items), opts)
+	}
+	comparison := func(noIcon bool) SaveableDrawing {
+		items := []ComparisonItem{}
+		for i := range 10 {
+			title := fmt.Sprintf("Game %d", i)
+			item := MostPlayedByPlaytime{Title: title, NoIcon: noIcon}
+			items = append(items, NewComparisonItem(title, "h", item, 50, 100-i, i+1, i+1))
+		}
+		return RenderComparisonWrapped(ctx, "2023 vs 2024", "2023", "2024", items, len(items), opts)
+	}
+	facts := func(noIcon bool) SaveableDrawing {
+		cards := []FactCard{}
+		for i := range 10 {
+			card := FactCard{Heading: "Most played", Value: "100h", Text: fmt.Sprintf("Game %d", i)}
+			if !noIcon {
+				card.Subject = card.Text
+			}
+			cards = append(cards, card)
+		}
+		return RenderFactCards(ctx, "Highlights", cards, opts)
+	}
+
+	tests := []struct {
+		name   string
+		render func(noIcon bool) SaveableDrawing
+	}{
+		{"bars", bars},
+		{"comparison", comparison},
+		{"facts", facts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			with, without := bytes.Buffer{}, bytes.Buffer{}
+			if err := tt.render(false).Encode(&with); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.render(true).Encode(&without); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(with.Bytes(), without.Bytes()) {
+				t.Errorf("the icons are drawn on a 100x100 canvas")
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package imagegen
+
+import "context"
+
+// Chart is a visualization of the stats, drawn with the theme and on the
+// canvas of the options.
+type Chart interface {
+	Render(ctx context.Context, opts Options) SaveableDrawing
+}
+
+// BarChart is the horizontal bar chart of RenderMostPlayedWrapped.
+type BarChart struct {
+	Title string
+	Items []BarChartItem
+	// Limit is how many items are shown, at most.
+	Limit int
+}
+
+func (b BarChart) Render(ctx context.Context, opts Options) SaveableDrawing {
+	return RenderMostPlayedWrapped(ctx, b.Title, b.Items, b.Limit, opts)
+}
+
+// FactCards are the cards of RenderFactCards.
+type FactCards struct {
+	Title string
+	Cards []FactCard
+}
+
+func (f FactCards) Render(ctx context.Context, opts Options) SaveableDrawing {
+	return RenderFactCards(ctx, f.Title, f.Cards, opts)
+}
@@ -0,0 +1,316 @@
+package imagegen
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+// coverAspect is the width over the height of the covers, like most box
+// art.
+const coverAspect = 3.0 / 4
+
+// CollageOrder is how the covers of a collage are laid out.
+type CollageOrder string
+
+const (
+	// CollageByPlaytime starts with the most played games, and makes the
+	// covers of the ones played much longer than the others larger.
+	CollageByPlaytime CollageOrder = "playtime"
+	// CollageByCompletion has every cover the same size, in the order the
+	// games were finished, and then the ones that were not.
+	CollageByCompletion CollageOrder = "completion"
+)
+
+func ParseCollageOrder(s string) (CollageOrder, error) {
+	switch o := CollageOrder(s); o {
+	case CollageByPlaytime, CollageByCompletion:
+		return o, nil
+	case "":
+		return CollageByPlaytime, nil
+	}
+	return "", fmt.Errorf("unknown collage order %q, use playtime or completion", s)
+}
+
+// Collage is a grid with the box art of every game played, cropped to the
+// same aspect ratio.
+type Collage struct {
+	Title        string
+	Playthroughs []Playthrough
+	Order        CollageOrder
+	// Captions writes the title and playtime over the bottom of each cover.
+	Captions bool
+	// Badges marks the status of each game on the top of its cover.
+	Badges bool
+}
+
+// cover is a game of the collage, with its playthroughs of the year added
+// up.
+type cover struct {
+	title    string
+	status   string
+	playtime float64
+	start    *time.Time
+	end      *time.Time
+}
+
+func (cv cover) Icon() (IconRef, bool) {
+	return IconRef{Name: cv.title, BoxArt: true}, true
+}
+
+// covers has a cover per game, with the status of its last playthrough and
+// the date it was last finished.
+func (cl Collage) covers() []cover {
+	covers := []cover{}
+	index := map[string]int{}
+	for _, p := range cl.Playthroughs {
+		i, ok := index[p.Title]
+		if !ok {
+			i = len(covers)
+			index[p.Title] = i
+			covers = append(covers, cover{title: p.Title})
+		}
+		cv := &covers[i]
+		cv.playtime += p.Playtime
+		if !ok || (p.StartDate != nil && (cv.start == nil || !p.StartDate.Before(*cv.start))) {
+			cv.start = cmp.Or(p.StartDate, cv.start)
+			cv.status = p.Status
+		}
+		if p.EndDate != nil && p.Status != "Abandoned" && (cv.end == nil || p.EndDate.After(*cv.end)) {
+			cv.end = p.EndDate
+		}
+	}
+
+	if cl.Order == CollageByCompletion {
+		slices.SortStableFunc(covers, func(a, b cover) int {
+			return compareDates(a.end, b.end, a.start, b.start)
+		})
+	} else {
+		slices.SortStableFunc(covers, func(a, b cover) int {
+			return cmp.Compare(b.playtime, a.playtime)
+		})
+	}
+	return covers
+}
+
+// compareDates orders by the end dates first, and by the start dates the
+// games that were not finished, with the ones without dates last.
+func compareDates(aEnd, bEnd, aStart, bStart *time.Time) int {
+	switch {
+	case aEnd != nil && bEnd != nil:
+		return aEnd.Compare(*bEnd)
+	case aEnd != nil:
+		return -1
+	case bEnd != nil:
+		return 1
+	case aStart != nil && bStart != nil:
+		return aStart.Compare(*bStart)
+	case aStart != nil:
+		return -1
+	case bStart != nil:
+		return 1
+	}
+	return 0
+}
+
+// spans is how many cells wide and high each cover is. Ordered by
+// playtime, the games played at least twice as long as the median get two,
+// up to a fifth of them.
+func (cl Collage) spans(covers []cover) []int {
+	spans := make([]int, len(covers))
+	for i := range spans {
+		spans[i] = 1
+	}
+	if cl.Order == CollageByCompletion || len(covers) < 5 {
+		return spans
+	}
+	median := covers[len(covers)/2].playtime
+	for i := 0; i < len(covers)/5 && covers[i].playtime >= 2*median && median > 0; i++ {
+		spans[i] = 2
+	}
+	return spans
+}
+
+// collageTile is where a cover is placed on the grid, in cells.
+type collageTile struct {
+	col, row, span int
+}
+
+// packCollage places the covers in order, each one on the first free cells
+// it fits, and returns how many rows it took.
+func packCollage(spans []int, cols int) ([]collageTile, int) {
+	taken := [][]bool{}
+	free := func(col, row, span int) bool {
+		if col+span > cols {
+			return false
+		}
+		for r := row; r < row+span && r < len(taken); r++ {
+			for c := col; c < col+span; c++ {
+				if taken[r][c] {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	tiles := make([]collageTile, len(spans))
+	rows := 0
+	for i, span := range spans {
+		span = min(span, cols)
+		for cell := 0; ; cell++ {
+			col, row := cell%cols, cell/cols
+			if !free(col, row, span) {
+				continue
+			}
+			for len(taken) < row+span {
+				taken = append(taken, make([]bool, cols))
+			}
+			for r := row; r < row+span; r++ {
+				for c := col; c < col+span; c++ {
+					taken[r][c] = true
+				}
+			}
+			tiles[i] = collageTile{col: col, row: row, span: span}
+			rows = max(rows, row+span)
+			break
+		}
+	}
+	return tiles, rows
+}
+
+func (cl Collage) Render(ctx context.Context, opts Options) SaveableDrawing {
+	covers := cl.covers()
+	icons := prefetchIcons(ctx, covers)
+	c := newChart(opts)
+	margin := 20.0 * c.scale
+	top := c.drawTitle(cl.Title, margin)
+
+	if len(covers) == 0 {
+		return c.drawing()
+	}
+
+	// the columns are the ones that make the covers largest
+	left := c.box.x + 1.5*margin
+	width := c.box.w - 3*margin
+	height := c.box.y + c.box.h - margin - top
+	gap := margin / 2
+	spans := cl.spans(covers)
+	var tiles []collageTile
+	var cols, rows int
+	cellW := 0.0
+	for n := 1; n <= len(covers); n++ {
+		t, r := packCollage(spans, n)
+		w := min((width-float64(n-1)*gap)/float64(n), (height-float64(r-1)*gap)/float64(r)*coverAspect)
+		if w > cellW {
+			tiles, cols, rows, cellW = t, n, r, w
+		}
+	}
+	if cellW <= 0 {
+		return c.drawing()
+	}
+	cellH := cellW / coverAspect
+	gridX := left + (width-float64(cols)*cellW-float64(cols-1)*gap)/2
+	gridY := top + (height-float64(rows)*cellH-float64(rows-1)*gap)/2
+
+	for i, cv := range covers {
+		t := tiles[i]
+		x := gridX + float64(t.col)*(cellW+gap)
+		y := gridY + float64(t.row)*(cellH+gap)
+		w := float64(t.span)*cellW + float64(t.span-1)*gap
+		h := float64(t.span)*cellH + float64(t.span-1)*gap
+
+		if icons[i] != nil && w >= 1 && h >= 1 {
+			c.DrawImageAnchored(ResizeAndCropImage(uint(w), uint(h), icons[i]), int(x), int(y), 0, 0)
+		} else {
+			c.drawMissingCover(cv.title, x, y, w, h)
+		}
+		if cl.Badges && cv.status != "" {
+			c.drawStatusBadge(cv.status, x, y, w, h)
+		}
+		if cl.Captions {
+			c.drawCaption(cv, x, y, w, h)
+		}
+	}
+
+	return c.drawing()
+}
+
+// drawMissingCover writes the title on a card where the box art would be.
+func (c *chart) drawMissingCover(title string, x, y, w, h float64) {
+	padding := min(w, h) * 0.1
+	c.SetHexColor(c.theme.Card)
+	c.rect(x, y, w, h)
+	c.Fill()
+	c.SetHexColor(c.theme.CardText)
+	c.SetFontFace(c.faces.regular)
+	lines := c.WordWrap(title, w-2*padding)
+	if float64(len(lines))*c.FontHeight()*1.2 > h-2*padding {
+		c.DrawStringAnchored(ellipsize(c, title, w-2*padding), x+w/2, y+h/2, 0.5, 0.35)
+	} else {
+		c.DrawStringWrapped(title, x+w/2, y+h/2, 0.5, 0.5, w-2*padding, 1.2, gg.AlignCenter)
+	}
+	c.Fill()
+}
+
+// drawStatusBadge puts the status on the top right of the cover, when it
+// fits.
+func (c *chart) drawStatusBadge(status string, x, y, w, h float64) {
+	c.SetFontFace(c.faces.regular)
+	padding := c.FontHeight() * 0.4
+	label := c.locale.T(status)
+	badgeW := measure(c, label) + 2*padding
+	badgeH := c.FontHeight() + padding
+	if badgeW > w-2*padding || badgeH > h/4 {
+		return
+	}
+	bx, by := x+w-padding-badgeW, y+padding
+	c.SetHexColor(c.badgeColor(status))
+	c.DrawRoundedRectangle(bx, by, badgeW, badgeH, badgeH/2)
+	c.Fill()
+	c.SetHexColor(c.theme.CardText)
+	c.DrawStringAnchored(label, bx+badgeW/2, by+badgeH/2, 0.5, 0.35)
+	c.Fill()
+}
+
+func (c *chart) badgeColor(status string) string {
+	switch status {
+	case "Abandoned":
+		return c.theme.CompareBar
+	case "Playing":
+		return c.theme.Card
+	default:
+		return c.theme.Bar
+	}
+}
+
+// drawCaption writes the title on a band over the bottom of the cover,
+// when it fits, and the playtime when the title keeps most of the band.
+func (c *chart) drawCaption(cv cover, x, y, w, h float64) {
+	c.SetFontFace(c.faces.regular)
+	bandH := c.FontHeight() * 1.8
+	padding := c.FontHeight() * 0.4
+	if bandH > h*0.35 {
+		return
+	}
+	c.SetHexColor(withAlpha(c.theme.Background, 0.75))
+	c.DrawRectangle(x, y+h-bandH, w, bandH)
+	c.Fill()
+
+	c.SetHexColor(c.theme.Text)
+	textY := y + h - bandH/2
+	titleWidth := w - 2*padding
+	if cv.playtime > 0 {
+		playtime := hours(c.locale, cv.playtime)
+		if playtimeWidth := measure(c, playtime); playtimeWidth < titleWidth/3 {
+			c.DrawStringAnchored(playtime, x+w-padding, textY, 1, 0.35)
+			titleWidth -= playtimeWidth + padding
+		}
+	}
+	c.DrawStringAnchored(ellipsize(c, cv.title, titleWidth), x+padding, textY, 0, 0.35)
+	c.Fill()
+}
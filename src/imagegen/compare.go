@@ -0,0 +1,183 @@
+package imagegen
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/i18n"
+)
+
+// ComparisonItem is a single entry of a stat computed for two periods.
+type ComparisonItem struct {
+	Title         string  `json:"title"`
+	From          int     `json:"from"`
+	To            int     `json:"to"`
+	FromRank      int     `json:"from_rank,omitempty"`
+	ToRank        int     `json:"to_rank,omitempty"`
+	Change        int     `json:"change"`
+	ChangePercent float64 `json:"change_percent"`
+	RankChange    int     `json:"rank_change"`
+	New           bool    `json:"new"`
+	Dropped       bool    `json:"dropped"`
+	Unit          string  `json:"unit"`
+
+	// Item is used to render icons the same way as the single period charts.
+	Item BarChartItem `json:"-"`
+}
+
+func (ci ComparisonItem) Icon() (IconRef, bool) {
+	if ci.Item == nil {
+		return IconRef{}, false
+	}
+	return ci.Item.Icon()
+}
+
+// NewComparisonItem computes the deltas between two periods. Ranks are
+// 1-based and 0 means the entry was not present in that period.
+func NewComparisonItem(title, unit string, item BarChartItem, from, to, fromRank, toRank int) ComparisonItem {
+	ci := ComparisonItem{
+		Title:    title,
+		From:     from,
+		To:       to,
+		FromRank: fromRank,
+		ToRank:   toRank,
+		Change:   to - from,
+		Unit:     unit,
+		New:      fromRank == 0,
+		Dropped:  toRank == 0,
+		Item:     item,
+	}
+	if from != 0 {
+		ci.ChangePercent = math.Round(float64(to-from)/float64(from)*1000) / 10
+	}
+	if !ci.New && !ci.Dropped {
+		ci.RankChange = fromRank - toRank
+	}
+	return ci
+}
+
+func (ci ComparisonItem) RenderChange(l *i18n.Locale) string {
+	if ci.New {
+		return l.T("new")
+	}
+	if ci.Dropped {
+		return l.T("dropped")
+	}
+	change := signed(l, float64(ci.Change), 0) + l.T(ci.Unit)
+	if ci.From != 0 {
+		change = fmt.Sprintf("%s (%s%%)", change, signed(l, ci.ChangePercent, 0))
+	}
+	if ci.RankChange > 0 {
+		change = l.T("%s, up %s", change, l.Int(ci.RankChange))
+	} else if ci.RankChange < 0 {
+		change = l.T("%s, down %s", change, l.Int(-ci.RankChange))
+	}
+	return change
+}
+
+func (ci ComparisonItem) RenderMetric(l *i18n.Locale, value int) string {
+	return l.Int(value) + l.T(ci.Unit)
+}
+
+// LocalTitle is the title in the language, for the months and the games
+// on a console.
+func (ci ComparisonItem) LocalTitle(l *i18n.Locale) string {
+	switch item := ci.Item.(type) {
+	case MostPlayedByPlaytime:
+		return item.LocalTitle(l)
+	case MostPlayedGame:
+		return item.GetTitle(l)
+	case BacklogMonth:
+		return monthTitle(l, item.Month)
+	}
+	return ci.Title
+}
+
+// signed writes the number with its sign, like "+12".
+func signed(l *i18n.Locale, f float64, decimals int) string {
+	f = math.Round(f*math.Pow10(decimals)) / math.Pow10(decimals)
+	if f >= 0 {
+		return "+" + l.Float(math.Abs(f), decimals)
+	}
+	return l.Float(f, decimals)
+}
+
+// RenderComparisonWrapped draws a paired bar chart, with the previous
+// period on top of the current one for each entry.
+func RenderComparisonWrapped(ctx context.Context, title, fromLabel, toLabel string, data []ComparisonItem, n int, opts Options) SaveableDrawing {
+	data = data[:min(n, len(data))]
+	icons := prefetchIcons(ctx, data)
+	c := newChart(opts)
+	theme := c.theme
+
+	margin := 20.0 * c.scale
+	if n > 10 {
+		margin = 18.0 * c.scale
+	}
+	maxMetric := -1
+	for _, d := range data {
+		maxMetric = max(maxMetric, d.From, d.To)
+	}
+	maxMetric = max(int(float64(maxMetric)*1.25), 1)
+
+	// The title may wrap, so the legend goes right below its last line
+	top := c.drawTitle(title, margin)
+	center := c.box.x + c.box.w/2
+	c.SetFontFace(c.faces.bold)
+	c.SetHexColor(theme.CompareBar)
+	c.DrawStringAnchored(fromLabel, center-margin, top, 1, 0.5)
+	c.SetHexColor(theme.Bar)
+	c.DrawStringAnchored(toLabel, center+margin, top, 0, 0.5)
+	c.Fill()
+
+	barHeight, start := c.listLayout(top+1.5*margin, margin, n)
+
+	for i, d := range data {
+		fullbarSize := c.box.w - 6*margin
+		icon := resizedIcon(icons[i], uint(max(barHeight*2-4, 1)))
+		if icon != nil {
+			fullbarSize = c.box.w - 8*margin
+		}
+
+		x := c.box.x + 1.5*margin
+		y := start + (float64(i) * ((barHeight * 2) + margin/2))
+
+		if icon != nil {
+			c.SetHexColor(theme.IconBackground)
+			c.rect(x, y, barHeight*2, barHeight*2)
+			c.Fill()
+
+			c.DrawImageAnchored(icon, int(x+barHeight), int(y+barHeight), 0.5, 0.5)
+
+			x += 8 + barHeight*2
+		}
+
+		halfBar := barHeight / 2
+		fromSize := (float64(d.From) / float64(maxMetric)) * fullbarSize
+		toSize := (float64(d.To) / float64(maxMetric)) * fullbarSize
+
+		c.SetFontFace(c.faces.regular)
+		c.drawCompareBar(d.RenderMetric(c.locale, d.From), theme.CompareBar, x, y, fromSize+margin/2, halfBar-2, margin)
+		c.drawCompareBar(d.RenderMetric(c.locale, d.To), theme.BarColor(i), x, y+halfBar, toSize+margin/2, halfBar-2, margin)
+
+		label := fmt.Sprintf("%s  %s", d.LocalTitle(c.locale), d.RenderChange(c.locale))
+		c.drawBarLabel(label, x, y, barHeight, margin, theme.Text)
+	}
+
+	return c.drawing()
+}
+
+// drawCompareBar draws one of the bars of a comparison, with its metric
+// after it, or inside it when it doesn't fit.
+func (c *chart) drawCompareBar(metric, color string, x, y, w, h, margin float64) {
+	c.SetHexColor(color)
+	c.rect(x, y, w, h)
+	c.Fill()
+	metricX, inside := c.metricX(metric, x, w, margin/2, margin)
+	if inside {
+		c.SetHexColor(c.theme.Background)
+	}
+	c.DrawStringAnchored(metric, metricX, y+h/2+1, 0, 0.5)
+	c.Fill()
+}
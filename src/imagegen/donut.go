@@ -0,0 +1,159 @@
+package imagegen
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/i18n"
+)
+
+// donutSlices is how many items get their own slice, the rest are grouped
+// as other.
+const donutSlices = 6
+
+// DonutChart shows the share of the total of each item, like the games by
+// status or the playtime by platform, with the legend below the donut on
+// portrait canvases and beside it otherwise.
+type DonutChart struct {
+	Title string
+	Items []BarChartItem
+	// Unit is appended to the total in the hole, like "h".
+	Unit string
+	// Label is written under the total, like "games".
+	Label string
+}
+
+type slice struct {
+	title, metric string
+	value         float64
+	other         bool
+}
+
+func (d DonutChart) slices(l *i18n.Locale) []slice {
+	slices := []slice{}
+	other := 0
+	for i, item := range d.Items {
+		if item.GetMetric() <= 0 {
+			continue
+		}
+		if i >= donutSlices && len(d.Items) > donutSlices+1 {
+			other += item.GetMetric()
+			continue
+		}
+		slices = append(slices, slice{title: item.GetTitle(l), metric: item.RenderMetric(l), value: float64(item.GetMetric())})
+	}
+	if other > 0 {
+		slices = append(slices, slice{title: l.T("Other"), metric: l.Int(other) + d.Unit, value: float64(other), other: true})
+	}
+	return slices
+}
+
+func (d DonutChart) Render(ctx context.Context, opts Options) SaveableDrawing {
+	c := newChart(opts)
+	theme := c.theme
+	margin := 20.0 * c.scale
+	top := c.drawTitle(d.Title, margin)
+
+	slices := d.slices(c.locale)
+	total := 0.0
+	for _, s := range slices {
+		total += s.value
+	}
+	if total == 0 {
+		return c.drawing()
+	}
+
+	left := c.box.x + 1.5*margin
+	width := c.box.w - 3*margin
+	bottom := c.box.y + c.box.h - margin
+	c.SetFontFace(c.faces.bold)
+	rowHeight := c.FontHeight() * 2
+	legendHeight := float64(len(slices)) * rowHeight
+
+	// the legend takes the bottom of portrait canvases, and the right half
+	// of the others
+	var cx, cy, radius, legendX, legendY, legendWidth float64
+	if c.box.h > c.box.w {
+		radius = min(width, bottom-top-legendHeight-margin) / 2
+		cx, cy = left+width/2, top+radius
+		legendX, legendY, legendWidth = left, cy+radius+margin, width
+		legendY += max(0, (bottom-legendY-legendHeight)/2)
+	} else {
+		radius = min(width/2-margin, bottom-top) / 2
+		cx, cy = left+width/4, top+(bottom-top)/2
+		legendX, legendY, legendWidth = left+width/2+margin, cy-legendHeight/2, width/2-margin
+	}
+	radius = max(radius, 1)
+	hole := radius * 0.6
+
+	// slices are apart by a gap of the same width all the way, so they are
+	// drawn slightly shorter than their angle
+	gap := 3 * c.scale
+	angle := -math.Pi / 2
+	for i, s := range slices {
+		sweep := 2 * math.Pi * s.value / total
+		outer, inner := gap/2/radius, gap/2/hole
+		if len(slices) == 1 {
+			outer, inner = 0, 0
+		}
+		if sweep > 2*inner {
+			c.SetHexColor(d.color(theme, i, slices))
+			c.NewSubPath()
+			c.DrawArc(cx, cy, radius, angle+outer, angle+sweep-outer)
+			c.DrawArc(cx, cy, hole, angle+sweep-inner, angle+inner)
+			c.ClosePath()
+			c.Fill()
+		}
+		angle += sweep
+	}
+
+	// the total and its label are centered in the hole together
+	totalText := c.locale.Int(int(total)) + d.Unit
+	c.SetFontFace(c.faces.regular)
+	labelHeight := 0.0
+	if d.Label != "" {
+		labelHeight = c.FontHeight() * 1.5
+	}
+	c.SetFontFace(c.faces.title)
+	if measure(c, totalText) > 1.8*hole {
+		c.SetFontFace(c.faces.bold)
+	}
+	y := cy - (c.FontHeight()+labelHeight)/2
+	c.SetHexColor(theme.Title)
+	c.DrawStringAnchored(totalText, cx, y, 0.5, 1)
+	if d.Label != "" {
+		y += c.FontHeight() + labelHeight/3
+		c.SetHexColor(theme.Text)
+		c.SetFontFace(c.faces.regular)
+		c.DrawStringAnchored(d.Label, cx, y, 0.5, 1)
+	}
+
+	for i, s := range slices {
+		y := legendY + float64(i)*rowHeight + rowHeight/2
+		c.SetFontFace(c.faces.bold)
+		swatch := c.FontHeight()
+		c.SetHexColor(d.color(theme, i, slices))
+		c.rect(legendX, y-swatch/2, swatch, swatch)
+		c.Fill()
+
+		c.SetHexColor(theme.Text)
+		share := fmt.Sprintf("%s  %s%%", s.metric, c.locale.Int(int(math.Round(100*s.value/total))))
+		c.DrawStringAnchored(share, legendX+legendWidth, y, 1, 0.35)
+		labelX := legendX + swatch + margin/2
+		labelWidth := legendX + legendWidth - measure(c, share) - margin/2 - labelX
+		c.SetFontFace(c.faces.regular)
+		c.DrawStringAnchored(ellipsize(c, s.title, labelWidth), labelX, y, 0, 0.35)
+	}
+	c.Fill()
+
+	return c.drawing()
+}
+
+// color keeps other in the color of the bars that are compared against.
+func (d DonutChart) color(theme *Theme, i int, slices []slice) string {
+	if slices[i].other {
+		return theme.CompareBar
+	}
+	return theme.SliceColor(i, len(slices))
+}
@@ -1,191 +1,221 @@
 package imagegen
 
 import (
+	"context"
 	"image"
-	"log"
-	"sync"
-
-	"io"
 
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/i18n"
 	"github.com/fogleman/gg"
 	"golang.org/x/image/font"
 )
 
-const (
-	Ratio = 2
-	W     = 540 * Ratio
-	H     = 960 * Ratio
-	//BarColor        = "#B4F8C8"
-	//BackgroundColor = "#000000"
-	IconColor       = "#FFF"
-	BarColor        = "#FCA311"
-	BackgroundColor = "#14213D"
-	TitleFontSize   = 48 * Ratio
-	BoldFontSize    = 20 * Ratio
-	RegularFontSize = 14 * Ratio
-)
-
-type Orientation int
+// Options are how a chart is rendered.
+type Options struct {
+	// Canvas defaults to CanvasVertical.
+	Canvas Canvas
+	// Theme defaults to DefaultTheme.
+	Theme *Theme
+	// Format defaults to PNG.
+	Format Format
+	// Document, when set, gets the chart as a new page, and the format is
+	// PDF.
+	Document *Document
+	// Locale is the language of the text, English by default.
+	Locale *i18n.Locale
+}
 
-const (
-	Horizontal Orientation = 0
-	Vertical   Orientation = iota
-)
+func (o Options) canvas() Canvas {
+	if o.Canvas.Width <= 0 || o.Canvas.Height <= 0 {
+		return CanvasVertical
+	}
+	return o.Canvas
+}
 
-var (
-	// Lock to prevent concurrent rendering, which was
-	// causing some glitches on text rendering
-	lock = sync.Mutex{}
-)
+func (o Options) format() Format {
+	if o.Document != nil {
+		return PDF
+	}
+	if o.Format == "" {
+		return PNG
+	}
+	return o.Format
+}
 
-func (o Orientation) String() string {
-	switch o {
-	case Horizontal:
-		return "horizontal"
-	case Vertical:
-		return "vertical"
-	default:
-		return "unknown"
+func (o Options) theme() *Theme {
+	if o.Theme == nil {
+		return &DefaultTheme
 	}
+	return o.Theme
 }
 
-var (
-	titleFont   font.Face
-	boldFont    font.Face
-	regularFont font.Face
-)
+func (o Options) locale() *i18n.Locale {
+	if o.Locale == nil {
+		return i18n.English
+	}
+	return o.Locale
+}
 
 type BarChartItem interface {
-	GetTitle() string
+	GetTitle(l *i18n.Locale) string
 	GetMetric() int
-	RenderMetric() string
-	RenderIcon(height uint, serperAPIKey string) image.Image
+	RenderMetric(l *i18n.Locale) string
+	Icon() (IconRef, bool)
 }
 
-type SaveableDrawing interface {
-	SavePNG(path string) error
-	EncodePNG(w io.Writer) error
+// chart is a canvas being drawn with a theme. Sizes are multiplied by the
+// scale of the canvas, and the content is laid out inside box.
+type chart struct {
+	surface
+	format Format
+	theme  *Theme
+	locale *i18n.Locale
+	faces  faces
+	scale  float64
+	box    box
+	// resized are the faces shrunk to fit text, by font file and size.
+	resized map[fontSize]font.Face
+}
+
+func newChart(opts Options) *chart {
+	canvas, theme := opts.canvas(), opts.theme()
+	s := newSurface(canvas.Width, canvas.Height, opts)
+	drawBackground(s, theme)
+	return &chart{
+		surface: s,
+		format:  opts.format(),
+		theme:   theme,
+		locale:  opts.locale(),
+		faces:   newFaces(theme, canvas.scale()),
+		scale:   canvas.scale(),
+		box:     canvas.content(),
+		resized: map[fontSize]font.Face{},
+	}
 }
 
-type drawing struct {
-	*gg.Context
+func (c *chart) drawing() SaveableDrawing {
+	return &drawing{c.surface, c.format}
 }
 
-func (d *drawing) EncodePNG(w io.Writer) error {
-	return d.Context.EncodePNG(w)
+// drawBarLabel writes the label below the bar of a list that starts at x
+// and y, shrinking and shortening it to fit before the next bar.
+func (c *chart) drawBarLabel(label string, x, y, barHeight, margin float64, color string) {
+	width := c.box.x + c.box.w - margin - x
+	top := y + 1.1*barHeight
+	fit := c.fitText(label, c.faces.regular, width, 0.9*barHeight+margin/4, 1)
+	c.SetHexColor(color)
+	// single lines are centered a bit below the bar, taller labels start
+	// right below it
+	fit.draw(c, x, max(top, y+1.4*barHeight-fit.height(c)/2), 0, 0, width, gg.AlignLeft)
+	c.Fill()
 }
 
-func RenderMostPlayedWrapped(title string, data []BarChartItem, n int, orientation Orientation, serperAPIKey string) SaveableDrawing {
-	width := W
-	height := H
-	if orientation != Vertical {
-		width, height = height, width
+// drawTitle draws the title centered at the top of the content, wrapping
+// it as needed, and returns where the content below it starts.
+func (c *chart) drawTitle(title string, margin float64) float64 {
+	return c.drawFadingTitle(title, margin, 1)
+}
+
+func (c *chart) drawFadingTitle(title string, margin, alpha float64) float64 {
+	width := c.box.w - 4*margin
+	c.SetHexColor(withAlpha(c.theme.Title, alpha))
+	c.SetFontFace(c.faces.title)
+	top := c.box.y + margin
+	c.DrawStringWrapped(title, c.box.x+c.box.w/2, top, 0.5, 0, width, 1, gg.AlignCenter)
+	c.Fill()
+	return top + float64(len(c.WordWrap(title, width)))*c.FontHeight() + margin
+}
+
+// rect draws a rectangle with the corners rounded by the theme.
+func (c *chart) rect(x, y, w, h float64) {
+	if c.theme.CornerRadius > 0 {
+		c.DrawRoundedRectangle(x, y, w, h, min(c.theme.CornerRadius*c.scale, w/2, h/2))
+		return
 	}
-	lock.Lock()
-	defer lock.Unlock()
-	dc := gg.NewContext(width, height)
-	dc.SetHexColor(BackgroundColor)
-	dc.DrawRectangle(0, 0, float64(width), float64(height))
-	dc.Fill()
-	margin := 20.0 * Ratio
+	c.DrawRectangle(x, y, w, h)
+}
+
+// listLayout fits n rows of bars below top, each one two bars high, and
+// centers them vertically in the space left. It returns the bar height and
+// where the first row starts.
+func (c *chart) listLayout(top, margin float64, n int) (barHeight, start float64) {
+	bottom := c.box.y + c.box.h - margin
+	n = max(n, 1)
+	barHeight = ((bottom-top)/float64(n) - margin/2) / 2
+	barHeight = max(min(barHeight, 40*c.scale), 1)
+	used := float64(n)*(2*barHeight+margin/2) - margin/2
+	return barHeight, top + max(0, (bottom-top-used)/2)
+}
+
+func RenderMostPlayedWrapped(ctx context.Context, title string, data []BarChartItem, n int, opts Options) SaveableDrawing {
+	data = data[:min(n, len(data))]
+	icons := prefetchIcons(ctx, data)
+	c := newChart(opts)
+	c.drawMostPlayed(title, data, n, icons, lastFrame)
+	return c.drawing()
+}
+
+// drawMostPlayed draws the bar chart as it is at the frame, which for the
+// static charts is the last one.
+func (c *chart) drawMostPlayed(title string, data []BarChartItem, n int, icons []image.Image, f frame) {
+	theme := c.theme
+
+	margin := 20.0 * c.scale
 	if n > 10 {
-		margin = 18.0 * Ratio
-	}
-	marginTop := 4 * margin
-	if orientation != Vertical {
-		marginTop = 1.5 * margin
-	}
-	// ((2 * barHeight) + (margin)) * n = 3*height/4
-	// ((2 * barHeight) + (margin)) = 3*height/4n
-	// (2 * barHeight) = (3*height/4n) - (margin)
-	// barHeight = ((3*height/4n) - (margin)) / 2
-	barHeight := ((float64(3*height/4) - float64(margin)) / float64(n)) / 2
-	if barHeight > 40*Ratio {
-		barHeight = 40 * Ratio
+		margin = 18.0 * c.scale
 	}
 	maxMetric := -1
-	total := 0
 	for _, d := range data {
-		m := d.GetMetric()
-		if m > maxMetric {
+		if m := d.GetMetric(); m > maxMetric {
 			maxMetric = m
 		}
-		total += m
 	}
-	maxMetric = int(float64(maxMetric) * 1.25)
+	maxMetric = max(int(float64(maxMetric)*1.25), 1)
 
-	textSize := float64(width) - 8*margin
-	if orientation != Vertical {
-		textSize = float64(width) - 2*margin
-	}
-
-	dc.SetHexColor(BarColor)
-	dc.SetFontFace(titleFont)
-	dc.DrawStringWrapped(title, float64(width)/2, marginTop, 0.5, 0.5, textSize, 1, gg.AlignCenter)
-	dc.Fill()
-
-	if orientation == Vertical {
-		marginTop += 5 * margin
-	} else {
-		marginTop += 2.5 * margin
-	}
+	top := c.drawFadingTitle(title, margin, f.title)
+	barHeight, start := c.listLayout(top, margin, n)
 
 	for i, d := range data {
-		if i > n-1 {
-			break
+		progress := f.bar(i)
+		if progress <= 0 {
+			continue
 		}
-		fullbarSize := float64(width) - 4*margin
-		icon := d.RenderIcon(uint(barHeight*2)-4, serperAPIKey)
+		// the labels fade in while the bar starts growing
+		alpha := min(1, 3*progress)
 
+		fullbarSize := c.box.w - 4*margin
+		icon := resizedIcon(icons[i], uint(max(barHeight*2-4, 1)))
 		if icon != nil {
-			fullbarSize = float64(width) - 6*margin
+			fullbarSize = c.box.w - 6*margin
 		}
 
-		size := (float64(d.GetMetric()) / float64(maxMetric)) * fullbarSize
-		x := float64(1.5 * margin)
-		y := marginTop + (float64(i) * ((barHeight * 2) + margin/2))
-
-		if icon == nil {
-			y = marginTop + (float64(i) * ((barHeight * 2) + margin/2))
-		}
+		size := (float64(d.GetMetric()) / float64(maxMetric)) * fullbarSize * progress
+		x := c.box.x + 1.5*margin
+		y := start + (float64(i) * ((barHeight * 2) + margin/2))
 
 		if icon != nil {
-			dc.SetHexColor(IconColor)
-			dc.DrawRectangle(x, y, barHeight*2, barHeight*2)
-			dc.Fill()
+			c.SetHexColor(withAlpha(theme.IconBackground, alpha))
+			c.rect(x, y, barHeight*2, barHeight*2)
+			c.Fill()
 
-			dc.DrawImageAnchored(icon, int(x+barHeight), int(y+barHeight), 0.5, 0.5)
+			c.DrawImageAnchored(icon, int(x+barHeight), int(y+barHeight), 0.5, 0.5)
 
 			x += 8 + barHeight*2
 		}
 
-		dc.SetHexColor(BarColor)
-		dc.SetFontFace(regularFont)
-		dc.DrawStringWrapped(d.GetTitle(), x+textSize/2, y+1.4*barHeight, 0.5, 0.5, textSize, 1, gg.AlignLeft)
-
-		dc.SetFontFace(boldFont)
-		dc.DrawString(d.RenderMetric(), x+size+(1.5*margin), y+(barHeight/2)+(BoldFontSize/4))
-		dc.Fill()
-
-		dc.DrawRectangle(x, y, size+margin, barHeight)
-		dc.Fill()
-	}
+		c.drawBarLabel(d.GetTitle(c.locale), x, y, barHeight, margin, withAlpha(theme.Text, alpha))
 
-	return &drawing{dc}
-}
+		barWidth := size + margin*progress
+		c.SetHexColor(withAlpha(theme.BarColor(i), alpha))
+		c.rect(x, y, barWidth, barHeight)
+		c.Fill()
 
-func LoadFonts() {
-	var err error
-	titleFont, err = gg.LoadFontFace("./fonts/SpaceMono-Bold.ttf", TitleFontSize)
-	if err != nil {
-		log.Fatalf("failed to load title font")
-	}
-	boldFont, err = gg.LoadFontFace("./fonts/SpaceMono-Bold.ttf", BoldFontSize)
-	if err != nil {
-		log.Fatalf("failed to load bold font")
-	}
-	regularFont, err = gg.LoadFontFace("./fonts/SpaceMono-Regular.ttf", RegularFontSize)
-	if err != nil {
-		log.Fatalf("failed to load regular font")
+		metric := countUp(c.locale, d.RenderMetric(c.locale), progress)
+		c.SetFontFace(c.faces.bold)
+		metricX, inside := c.metricX(metric, x, barWidth, margin/2, margin)
+		if inside {
+			c.SetHexColor(withAlpha(theme.Background, alpha))
+		}
+		c.DrawString(metric, metricX, y+(barHeight/2)+(theme.BoldFontSize*c.scale/4))
+		c.Fill()
 	}
 }
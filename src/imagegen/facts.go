@@ -0,0 +1,86 @@
+package imagegen
+
+import (
+	"context"
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+// FactCard is a highlight rendered as a card, with an optional icon for the
+// game or serie it talks about.
+type FactCard struct {
+	Heading string `json:"heading"`
+	Value   string `json:"value"`
+	Text    string `json:"text"`
+	Subject string `json:"subject,omitempty"`
+	BoxArt  bool   `json:"-"`
+}
+
+func (fc FactCard) Icon() (IconRef, bool) {
+	return IconRef{Name: fc.Subject, BoxArt: fc.BoxArt}, fc.Subject != ""
+}
+
+// RenderFactCards draws the cards stacked on a single column, or on two
+// columns when the canvas is not much taller than wide.
+func RenderFactCards(ctx context.Context, title string, cards []FactCard, opts Options) SaveableDrawing {
+	icons := prefetchIcons(ctx, cards)
+	c := newChart(opts)
+	theme := c.theme
+	margin := 20.0 * c.scale
+
+	top := c.drawTitle(title, margin)
+
+	if len(cards) == 0 {
+		return c.drawing()
+	}
+
+	cols := 1
+	if c.box.h <= 1.1*c.box.w {
+		cols = 2
+	}
+	rows := int(math.Ceil(float64(len(cards)) / float64(cols)))
+	gap := margin / 2
+	cardWidth := (c.box.w - 3*margin - float64(cols-1)*gap) / float64(cols)
+	cardHeight := (c.box.y + c.box.h - top - 1.5*margin - float64(rows-1)*gap) / float64(rows)
+	cardHeight = min(cardHeight, 160*c.scale)
+	padding := margin / 2
+
+	for i, card := range cards {
+		col := i % cols
+		row := i / cols
+		x := c.box.x + 1.5*margin + float64(col)*(cardWidth+gap)
+		y := top + float64(row)*(cardHeight+gap)
+
+		c.SetHexColor(theme.Card)
+		c.DrawRoundedRectangle(x, y, cardWidth, cardHeight, padding)
+		c.Fill()
+
+		textX := x + padding
+		iconSize := cardHeight - 2*padding
+		icon := resizedIcon(icons[i], uint(max(iconSize-4, 1)))
+		if icon != nil {
+			c.SetHexColor(theme.IconBackground)
+			c.rect(textX, y+padding, iconSize, iconSize)
+			c.Fill()
+			c.DrawImageAnchored(icon, int(textX+iconSize/2), int(y+padding+iconSize/2), 0.5, 0.5)
+			textX += iconSize + padding
+		}
+		textWidth := x + cardWidth - padding - textX
+
+		c.SetHexColor(theme.CardText)
+		c.SetFontFace(c.faces.bold)
+		c.DrawStringAnchored(card.Heading, textX, y+padding, 0, 1)
+		headingHeight := c.FontHeight()
+
+		c.SetFontFace(c.faces.title)
+		c.DrawStringAnchored(card.Value, textX, y+padding+headingHeight, 0, 0.9)
+		valueHeight := c.FontHeight()
+
+		c.SetFontFace(c.faces.regular)
+		c.DrawStringWrapped(card.Text, textX, y+padding+headingHeight+valueHeight+padding/2, 0, 0, textWidth, 1.2, gg.AlignLeft)
+		c.Fill()
+	}
+
+	return c.drawing()
+}
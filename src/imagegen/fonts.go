@@ -0,0 +1,363 @@
+package imagegen
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// The font files of the default theme, looked up in the fonts folder.
+const (
+	BoldFontFile    = "SpaceMono-Bold.ttf"
+	RegularFontFile = "SpaceMono-Regular.ttf"
+)
+
+// DefaultFontsDir is the fonts folder when LoadFonts isn't given one.
+const DefaultFontsDir = "./fonts/"
+
+// FontRegistry parses each font file once. The faces created from a font
+// keep a glyph cache that is not safe for concurrent use, so every render
+// creates its own faces instead of sharing them.
+type FontRegistry struct {
+	mu    sync.Mutex
+	fonts map[string]*fontFile
+	// dir is where the font files are looked up when they are not found
+	// from the working directory.
+	dir string
+	// fallbacks are the font files the glyphs missing from a font are
+	// taken from, in order.
+	fallbacks []string
+}
+
+func NewFontRegistry() *FontRegistry {
+	return &FontRegistry{fonts: map[string]*fontFile{}, dir: DefaultFontsDir}
+}
+
+var fonts = NewFontRegistry()
+
+// fontFile is a parsed font. TrueType fonts are drawn with freetype, and
+// OpenType fonts with CFF outlines, which it can't read, with sfnt.
+type fontFile struct {
+	ttf *truetype.Font
+	otf *sfnt.Font
+}
+
+func parseFont(data []byte) (*fontFile, error) {
+	ttf, err := truetype.Parse(data)
+	if err == nil {
+		return &fontFile{ttf: ttf}, nil
+	}
+	otf, otfErr := sfnt.Parse(data)
+	if otfErr != nil {
+		return nil, err
+	}
+	return &fontFile{otf: otf}, nil
+}
+
+func (f *fontFile) face(size float64) (font.Face, error) {
+	if f.ttf != nil {
+		return truetype.NewFace(f.ttf, &truetype.Options{Size: size}), nil
+	}
+	return opentype.NewFace(f.otf, &opentype.FaceOptions{Size: size, DPI: 72})
+}
+
+// has tells if the font has a glyph for the rune.
+func (f *fontFile) has(buf *sfnt.Buffer, r rune) bool {
+	if f.ttf != nil {
+		return f.ttf.Index(r) != 0
+	}
+	i, err := f.otf.GlyphIndex(buf, r)
+	return err == nil && i != 0
+}
+
+// names are the family and subfamily of the font, like "Space Mono" and
+// "Bold".
+func (f *fontFile) names() (family, subfamily string) {
+	if f.ttf != nil {
+		return f.ttf.Name(truetype.NameIDFontFamily), f.ttf.Name(truetype.NameIDFontSubfamily)
+	}
+	family, _ = f.otf.Name(nil, sfnt.NameIDFamily)
+	subfamily, _ = f.otf.Name(nil, sfnt.NameIDSubfamily)
+	return family, subfamily
+}
+
+// resolve is the path of the font file, from the working directory when it
+// is there, and from the fonts folder otherwise.
+func (r *FontRegistry) resolve(path string) string {
+	if _, err := os.Stat(path); err == nil || filepath.IsAbs(path) || path == "" {
+		return path
+	}
+	return filepath.Join(r.dir, path)
+}
+
+// Load parses the font file, unless it was already parsed.
+func (r *FontRegistry) Load(path string) (*fontFile, error) {
+	path = r.resolve(path)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.fonts[path]; ok {
+		return f, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parseFont(data)
+	if err != nil {
+		return nil, err
+	}
+	r.fonts[path] = f
+	return f, nil
+}
+
+// Face creates a new face of the font file with the given size in points,
+// with the glyphs it is missing taken from the fallback fonts.
+func (r *FontRegistry) Face(path string, size float64) (font.Face, error) {
+	f, err := r.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	face, err := f.face(size)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	fallbacks := r.fallbacks
+	r.mu.Unlock()
+	if len(fallbacks) == 0 {
+		return face, nil
+	}
+
+	ff := &fallbackFace{faces: []font.Face{face}, files: []*fontFile{f}, paths: []string{r.resolve(path)}, picked: map[rune]int{}}
+	for _, fallback := range fallbacks {
+		if fallback == ff.paths[0] {
+			continue
+		}
+		file, err := r.Load(fallback)
+		if err != nil {
+			return nil, err
+		}
+		face, err := file.face(size)
+		if err != nil {
+			return nil, err
+		}
+		ff.faces = append(ff.faces, face)
+		ff.files = append(ff.files, file)
+		ff.paths = append(ff.paths, fallback)
+	}
+	return ff, nil
+}
+
+// fallbackFace draws each rune with the first of its faces that has a
+// glyph for it, or with the first one, which has the metrics, when none
+// does. pick is -1 for the ignorable runes no face has.
+type fallbackFace struct {
+	faces  []font.Face
+	files  []*fontFile
+	paths  []string
+	picked map[rune]int
+	buf    sfnt.Buffer
+}
+
+func (f *fallbackFace) pick(r rune) int {
+	if i, ok := f.picked[r]; ok {
+		return i
+	}
+	i := 0
+	if ignorable(r) {
+		i = -1
+	}
+	for j, file := range f.files {
+		if file.has(&f.buf, r) {
+			i = j
+			break
+		}
+	}
+	f.picked[r] = i
+	return i
+}
+
+func (f *fallbackFace) Close() error {
+	var errs []error
+	for _, face := range f.faces {
+		errs = append(errs, face.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// ignorable runes, like the joiners and variation selectors of emoji, are
+// left out when no font has them instead of drawing a missing glyph.
+func ignorable(r rune) bool {
+	return r == '\u200d' || unicode.Is(unicode.Variation_Selector, r)
+}
+
+func (f *fallbackFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	i := f.pick(r)
+	if i < 0 {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+	return f.faces[i].Glyph(dot, r)
+}
+
+func (f *fallbackFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	i := f.pick(r)
+	if i < 0 {
+		return fixed.Rectangle26_6{}, 0, false
+	}
+	return f.faces[i].GlyphBounds(r)
+}
+
+func (f *fallbackFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	i := f.pick(r)
+	if i < 0 {
+		return 0, false
+	}
+	return f.faces[i].GlyphAdvance(r)
+}
+
+// Kern only applies between runes of the same font.
+func (f *fallbackFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	i := f.pick(r0)
+	if i < 0 || i != f.pick(r1) {
+		return 0
+	}
+	return f.faces[i].Kern(r0, r1)
+}
+
+func (f *fallbackFace) Metrics() font.Metrics {
+	return f.faces[0].Metrics()
+}
+
+// faces are the font faces of a single render.
+type faces struct {
+	title   font.Face
+	bold    font.Face
+	regular font.Face
+}
+
+func newFaces(t *Theme, scale float64) faces {
+	return faces{
+		title:   newFace(t.TitleFont, t.TitleFontSize*scale),
+		bold:    newFace(t.BoldFont, t.BoldFontSize*scale),
+		regular: newFace(t.RegularFont, t.RegularFontSize*scale),
+	}
+}
+
+// namedFace is a face that remembers its font file and size, for the
+// formats that write the text instead of drawing it.
+type namedFace struct {
+	font.Face
+	path string
+	size float64
+}
+
+func newFace(path string, size float64) font.Face {
+	face, err := fonts.Face(path, size)
+	if err != nil {
+		fmt.Println("failed to load font: ", path, err)
+		return namedFace{Face: basicfont.Face7x13}
+	}
+	return namedFace{Face: face, path: fonts.resolve(path), size: size}
+}
+
+// textRun is a part of a string written with a single font file.
+type textRun struct {
+	text string
+	path string
+}
+
+// runs splits the text by the font file each rune is written with.
+func (f namedFace) runs(text string) []textRun {
+	ff, ok := f.Face.(*fallbackFace)
+	if !ok {
+		return []textRun{{text, f.path}}
+	}
+	runs := []textRun{}
+	current := -1
+	var b strings.Builder
+	for _, r := range text {
+		i := ff.pick(r)
+		if i < 0 {
+			i = max(current, 0)
+		}
+		if i != current && b.Len() > 0 {
+			runs = append(runs, textRun{b.String(), ff.paths[current]})
+			b.Reset()
+		}
+		current = i
+		b.WriteRune(r)
+	}
+	if b.Len() > 0 {
+		runs = append(runs, textRun{b.String(), ff.paths[current]})
+	}
+	return runs
+}
+
+// LoadFonts parses the fonts of the default theme from the fonts folder up
+// front, so a missing font file fails at startup rather than on the first
+// render. Glyphs missing from the theme fonts, like Japanese titles or
+// emoji, are taken from the extra font files, in order, and then from the
+// ones in the fallback folder inside the fonts folder.
+func LoadFonts(dir string, extra []string) error {
+	// the fonts are loaded before rendering, so the folder isn't locked
+	fonts.dir = dir
+	for _, path := range []string{BoldFontFile, RegularFontFile} {
+		if _, err := fonts.Load(path); err != nil {
+			return fmt.Errorf("failed to load font %s: %v", path, err)
+		}
+	}
+
+	fallbacks := []string{}
+	for _, path := range extra {
+		fallbacks = append(fallbacks, fonts.resolve(path))
+	}
+	bundled, err := fontFiles(filepath.Join(dir, "fallback"))
+	if err != nil {
+		return err
+	}
+	for _, path := range append(fallbacks, bundled...) {
+		if _, err := fonts.Load(path); err != nil {
+			return fmt.Errorf("failed to load fallback font %s: %v", path, err)
+		}
+	}
+
+	fonts.mu.Lock()
+	fonts.fallbacks = append(fallbacks, bundled...)
+	fonts.mu.Unlock()
+	return nil
+}
+
+// fontFiles are the *.ttf and *.otf files in dir, by name. A missing
+// directory just has none.
+func fontFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fonts folder: %v", err)
+	}
+	files := []string{}
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if !entry.IsDir() && (ext == ".ttf" || ext == ".otf") {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
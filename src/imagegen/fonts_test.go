@@ -0,0 +1,52 @@
+package imagegen
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestBundledFallbacks checks that the runes missing from the theme fonts
+// are written with the fonts in fonts/fallback, without any --fallback-fonts.
+func TestBundledFallbacks(t *testing.T) {
+	face := newFace(BoldFontFile, 48).(namedFace)
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Zelda 2024", []string{"SpaceMono-Bold.ttf"}},
+		{"ゼルダの伝説", []string{"NotoSansCJKjp-Bold-Subset.ttf"}},
+		{"Тетрис 99", []string{"NotoSans-Regular-Subset.ttf", "SpaceMono-Bold.ttf"}},
+		{"ゼルダ 2024", []string{"NotoSansCJKjp-Bold-Subset.ttf", "SpaceMono-Bold.ttf"}},
+		{"Mario 🍄", []string{"SpaceMono-Bold.ttf", "NotoColorEmoji-Mono-Subset.ttf"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got := []string{}
+			for _, run := range face.runs(tt.text) {
+				got = append(got, filepath.Base(run.path))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("runs(%q) are in %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJapaneseTitle(t *testing.T) {
+	items := []BarChartItem{
+		MostPlayedByPlaytime{Title: "ゼルダの伝説", Playtime: 120, Count: 1, NoIcon: true},
+	}
+	opts := Options{Format: SVG}
+	buf := bytes.Buffer{}
+	if err := RenderMostPlayedWrapped(context.Background(), "2024年に一番遊んだゲーム", items, 1, opts).Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `font-family="Noto Sans CJK JP"`) {
+		t.Errorf("the Japanese title isn't written with the bundled CJK font")
+	}
+}
@@ -0,0 +1,181 @@
+package imagegen
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// heatLevels are the alpha of the bar color for each level of playtime,
+// from the days without any.
+var heatLevels = []float64{0.12, 0.35, 0.55, 0.78, 1}
+
+// CalendarHeatmap shows the playtime of each day of the year as a grid of
+// weeks, like the contributions calendar of GitHub. The weeks are columns
+// on landscape canvases and rows on portrait ones, split in as many blocks
+// as makes the days largest.
+type CalendarHeatmap struct {
+	Title string
+	Year  int
+	// Hours has the playtime of each day, from January 1st.
+	Hours []float64
+}
+
+// heatmapLayout places the weeks of the calendar. Weeks are columns when
+// horizontal, and rows otherwise.
+type heatmapLayout struct {
+	horizontal bool
+	blocks     int
+	weeks      int
+	cell       float64
+}
+
+func (h CalendarHeatmap) Render(ctx context.Context, opts Options) SaveableDrawing {
+	c := newChart(opts)
+	theme := c.theme
+	margin := 20.0 * c.scale
+	top := c.drawTitle(h.Title, margin)
+
+	first := time.Date(h.Year, 1, 1, 0, 0, 0, 0, time.UTC)
+	days := int(first.AddDate(1, 0, 0).Sub(first).Hours() / 24)
+	offset := int(first.Weekday())
+	weeks := (days-1+offset)/7 + 1
+
+	c.SetFontFace(c.faces.regular)
+	labelHeight := c.FontHeight() * 1.6
+	labelWidth := 0.0
+	for month := time.January; month <= time.December; month++ {
+		labelWidth = max(labelWidth, measure(c, c.locale.ShortMonth(month))+margin/2)
+	}
+	footerHeight := c.FontHeight() * 2.5
+	left := c.box.x + 1.5*margin
+	width := c.box.w - 3*margin
+	height := c.box.y + c.box.h - margin - footerHeight - top
+	gap := margin
+
+	horizontal := c.box.w >= c.box.h
+	best := heatmapLayout{}
+	for blocks := 1; blocks <= 4; blocks++ {
+		l := heatmapLayout{horizontal: horizontal, blocks: blocks, weeks: (weeks + blocks - 1) / blocks}
+		along, across, label := width, height, labelHeight
+		if !horizontal {
+			along, across, label = height, width, labelWidth
+		}
+		l.cell = min(along/float64(l.weeks), (across-float64(blocks)*label-float64(blocks-1)*gap)/float64(7*blocks))
+		if l.cell > best.cell {
+			best = l
+		}
+	}
+	l := best
+	if l.cell <= 0 {
+		return c.drawing()
+	}
+
+	// the grid is centered in the space below the title
+	blockSize := 7 * l.cell
+	gridW := float64(l.weeks) * l.cell
+	gridH := float64(l.blocks)*(labelHeight+blockSize) + float64(l.blocks-1)*gap
+	if !l.horizontal {
+		gridW = float64(l.blocks)*(labelWidth+blockSize) + float64(l.blocks-1)*gap
+		gridH = float64(l.weeks) * l.cell
+	}
+	// days are smaller than their cell, which leaves the gap after them
+	size := l.cell * 0.82
+	gridX := left + (width-gridW+l.cell-size)/2
+	gridY := top + (height-gridH+l.cell-size)/2
+	position := func(week, weekday int) (float64, float64) {
+		block, week := week/l.weeks, week%l.weeks
+		if l.horizontal {
+			return gridX + float64(week)*l.cell, gridY + float64(block)*(labelHeight+blockSize+gap) + labelHeight + float64(weekday)*l.cell
+		}
+		return gridX + float64(block)*(labelWidth+blockSize+gap) + labelWidth + float64(weekday)*l.cell, gridY + float64(week)*l.cell
+	}
+
+	levels := heatThresholds(h.Hours)
+	radius := min(theme.CornerRadius*c.scale, size*0.2)
+	total, played := 0.0, 0
+	for day := range days {
+		hours := 0.0
+		if day < len(h.Hours) {
+			hours = h.Hours[day]
+		}
+		if hours > 0 {
+			total += hours
+			played++
+		}
+		x, y := position((day+offset)/7, (day+offset)%7)
+		c.SetHexColor(withAlpha(theme.Bar, heatLevels[heatLevel(levels, hours)]))
+		if radius > 0 {
+			c.DrawRoundedRectangle(x, y, size, size, radius)
+		} else {
+			c.DrawRectangle(x, y, size, size)
+		}
+		c.Fill()
+	}
+
+	c.SetHexColor(theme.Text)
+	c.SetFontFace(c.faces.regular)
+	for month := time.January; month <= time.December; month++ {
+		day := time.Date(h.Year, month, 1, 0, 0, 0, 0, time.UTC).YearDay() - 1
+		x, y := position((day+offset)/7, 0)
+		name := c.locale.ShortMonth(month)
+		if l.horizontal {
+			c.DrawStringAnchored(name, x, y-labelHeight/2, 0, 0.35)
+		} else {
+			c.DrawStringAnchored(name, x-margin/2, y+l.cell/2, 1, 0.35)
+		}
+	}
+
+	// the footer has the totals on the left and the levels on the right
+	footerY := c.box.y + c.box.h - margin - footerHeight/2
+	totals := c.locale.T("%s, %s in total", c.locale.N(played, "%s day played", "%s days played"), hours(c.locale, total))
+	c.DrawStringAnchored(totals, left, footerY, 0, 0.35)
+	swatch := c.FontHeight()
+	more := c.locale.T("More")
+	x := left + width - measure(c, more)
+	c.DrawStringAnchored(more, x, footerY, 0, 0.35)
+	for i := len(heatLevels) - 1; i >= 0; i-- {
+		x -= swatch * 1.3
+		c.SetHexColor(withAlpha(theme.Bar, heatLevels[i]))
+		c.DrawRectangle(x, footerY-swatch/2, swatch, swatch)
+		c.Fill()
+	}
+	c.SetHexColor(theme.Text)
+	c.DrawStringAnchored(c.locale.T("Less"), x-margin/2, footerY, 1, 0.35)
+	c.Fill()
+
+	return c.drawing()
+}
+
+// heatThresholds splits the days played in quartiles, so the levels show
+// how a day compares with the others, even with a few long sessions.
+func heatThresholds(hours []float64) []float64 {
+	played := []float64{}
+	for _, h := range hours {
+		if h > 0 {
+			played = append(played, h)
+		}
+	}
+	if len(played) == 0 {
+		return nil
+	}
+	slices.Sort(played)
+	thresholds := make([]float64, len(heatLevels)-2)
+	for i := range thresholds {
+		thresholds[i] = played[(i+1)*len(played)/(len(heatLevels)-1)]
+	}
+	return thresholds
+}
+
+func heatLevel(thresholds []float64, hours float64) int {
+	if hours <= 0 {
+		return 0
+	}
+	level := 1
+	for _, t := range thresholds {
+		if hours > t {
+			level++
+		}
+	}
+	return level
+}
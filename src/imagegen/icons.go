@@ -0,0 +1,105 @@
+package imagegen
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"time"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/icons"
+	_ "golang.org/x/image/webp"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	defaultStore                      = icons.NewStore(AssetsFolder)
+	logoProvider   icons.IconProvider = icons.NewChain(icons.Link{Provider: defaultStore})
+	boxArtProvider icons.IconProvider = icons.NewChain(icons.Link{Provider: defaultStore})
+)
+
+// IconRef is an icon a chart needs, by the name it is looked up with.
+type IconRef struct {
+	Name   string `json:"name"`
+	BoxArt bool   `json:"box_art"`
+}
+
+// IconSource is anything drawn next to an icon.
+type IconSource interface {
+	Icon() (IconRef, bool)
+}
+
+var (
+	// IconWorkers is how many icons of a chart are looked up at once.
+	IconWorkers = 4
+	// IconTimeout bounds the lookup of all the icons of a chart, the ones
+	// not found by then are drawn without an icon.
+	IconTimeout = 20 * time.Second
+)
+
+// SetIconProviders sets where logos and box art are looked up. By default
+// only the assets folder is used.
+func SetIconProviders(logos, boxArt icons.IconProvider) {
+	logoProvider = logos
+	boxArtProvider = boxArt
+}
+
+func LoadIconForName(ctx context.Context, name string, isBoxArt bool) (image.Image, error) {
+	provider, kind := logoProvider, icons.Logo
+	if isBoxArt {
+		provider, kind = boxArtProvider, icons.BoxArt
+	}
+	found, err := provider.FindIcon(ctx, name, kind)
+	if err != nil {
+		return nil, err
+	}
+	icon, _, err := image.Decode(bytes.NewReader(found.Data))
+	return icon, err
+}
+
+// prefetchIcons looks up the icons of the items concurrently, before the
+// chart is drawn, so slow providers don't hold the other renders. Items
+// without an icon, or whose icon couldn't be loaded, get nil.
+func prefetchIcons[T IconSource](ctx context.Context, items []T) []image.Image {
+	ctx, cancel := context.WithTimeout(ctx, IconTimeout)
+	defer cancel()
+
+	images := make([]image.Image, len(items))
+	g := errgroup.Group{}
+	g.SetLimit(IconWorkers)
+	for i, item := range items {
+		ref, ok := item.Icon()
+		if !ok {
+			continue
+		}
+		g.Go(func() error {
+			if ctx.Err() != nil {
+				return nil
+			}
+			icon, err := LoadIconForName(ctx, ref.Name, ref.BoxArt)
+			if err != nil {
+				fmt.Println("failed to load icon for: ", ref.Name, err)
+				return nil
+			}
+			images[i] = icon
+			return nil
+		})
+	}
+	g.Wait()
+	return images
+}
+
+// minIconSize is the smallest icon drawn, tiny canvases with long lists
+// leave the rows too short for one.
+const minIconSize = 8
+
+// resizedIcon fits the prefetched icon in a square of the given size, or
+// gives nil when the square is too small for it.
+func resizedIcon(icon image.Image, height uint) image.Image {
+	if icon == nil || height < minIconSize {
+		return nil
+	}
+	return AutoResizeImage(height, icon)
+}
@@ -1,14 +1,8 @@
 package imagegen
 
 import (
-	"bytes"
-	"fmt"
 	"image"
-	"io"
-	"net/http"
-	"os"
 
-	"github.com/alvarowolfx/gamer-journal-wrapped/src/util"
 	"github.com/muesli/smartcrop"
 	"github.com/muesli/smartcrop/nfnt"
 	"github.com/nfnt/resize"
@@ -36,30 +30,3 @@ func AutoResizeImage(h uint, img image.Image) image.Image {
 	}
 	return ResizeImage(h, 0, img)
 }
-
-func DownloadImageFromUrl(name, folder, url string) (image.Image, error) {
-	fmt.Println("Downloading image for game:", name)
-	fmt.Println("from url:", url)
-	response, err := http.Get(url)
-	if err != nil {
-		fmt.Println("failed to download image:", err)
-		return nil, err
-	}
-	defer response.Body.Close()
-
-	buf := bytes.NewBuffer([]byte{})
-	iconReader := io.TeeReader(response.Body, buf)
-
-	icon, _, err := image.Decode(iconReader)
-	if err != nil {
-		fmt.Println("failed to decode image:", err)
-		return nil, err
-	}
-
-	werr := os.WriteFile(fmt.Sprintf("%s/%s.png", folder, util.ToSnakecase(name)), buf.Bytes(), 0644)
-	if werr != nil {
-		fmt.Println("failed to save box art:", werr)
-	}
-
-	return icon, werr
-}
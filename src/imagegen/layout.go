@@ -0,0 +1,394 @@
+package imagegen
+
+import (
+	"context"
+	"image"
+
+	"github.com/fogleman/gg"
+	"golang.org/x/image/font"
+)
+
+// Block is a part of a layout, drawn in the box its parent gives it. Blocks
+// tell how tall they would like to be at a width, or 0 when they take
+// whatever space is left.
+type Block interface {
+	height(l *layout, width float64) float64
+	draw(l *layout, b box)
+}
+
+// parent is a block made of other blocks.
+type parent interface {
+	children() []Block
+}
+
+// layout is a chart being drawn from blocks, with the icons of its image
+// slots already loaded.
+type layout struct {
+	*chart
+	margin float64
+	icons  map[IconRef]image.Image
+	// text is the color of the text blocks without one, which is the card
+	// text on cards.
+	text string
+}
+
+// RenderLayout draws the title, and the block in the content below it.
+func RenderLayout(ctx context.Context, title string, root Block, opts Options) SaveableDrawing {
+	slots := imageSlots(root)
+	icons := prefetchIcons(ctx, slots)
+	c := newChart(opts)
+	l := &layout{chart: c, margin: 20 * c.scale, icons: map[IconRef]image.Image{}, text: c.theme.Text}
+	for i, slot := range slots {
+		ref, _ := slot.Icon()
+		l.icons[ref] = icons[i]
+	}
+
+	top := c.box.y + l.margin
+	if title != "" {
+		top = c.drawTitle(title, l.margin)
+	}
+	b := box{x: c.box.x + 1.5*l.margin, y: top, w: c.box.w - 3*l.margin}
+	b.h = c.box.y + c.box.h - l.margin - top
+	if b.w > 0 && b.h > 0 {
+		root.draw(l, b)
+	}
+	return c.drawing()
+}
+
+func imageSlots(b Block) []ImageSlot {
+	switch b := b.(type) {
+	case ImageSlot:
+		return []ImageSlot{b}
+	case parent:
+		slots := []ImageSlot{}
+		for _, child := range b.children() {
+			slots = append(slots, imageSlots(child)...)
+		}
+		return slots
+	}
+	return nil
+}
+
+// points scales a size given in points before scaling to the canvas.
+func (l *layout) points(p float64) float64 {
+	return p * l.scale
+}
+
+// TextStyle is the font a text block is written with.
+type TextStyle int
+
+const (
+	RegularText TextStyle = iota
+	BoldText
+	TitleText
+)
+
+// Text is wrapped to the width of its block.
+type Text struct {
+	Value string
+	Style TextStyle
+	// Align places the lines, 0 on the left, 0.5 centered and 1 on the
+	// right.
+	Align float64
+	// Color defaults to the text color of the theme, or the card text on
+	// cards.
+	Color string
+}
+
+const textLineSpacing = 1.2
+
+// face is the font of the style, or of a smaller one when a word doesn't
+// fit the width.
+func (t Text) face(l *layout, width float64) font.Face {
+	faces := []font.Face{l.faces.regular}
+	switch t.Style {
+	case TitleText:
+		faces = []font.Face{l.faces.title, l.faces.bold, l.faces.regular}
+	case BoldText:
+		faces = []font.Face{l.faces.bold, l.faces.regular}
+	}
+	for _, face := range faces {
+		l.SetFontFace(face)
+		fits := true
+		for _, line := range l.WordWrap(t.Value, width) {
+			fits = fits && measure(l, line) <= width
+		}
+		if fits {
+			return face
+		}
+	}
+	return l.faces.regular
+}
+
+func (t Text) height(l *layout, width float64) float64 {
+	if t.Value == "" {
+		return 0
+	}
+	l.SetFontFace(t.face(l, width))
+	lines := len(l.WordWrap(t.Value, width))
+	return l.FontHeight() * float64(lines) * textLineSpacing
+}
+
+func (t Text) draw(l *layout, b box) {
+	align := gg.AlignLeft
+	switch {
+	case t.Align >= 1:
+		align = gg.AlignRight
+	case t.Align > 0:
+		align = gg.AlignCenter
+	}
+	l.SetFontFace(t.face(l, b.w))
+	if t.Color != "" {
+		l.SetHexColor(t.Color)
+	} else {
+		l.SetHexColor(l.text)
+	}
+	l.DrawStringWrapped(t.Value, b.x+t.Align*b.w, b.y, t.Align, 0, b.w, textLineSpacing, align)
+	l.Fill()
+}
+
+// ImageSlot is the logo or box art of a name, cropped to the aspect and
+// centered in its block, which takes whatever space is left. Without an
+// aspect it fills the block.
+type ImageSlot struct {
+	Name   string
+	BoxArt bool
+	Aspect float64
+}
+
+func (s ImageSlot) Icon() (IconRef, bool) {
+	return IconRef{Name: s.Name, BoxArt: s.BoxArt}, s.Name != ""
+}
+
+func (s ImageSlot) height(l *layout, width float64) float64 {
+	return 0
+}
+
+func (s ImageSlot) draw(l *layout, b box) {
+	if s.Aspect > 0 {
+		w := min(b.w, b.h*s.Aspect)
+		h := w / s.Aspect
+		b = box{x: b.x + (b.w-w)/2, y: b.y + (b.h-h)/2, w: w, h: h}
+	}
+	if b.w < 1 || b.h < 1 {
+		return
+	}
+	ref, _ := s.Icon()
+	icon := l.icons[ref]
+	if icon == nil {
+		l.SetHexColor(l.theme.IconBackground)
+		l.rect(b.x, b.y, b.w, b.h)
+		l.Fill()
+		return
+	}
+	l.DrawImageAnchored(ResizeAndCropImage(uint(b.w), uint(b.h), icon), int(b.x), int(b.y), 0, 0)
+}
+
+// Stack places the blocks one after the other, from the top, or from the
+// left when horizontal.
+//
+// Vertical stacks give the blocks their height and share what is left
+// between the ones that take the space left, or center them when there
+// are none. The last blocks are left out when they don't fit. Horizontal stacks share the width by the weights, equally
+// without them, and stretch the blocks to the tallest one.
+type Stack struct {
+	Blocks     []Block
+	Horizontal bool
+	Weights    []float64
+	// Gap is the space between the blocks, in points.
+	Gap float64
+}
+
+func (s Stack) children() []Block {
+	return s.Blocks
+}
+
+// widths splits the width between the blocks of a horizontal stack.
+func (s Stack) widths(l *layout, width float64) []float64 {
+	widths := make([]float64, len(s.Blocks))
+	if len(s.Blocks) == 0 {
+		return widths
+	}
+	width -= float64(len(s.Blocks)-1) * l.points(s.Gap)
+	total := 0.0
+	for i := range s.Blocks {
+		total += s.weight(i)
+	}
+	for i := range s.Blocks {
+		widths[i] = width * s.weight(i) / total
+	}
+	return widths
+}
+
+func (s Stack) weight(i int) float64 {
+	if i < len(s.Weights) && s.Weights[i] > 0 {
+		return s.Weights[i]
+	}
+	return 1
+}
+
+func (s Stack) height(l *layout, width float64) float64 {
+	if s.Horizontal {
+		height := 0.0
+		for i, w := range s.widths(l, width) {
+			height = max(height, s.Blocks[i].height(l, w))
+		}
+		return height
+	}
+	height := float64(max(len(s.Blocks)-1, 0)) * l.points(s.Gap)
+	for _, block := range s.Blocks {
+		h := block.height(l, width)
+		if h == 0 {
+			return 0
+		}
+		height += h
+	}
+	return height
+}
+
+func (s Stack) draw(l *layout, b box) {
+	gap := l.points(s.Gap)
+	if s.Horizontal {
+		if h := s.height(l, b.w); h > 0 && h < b.h {
+			b.y, b.h = b.y+(b.h-h)/2, h
+		}
+		x := b.x
+		for i, w := range s.widths(l, b.w) {
+			s.Blocks[i].draw(l, box{x: x, y: b.y, w: w, h: b.h})
+			x += w + gap
+		}
+		return
+	}
+
+	blocks := s.Blocks
+	heights := make([]float64, len(blocks))
+	left := b.h - float64(max(len(blocks)-1, 0))*gap
+	for i, block := range blocks {
+		heights[i] = block.height(l, b.w)
+		left -= heights[i]
+	}
+	// the blocks that don't fit are left out, from the last one, but for
+	// rounding errors
+	for left < -1 && len(blocks) > 1 {
+		last := len(blocks) - 1
+		left += heights[last] + gap
+		blocks, heights = blocks[:last], heights[:last]
+	}
+	flexible := 0
+	for _, h := range heights {
+		if h == 0 {
+			flexible++
+		}
+	}
+	y := b.y
+	if flexible == 0 {
+		y += max(left, 0) / 2
+	}
+	for i, block := range blocks {
+		h := heights[i]
+		if h == 0 {
+			h = max(left, 0) / float64(flexible)
+		}
+		block.draw(l, box{x: b.x, y: y, w: b.w, h: h})
+		y += h + gap
+	}
+}
+
+// Grid places the blocks in rows of as many columns, all of them as wide.
+type Grid struct {
+	Blocks  []Block
+	Columns int
+	// Gap is the space between the rows and columns, in points.
+	Gap float64
+}
+
+func (g Grid) children() []Block {
+	return g.Blocks
+}
+
+func (g Grid) stack() Stack {
+	cols := max(g.Columns, 1)
+	rows := Stack{Gap: g.Gap}
+	for i := 0; i < len(g.Blocks); i += cols {
+		row := Stack{Horizontal: true, Gap: g.Gap}
+		row.Blocks = append(row.Blocks, g.Blocks[i:min(i+cols, len(g.Blocks))]...)
+		// the last row keeps the width of the columns
+		for len(row.Blocks) < cols {
+			row.Blocks = append(row.Blocks, Spacer{})
+		}
+		rows.Blocks = append(rows.Blocks, row)
+	}
+	return rows
+}
+
+func (g Grid) height(l *layout, width float64) float64 {
+	return g.stack().height(l, width)
+}
+
+func (g Grid) draw(l *layout, b box) {
+	g.stack().draw(l, b)
+}
+
+// Spacer is an empty block, taking whatever space is left.
+type Spacer struct{}
+
+func (Spacer) height(l *layout, width float64) float64 {
+	return 0
+}
+
+func (Spacer) draw(l *layout, b box) {}
+
+// Card draws its block on a card of the theme, with its text in the card
+// text color.
+type Card struct {
+	Block Block
+}
+
+func (c Card) children() []Block {
+	return []Block{c.Block}
+}
+
+func (c Card) padding(l *layout) float64 {
+	return l.margin * 0.75
+}
+
+func (c Card) height(l *layout, width float64) float64 {
+	h := c.Block.height(l, width-2*c.padding(l))
+	if h == 0 {
+		return 0
+	}
+	return h + 2*c.padding(l)
+}
+
+func (c Card) draw(l *layout, b box) {
+	padding := c.padding(l)
+	l.SetHexColor(l.theme.Card)
+	l.DrawRoundedRectangle(b.x, b.y, b.w, b.h, l.margin/2)
+	l.Fill()
+
+	text := l.text
+	l.text = l.theme.CardText
+	c.Block.draw(l, box{x: b.x + padding, y: b.y + padding, w: b.w - 2*padding, h: b.h - 2*padding})
+	l.text = text
+}
+
+// StatTile is a number on a card, with what it counts below it.
+type StatTile struct {
+	Value string
+	Label string
+}
+
+func (s StatTile) block() Block {
+	return Card{Stack{Blocks: []Block{
+		Text{Value: s.Value, Style: TitleText, Align: 0.5},
+		Text{Value: s.Label, Align: 0.5},
+	}}}
+}
+
+func (s StatTile) height(l *layout, width float64) float64 {
+	return s.block().height(l, width)
+}
+
+func (s StatTile) draw(l *layout, b box) {
+	s.block().draw(l, b)
+}
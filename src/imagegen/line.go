@@ -0,0 +1,146 @@
+package imagegen
+
+import (
+	"context"
+	"math"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/i18n"
+)
+
+// LinePoint is a value of a line chart, with the label written under it on
+// the x axis, if any.
+type LinePoint struct {
+	Label string  `json:"label,omitempty"`
+	Value float64 `json:"value"`
+}
+
+// LineChart draws the points as a line with the area below it filled, like
+// the hours played over the year.
+type LineChart struct {
+	Title  string
+	Points []LinePoint
+	// Unit is appended to the values, like "h".
+	Unit string
+}
+
+func (l LineChart) Render(ctx context.Context, opts Options) SaveableDrawing {
+	c := newChart(opts)
+	theme := c.theme
+	margin := 20.0 * c.scale
+	top := c.drawTitle(l.Title, margin)
+
+	if len(l.Points) == 0 {
+		return c.drawing()
+	}
+	maxValue := 0.0
+	for _, p := range l.Points {
+		maxValue = max(maxValue, p.Value)
+	}
+	step := niceStep(maxValue / 4)
+	ticks := int(math.Ceil(maxValue/step)) + 1
+	if maxValue <= 0 {
+		ticks = 2
+	}
+	maxAxis := step * float64(ticks-1)
+
+	// the y axis labels are on the left of the plot, and the x axis ones
+	// below it
+	c.SetFontFace(c.faces.regular)
+	axisWidth := measure(c, formatValue(c.locale, maxAxis)+l.Unit) + margin/2
+	left := c.box.x + 1.5*margin + axisWidth
+	right := c.box.x + c.box.w - 1.5*margin
+	plotTop := top + margin
+	plotBottom := c.box.y + c.box.h - margin - c.FontHeight()*2
+	if plotBottom <= plotTop || right <= left {
+		return c.drawing()
+	}
+	xAt := func(i int) float64 {
+		if len(l.Points) == 1 {
+			return left
+		}
+		return left + (right-left)*float64(i)/float64(len(l.Points)-1)
+	}
+	yAt := func(v float64) float64 {
+		return plotBottom - (plotBottom-plotTop)*v/maxAxis
+	}
+
+	line := max(1, c.scale)
+	for i := range ticks {
+		v := step * float64(i)
+		y := yAt(v)
+		c.SetHexColor(withAlpha(theme.Text, 0.2))
+		c.DrawRectangle(left, y-line/2, right-left, line)
+		c.Fill()
+		c.SetHexColor(theme.Text)
+		c.DrawStringAnchored(formatValue(c.locale, v)+l.Unit, left-margin/2, y, 1, 0.35)
+	}
+	c.Fill()
+
+	// the labels are skipped when they would overlap the previous one
+	lastRight := math.Inf(-1)
+	for i, p := range l.Points {
+		if p.Label == "" {
+			continue
+		}
+		x := xAt(i)
+		w := measure(c, p.Label)
+		if x-w/2 < lastRight+margin/2 {
+			continue
+		}
+		c.DrawStringAnchored(p.Label, x, plotBottom+c.FontHeight(), 0.5, 0.35)
+		lastRight = x + w/2
+	}
+	c.Fill()
+
+	c.SetHexColor(withAlpha(theme.Bar, 0.3))
+	c.MoveTo(xAt(0), plotBottom)
+	for i, p := range l.Points {
+		c.LineTo(xAt(i), yAt(p.Value))
+	}
+	c.LineTo(xAt(len(l.Points)-1), plotBottom)
+	c.ClosePath()
+	c.Fill()
+
+	c.SetHexColor(theme.Bar)
+	c.SetLineWidth(3 * c.scale)
+	for i, p := range l.Points {
+		if i == 0 {
+			c.MoveTo(xAt(i), yAt(p.Value))
+		} else {
+			c.LineTo(xAt(i), yAt(p.Value))
+		}
+	}
+	c.Stroke()
+
+	last := l.Points[len(l.Points)-1]
+	x, y := xAt(len(l.Points)-1), yAt(last.Value)
+	c.DrawCircle(x, y, 5*c.scale)
+	c.Fill()
+	c.SetFontFace(c.faces.bold)
+	c.DrawStringAnchored(formatValue(c.locale, last.Value)+l.Unit, x, y-margin, 1, 0)
+	c.Fill()
+
+	return c.drawing()
+}
+
+// niceStep rounds the step up to 1, 2 or 5 times a power of ten, so the
+// axis has round values.
+func niceStep(step float64) float64 {
+	if step <= 0 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(step)))
+	for _, m := range []float64{1, 2, 5} {
+		if step <= m*magnitude {
+			return m * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+func formatValue(l *i18n.Locale, v float64) string {
+	if v == math.Trunc(v) || v >= 10 {
+		return l.Int(int(math.Round(v)))
+	}
+	return l.Float(v, 1)
+}
@@ -2,11 +2,11 @@ package imagegen
 
 import (
 	"fmt"
-	"image"
 	"math"
-	"os"
+	"strings"
+	"time"
 
-	"github.com/alvarowolfx/gamer-journal-wrapped/src/util"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/i18n"
 )
 
 const (
@@ -18,33 +18,54 @@ type MostPlayedByPlaytime struct {
 	Playtime float64 `db:"playtime" json:"playtime"`
 	Count    int     `db:"count" json:"count"`
 	NoIcon   bool    `json:"-"`
+	BoxArt   bool    `json:"-"`
+	// Month is set when the entry is a month, to title it in the language
+	// of the chart.
+	Month time.Month `db:"-" json:"-"`
 }
 
-func (mp MostPlayedByPlaytime) GetTitle() string {
-	if mp.Count == 1 {
-		return fmt.Sprintf("%s (%d game)", mp.Title, mp.Count)
+func (mp MostPlayedByPlaytime) GetTitle(l *i18n.Locale) string {
+	return gamesTitle(l, mp.LocalTitle(l), mp.Count)
+}
+
+// LocalTitle is the title, or the name of the month in the language.
+func (mp MostPlayedByPlaytime) LocalTitle(l *i18n.Locale) string {
+	if mp.Month != 0 {
+		return l.Month(mp.Month)
+	}
+	return mp.Title
+}
+
+// gamesTitle is the title followed by its number of games.
+func gamesTitle(l *i18n.Locale, title string, count int) string {
+	return l.N(count, "%[2]s (%[1]s game)", "%[2]s (%[1]s games)", title)
+}
+
+// hours is the playtime rounded to hours, like "12h".
+func hours(l *i18n.Locale, playtime float64) string {
+	return l.T("%sh", l.Int(int(math.Round(playtime))))
+}
+
+// monthTitle is the English name of a month in the language.
+func monthTitle(l *i18n.Locale, name string) string {
+	for m := time.January; m <= time.December; m++ {
+		if m.String() == name {
+			return l.Month(m)
+		}
 	}
-	return fmt.Sprintf("%s (%d games)", mp.Title, mp.Count)
+	return name
 }
 
 func (mp MostPlayedByPlaytime) GetMetric() int {
 	return int(math.Round(mp.Playtime))
 }
 
-func (mp MostPlayedByPlaytime) RenderMetric() string {
-	return fmt.Sprintf("%dh", mp.GetMetric())
+func (mp MostPlayedByPlaytime) RenderMetric(l *i18n.Locale) string {
+	return hours(l, mp.Playtime)
 }
 
-func (mp MostPlayedByPlaytime) RenderIcon(height uint, serperAPIKey string) image.Image {
-	if mp.NoIcon {
-		return nil
-	}
-	icon, err := LoadIconForName(mp.Title, false, serperAPIKey)
-	if err != nil {
-		fmt.Println("failed to load icon for game: ", mp.Title, err)
-		return nil
-	}
-	return AutoResizeImage(height, icon)
+func (mp MostPlayedByPlaytime) Icon() (IconRef, bool) {
+	return IconRef{Name: mp.Title, BoxArt: mp.BoxArt}, !mp.NoIcon
 }
 
 type MostPlayedByNumGames struct {
@@ -53,20 +74,20 @@ type MostPlayedByNumGames struct {
 	Count    int     `db:"count" json:"count"`
 }
 
-func (mp MostPlayedByNumGames) GetTitle() string {
-	return mp.Title
+func (mp MostPlayedByNumGames) GetTitle(l *i18n.Locale) string {
+	return l.T(mp.Title)
 }
 
 func (mp MostPlayedByNumGames) GetMetric() int {
 	return mp.Count
 }
 
-func (mp MostPlayedByNumGames) RenderMetric() string {
-	return fmt.Sprintf("%d", mp.Count)
+func (mp MostPlayedByNumGames) RenderMetric(l *i18n.Locale) string {
+	return l.Int(mp.Count)
 }
 
-func (mp MostPlayedByNumGames) RenderIcon(height uint, serperAPIKey string) image.Image {
-	return nil
+func (mp MostPlayedByNumGames) Icon() (IconRef, bool) {
+	return IconRef{}, false
 }
 
 type MostPlayedGame struct {
@@ -76,47 +97,182 @@ type MostPlayedGame struct {
 	Playtime float64 `db:"playtime" json:"playtime"`
 }
 
-func (mpg MostPlayedGame) GetTitle() string {
-	return fmt.Sprintf("%s (%s) on %s", mpg.Title, mpg.Platform, mpg.Console)
+func (mpg MostPlayedGame) GetTitle(l *i18n.Locale) string {
+	return l.T("%s (%s) on %s", mpg.Title, mpg.Platform, mpg.Console)
 }
 
 func (mpg MostPlayedGame) GetMetric() int {
 	return int(math.Round(mpg.Playtime))
 }
 
-func (mpg MostPlayedGame) RenderMetric() string {
-	return fmt.Sprintf("%dh", mpg.GetMetric())
+func (mpg MostPlayedGame) RenderMetric(l *i18n.Locale) string {
+	return hours(l, mpg.Playtime)
+}
+
+func (mpg MostPlayedGame) Icon() (IconRef, bool) {
+	return IconRef{Name: mpg.Title, BoxArt: true}, true
+}
+
+type ShareOfGames struct {
+	Title string `db:"title" json:"title"`
+	Count int    `db:"count" json:"count"`
+	Total int    `db:"total" json:"total"`
 }
 
-func (mpg MostPlayedGame) RenderIcon(height uint, serperAPIKey string) image.Image {
-	icon, err := LoadIconForName(mpg.Title, true, serperAPIKey)
-	if err != nil {
-		fmt.Println("failed to load icon for game: ", mpg.Title, err)
-		return nil
+func (s ShareOfGames) Rate() float64 {
+	if s.Total == 0 {
+		return 0
 	}
-	return AutoResizeImage(height, icon)
+	return float64(s.Count) / float64(s.Total)
 }
 
-func LoadIconForName(name string, isBoxArt bool, serperAPIKey string) (image.Image, error) {
-	var icon image.Image
-	iconContent, _ := os.Open(fmt.Sprintf("%s/%s.png", AssetsFolder, util.ToSnakecase(name)))
-	if iconContent == nil {
-		if serperAPIKey == "" {
-			return nil, fmt.Errorf("serper api key not provided")
-		}
-		boxArtURL, err := FindBoxArtUrl(name, isBoxArt, serperAPIKey)
-		if err != nil {
-			fmt.Println("failed to find image for game: ", name)
-			return nil, err
-		}
+func (s ShareOfGames) GetTitle(l *i18n.Locale) string {
+	return l.N(s.Total, "%[2]s (%[3]s of %[1]s game)", "%[2]s (%[3]s of %[1]s games)", s.Title, l.Int(s.Count))
+}
 
-		icon, err = DownloadImageFromUrl(name, AssetsFolder, boxArtURL)
-		if err != nil {
-			fmt.Println("failed to download image for game: ", name)
-			return nil, err
-		}
-		return icon, nil
+func (s ShareOfGames) GetMetric() int {
+	return int(math.Round(s.Rate() * 100))
+}
+
+func (s ShareOfGames) RenderMetric(l *i18n.Locale) string {
+	return l.Int(s.GetMetric()) + "%"
+}
+
+func (s ShareOfGames) Icon() (IconRef, bool) {
+	return IconRef{}, false
+}
+
+type AverageDuration struct {
+	Title string  `db:"title" json:"title"`
+	Days  float64 `db:"days" json:"days"`
+	Count int     `db:"count" json:"count"`
+}
+
+func (ad AverageDuration) GetTitle(l *i18n.Locale) string {
+	return gamesTitle(l, ad.Title, ad.Count)
+}
+
+func (ad AverageDuration) GetMetric() int {
+	return int(math.Round(ad.Days))
+}
+
+func (ad AverageDuration) RenderMetric(l *i18n.Locale) string {
+	return l.T("%sd", l.Int(ad.GetMetric()))
+}
+
+func (ad AverageDuration) Icon() (IconRef, bool) {
+	return IconRef{}, false
+}
+
+type BacklogMonth struct {
+	// Month is the English name of the month.
+	Month    string `json:"month"`
+	Added    int    `json:"added"`
+	Finished int    `json:"finished"`
+	Open     int    `json:"open"`
+}
+
+func (bm BacklogMonth) GetTitle(l *i18n.Locale) string {
+	return fmt.Sprintf("%s (+%s / -%s)", monthTitle(l, bm.Month), l.Int(bm.Added), l.Int(bm.Finished))
+}
+
+func (bm BacklogMonth) GetMetric() int {
+	return bm.Open
+}
+
+func (bm BacklogMonth) RenderMetric(l *i18n.Locale) string {
+	return l.Int(bm.Open)
+}
+
+func (bm BacklogMonth) Icon() (IconRef, bool) {
+	return IconRef{}, false
+}
+
+type BurnDownPoint struct {
+	// Month is the English name of the month.
+	Month     string  `json:"month"`
+	Remaining int     `json:"remaining"`
+	Ideal     float64 `json:"ideal"`
+}
+
+func (bp BurnDownPoint) GetTitle(l *i18n.Locale) string {
+	return monthTitle(l, bp.Month)
+}
+
+func (bp BurnDownPoint) GetMetric() int {
+	return bp.Remaining
+}
+
+func (bp BurnDownPoint) RenderMetric(l *i18n.Locale) string {
+	return l.Int(bp.Remaining)
+}
+
+func (bp BurnDownPoint) Icon() (IconRef, bool) {
+	return IconRef{}, false
+}
+
+// RatedItem is a game, or a group of games, with its average rating from 1
+// to 5, rendered as stars.
+type RatedItem struct {
+	Title    string  `db:"title" json:"title"`
+	Rating   float64 `db:"rating" json:"rating"`
+	Count    int     `db:"count" json:"count,omitempty"`
+	Playtime float64 `db:"playtime" json:"playtime"`
+	NoIcon   bool    `json:"-"`
+	BoxArt   bool    `json:"-"`
+}
+
+func (ri RatedItem) GetTitle(l *i18n.Locale) string {
+	if ri.Count > 0 {
+		return gamesTitle(l, ri.Title, ri.Count)
+	}
+	return fmt.Sprintf("%s (%s)", ri.Title, hours(l, ri.Playtime))
+}
+
+// GetMetric is the rating as a percentage of 5 stars.
+func (ri RatedItem) GetMetric() int {
+	return int(math.Round(ri.Rating * 20))
+}
+
+func (ri RatedItem) RenderMetric(l *i18n.Locale) string {
+	return StarRating(ri.Rating)
+}
+
+func (ri RatedItem) Icon() (IconRef, bool) {
+	return IconRef{Name: ri.Title, BoxArt: ri.BoxArt}, !ri.NoIcon
+}
+
+// StarRating renders a rating rounded to the nearest half star. Space Mono
+// has no star glyph, so stars are drawn as asterisks.
+func StarRating(rating float64) string {
+	halves := int(math.Round(rating * 2))
+	stars := strings.Repeat("*", halves/2)
+	if halves%2 == 1 {
+		stars += "½"
+	}
+	return stars
+}
+
+// Playthrough is a single row of the journal, with its linked records
+// resolved to their names.
+type Playthrough struct {
+	ID        string     `db:"id" json:"id"`
+	Title     string     `db:"title" json:"title"`
+	Serie     string     `db:"serie" json:"serie"`
+	Console   string     `db:"console" json:"console"`
+	Platform  string     `db:"platform" json:"platform"`
+	Status    string     `db:"status" json:"status"`
+	Playtime  float64    `db:"playtime" json:"playtime"`
+	Year      string     `db:"year" json:"year"`
+	StartDate *time.Time `db:"start_date" json:"start_date"`
+	EndDate   *time.Time `db:"end_date" json:"end_date"`
+}
+
+// Days is the amount of days between start and end date, or -1 when the
+// playthrough is not finished.
+func (p Playthrough) Days() int {
+	if p.StartDate == nil || p.EndDate == nil {
+		return -1
 	}
-	icon, _, err := image.Decode(iconContent)
-	return icon, err
+	return int(p.EndDate.Sub(*p.StartDate).Hours() / 24)
 }
@@ -0,0 +1,116 @@
+package imagegen
+
+import (
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+// RenderCover draws a title page, with the subtitle in large type and the
+// lines below it.
+func RenderCover(title, subtitle string, lines []string, opts Options) SaveableDrawing {
+	c := newChart(opts)
+	theme := c.theme
+	margin := 20.0 * c.scale
+	width := c.box.w - 4*margin
+	center := c.box.x + c.box.w/2
+
+	y := c.box.y + c.box.h*0.3
+	c.SetHexColor(theme.Title)
+	c.SetFontFace(c.faces.title)
+	c.DrawStringWrapped(title, center, y, 0.5, 1, width, 1, gg.AlignCenter)
+
+	c.SetFontFace(newFace(theme.TitleFont, theme.TitleFontSize*c.scale*2))
+	c.DrawStringWrapped(subtitle, center, y+margin, 0.5, 0, width, 1, gg.AlignCenter)
+	y += margin + float64(len(c.WordWrap(subtitle, width)))*c.FontHeight() + 2*margin
+
+	c.SetHexColor(theme.Text)
+	c.SetFontFace(c.faces.bold)
+	for _, line := range lines {
+		c.DrawStringAnchored(line, center, y, 0.5, 1)
+		y += c.FontHeight() * 1.6
+	}
+	c.Fill()
+
+	return c.drawing()
+}
+
+// RenderGameList draws a table of the playthroughs, with where they were
+// played, their status and playtime, on as many pages as they need.
+func RenderGameList(title string, games []Playthrough, opts Options) []SaveableDrawing {
+	type row struct {
+		title, platform, status, playtime string
+	}
+	l := opts.locale()
+	rows := make([]row, len(games))
+	for i, g := range games {
+		rows[i] = row{title: g.Title, platform: g.Console, status: l.T(g.Status), playtime: "-"}
+		if rows[i].platform == "" {
+			rows[i].platform = g.Platform
+		}
+		if g.Playtime > 0 {
+			rows[i].playtime = l.T("%sh", l.Float(g.Playtime, 1))
+		}
+	}
+	header := row{l.T("Game"), l.T("Played on"), l.T("Status"), l.T("Playtime")}
+	all := rows
+
+	pages := []SaveableDrawing{}
+	for len(pages) == 0 || len(rows) > 0 {
+		c := newChart(opts)
+		theme := c.theme
+		margin := 20.0 * c.scale
+		top := c.drawTitle(title, margin)
+
+		// the columns fit their longest value on any page, and the title
+		// gets the rest
+		left := c.box.x + 1.5*margin
+		width := c.box.w - 3*margin
+		right := left + width
+		columnWidth := func(value func(r row) string) float64 {
+			c.SetFontFace(c.faces.bold)
+			w, _ := c.MeasureString(value(header))
+			c.SetFontFace(c.faces.regular)
+			for _, r := range all {
+				w = max(w, measure(c, value(r)))
+			}
+			return w
+		}
+		playtimeWidth := columnWidth(func(r row) string { return r.playtime })
+		statusWidth := min(columnWidth(func(r row) string { return r.status }), width*0.2)
+		platformWidth := min(columnWidth(func(r row) string { return r.platform }), width*0.25)
+		statusX := right - playtimeWidth - margin - statusWidth
+		platformX := statusX - margin - platformWidth
+
+		c.SetFontFace(c.faces.regular)
+		rowHeight := c.FontHeight() * 1.8
+		bottom := c.box.y + c.box.h - margin
+		n := min(max(1, int(math.Floor((bottom-top)/rowHeight))-1), len(rows))
+
+		c.SetHexColor(theme.Title)
+		c.SetFontFace(c.faces.bold)
+		y := top + rowHeight/2
+		c.DrawStringAnchored(header.title, left, y, 0, 0.5)
+		c.DrawStringAnchored(header.platform, platformX, y, 0, 0.5)
+		c.DrawStringAnchored(header.status, statusX, y, 0, 0.5)
+		c.DrawStringAnchored(header.playtime, right, y, 1, 0.5)
+
+		for i, r := range rows[:n] {
+			line := top + rowHeight*float64(i+1)
+			c.SetHexColor(theme.CompareBar)
+			c.DrawRectangle(left, line, width, math.Max(1, c.scale/2))
+			c.Fill()
+
+			y := line + rowHeight/2
+			c.SetHexColor(theme.Text)
+			c.SetFontFace(c.faces.regular)
+			c.DrawStringAnchored(ellipsize(c, r.title, platformX-margin-left), left, y, 0, 0.5)
+			c.DrawStringAnchored(ellipsize(c, r.platform, platformWidth), platformX, y, 0, 0.5)
+			c.DrawStringAnchored(ellipsize(c, r.status, statusWidth), statusX, y, 0, 0.5)
+			c.DrawStringAnchored(r.playtime, right, y, 1, 0.5)
+		}
+		rows = rows[n:]
+		pages = append(pages, c.drawing())
+	}
+	return pages
+}
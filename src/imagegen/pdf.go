@@ -0,0 +1,241 @@
+package imagegen
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fogleman/gg"
+	"github.com/go-pdf/fpdf"
+)
+
+// PrintDPI is the resolution charts are printed at on PDF pages, which
+// makes a page of CanvasA4 exactly A4.
+const PrintDPI = 150.0
+
+// Document is a PDF with a chart on each page. Render charts on it by
+// setting it in their Options. Text is written with the chart fonts, so it
+// can be selected and searched.
+type Document struct {
+	pdf *fpdf.Fpdf
+	// fonts maps the font files to their family in the document
+	fonts map[string]string
+	// transform is true while the scale of the last page is applied
+	transform bool
+	images    int
+}
+
+func NewDocument(title string) *Document {
+	pdf := fpdf.NewCustom(&fpdf.InitType{UnitStr: "pt", SizeStr: "A4"})
+	pdf.SetTitle(title, true)
+	pdf.SetCreator("gamer-journal-wrapped", true)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetMargins(0, 0, 0)
+	return &Document{pdf: pdf, fonts: map[string]string{}}
+}
+
+func (d *Document) Pages() int {
+	return d.pdf.PageCount()
+}
+
+func (d *Document) Encode(w io.Writer) error {
+	d.endPage()
+	return d.pdf.Output(w)
+}
+
+func (d *Document) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return d.Encode(f)
+}
+
+// newPage adds a page sized for the canvas to be printed at PrintDPI, and
+// scales it so it is drawn on with the coordinates of the canvas.
+func (d *Document) newPage(width, height int) *pdfPage {
+	d.endPage()
+	k := 72 / PrintDPI
+	d.pdf.AddPageFormat("P", fpdf.SizeType{Wd: float64(width) * k, Ht: float64(height) * k})
+	d.pdf.TransformBegin()
+	d.pdf.TransformScale(k*100, k*100, 0, 0)
+	d.transform = true
+	return &pdfPage{vector: newVector(width, height), doc: d, fill: color.NRGBA{A: 255}}
+}
+
+func (d *Document) endPage() {
+	if d.transform {
+		d.pdf.TransformEnd()
+		d.transform = false
+	}
+}
+
+// family registers the font file in the document, once.
+func (d *Document) family(path string) (string, error) {
+	if family, ok := d.fonts[path]; ok {
+		return family, nil
+	}
+	if f, err := fonts.Load(path); err == nil && f.ttf == nil {
+		return "", fmt.Errorf("failed to embed font %s: only TrueType fonts can be embedded in PDF", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to embed font %s: %v", path, err)
+	}
+	family := fmt.Sprintf("font%d-%s", len(d.fonts), strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	d.pdf.AddUTF8FontFromBytes(family, "", data)
+	d.fonts[path] = family
+	return family, nil
+}
+
+// pdfPage draws a chart on the current page of a document.
+type pdfPage struct {
+	vector
+	doc  *Document
+	fill color.NRGBA
+}
+
+func (p *pdfPage) SetHexColor(x string) {
+	c, err := parseHexColor(x)
+	if err != nil {
+		c = color.Black
+	}
+	p.fill = color.NRGBAModel.Convert(c).(color.NRGBA)
+}
+
+// setAlpha applies the alpha of the current color, which fpdf keeps apart.
+func (p *pdfPage) setAlpha() {
+	p.doc.pdf.SetAlpha(float64(p.fill.A)/255, "Normal")
+}
+
+func (p *pdfPage) Fill() {
+	p.doc.pdf.SetFillColor(int(p.fill.R), int(p.fill.G), int(p.fill.B))
+	p.drawShapes("F")
+}
+
+func (p *pdfPage) Stroke() {
+	pdf := p.doc.pdf
+	pdf.SetDrawColor(int(p.fill.R), int(p.fill.G), int(p.fill.B))
+	pdf.SetLineWidth(p.lineWidth)
+	pdf.SetLineCapStyle("round")
+	pdf.SetLineJoinStyle("round")
+	p.drawShapes("D")
+}
+
+// drawShapes fills or strokes the pending rects and paths.
+func (p *pdfPage) drawShapes(style string) {
+	pdf := p.doc.pdf
+	p.setAlpha()
+	for _, r := range p.rects {
+		if r.r > 0 {
+			pdf.RoundedRect(r.x, r.y, r.w, r.h, r.r, "1234", style)
+		} else {
+			pdf.Rect(r.x, r.y, r.w, r.h, style)
+		}
+	}
+	if len(p.paths) > 0 {
+		for _, path := range p.paths {
+			for i, pt := range path.points {
+				if i == 0 {
+					pdf.MoveTo(pt.x, pt.y)
+				} else {
+					pdf.LineTo(pt.x, pt.y)
+				}
+			}
+			if path.closed {
+				pdf.ClosePath()
+			}
+		}
+		pdf.DrawPath(style)
+	}
+	p.clearPath()
+}
+
+func (p *pdfPage) DrawString(s string, x, y float64) {
+	p.DrawStringAnchored(s, x, y, 0, 0)
+}
+
+func (p *pdfPage) DrawStringAnchored(s string, x, y, ax, ay float64) {
+	pdf := p.doc.pdf
+	w, h := p.MeasureString(s)
+	x -= ax * w
+	y += ay * h
+	pdf.SetTextColor(int(p.fill.R), int(p.fill.G), int(p.fill.B))
+	p.setAlpha()
+	if p.face.path == "" {
+		// the fallback face has no font file, the closest core font is Courier
+		pdf.SetFont("Courier", "", p.FontHeight())
+		pdf.Text(x, y, pdf.UnicodeTranslatorFromDescriptor("")(s))
+		return
+	}
+	// the runs of the fallback fonts are written one after the other
+	for _, run := range p.face.runs(s) {
+		family, err := p.doc.family(run.path)
+		if err != nil {
+			fmt.Println("failed to load font for pdf: ", err)
+		} else {
+			pdf.SetFont(family, "", p.face.size)
+			pdf.Text(x, y, bmp(run.text))
+		}
+		w, _ := p.MeasureString(run.text)
+		x += w
+	}
+}
+
+// bmp leaves out the runes past the Basic Multilingual Plane, like most
+// emoji, which fpdf can't write.
+func bmp(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r > 0xFFFF {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func (p *pdfPage) DrawStringWrapped(s string, x, y, ax, ay, width, lineSpacing float64, align gg.Align) {
+	drawStringWrapped(p, s, x, y, ax, ay, width, lineSpacing, align)
+}
+
+func (p *pdfPage) DrawImageAnchored(im image.Image, x, y int, ax, ay float64) {
+	b := im.Bounds()
+	fx := float64(x) - ax*float64(b.Dx())
+	fy := float64(y) - ay*float64(b.Dy())
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, im); err != nil {
+		fmt.Println("failed to encode image for pdf: ", err)
+		return
+	}
+	p.doc.images++
+	name := fmt.Sprintf("image%d", p.doc.images)
+	options := fpdf.ImageOptions{ImageType: "PNG"}
+	p.doc.pdf.RegisterImageOptionsReader(name, options, &buf)
+	p.doc.pdf.SetAlpha(1, "Normal")
+	p.doc.pdf.ImageOptions(name, fx, fy, float64(b.Dx()), float64(b.Dy()), false, options, 0, "")
+}
+
+// FillVerticalGradient draws a band between each pair of colors, since fpdf
+// gradients only have two.
+func (p *pdfPage) FillVerticalGradient(colors []color.Color) {
+	band := float64(p.height) / float64(len(colors)-1)
+	p.doc.pdf.SetAlpha(1, "Normal")
+	for i := 0; i+1 < len(colors); i++ {
+		from := color.NRGBAModel.Convert(colors[i]).(color.NRGBA)
+		to := color.NRGBAModel.Convert(colors[i+1]).(color.NRGBA)
+		// the gradient vector goes up from the bottom left corner
+		p.doc.pdf.LinearGradient(0, float64(i)*band, float64(p.width), band,
+			int(from.R), int(from.G), int(from.B), int(to.R), int(to.G), int(to.B), 0, 1, 0, 0)
+	}
+}
+
+// Encode writes the whole document the page is part of.
+func (p *pdfPage) Encode(w io.Writer) error {
+	return p.doc.Encode(w)
+}
@@ -0,0 +1,140 @@
+package imagegen
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/i18n"
+)
+
+func TestMain(m *testing.M) {
+	if err := LoadFonts("../../fonts/", nil); err != nil {
+		fmt.Println("failed to load fonts:", err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+// testCharts are charts without icons, so rendering them doesn't look
+// anything up.
+func testCharts() []Chart {
+	playtime := []BarChartItem{
+		MostPlayedByPlaytime{Title: "Nintendo Switch", Playtime: 312.4, NoIcon: true},
+		MostPlayedByPlaytime{Title: "PlayStation 5", Playtime: 120, NoIcon: true},
+		MostPlayedByPlaytime{Title: "ゼルダの伝説 ティアーズ オブ ザ キングダム", Playtime: 98.6, NoIcon: true},
+		MostPlayedByPlaytime{Title: "Steam Deck", Playtime: 12, NoIcon: true},
+	}
+	hours := make([]float64, 365)
+	points := []LinePoint{}
+	for i := range hours {
+		hours[i] = float64(i % 7)
+		if i%30 == 0 {
+			points = append(points, LinePoint{Label: fmt.Sprint(i / 30), Value: float64(i)})
+		}
+	}
+	return []Chart{
+		BarChart{Title: "Most played consoles in 2024", Items: playtime, Limit: len(playtime)},
+		DonutChart{Title: "Playtime by platform in 2024", Items: playtime, Unit: "h", Label: "played"},
+		CalendarHeatmap{Title: "Playtime per day in 2024", Year: 2024, Hours: hours},
+		LineChart{Title: "Hours played over 2024", Points: points, Unit: "h"},
+		comparisonChart{},
+	}
+}
+
+// comparisonChart draws a fixed comparison, as a Chart.
+type comparisonChart struct{}
+
+func (comparisonChart) Render(ctx context.Context, opts Options) SaveableDrawing {
+	data := []ComparisonItem{
+		NewComparisonItem("Nintendo Switch", "h", nil, 200, 312, 1, 1),
+		NewComparisonItem("PlayStation 5", "h", nil, 0, 120, 0, 2),
+		NewComparisonItem("Xbox Series X", "h", nil, 80, 0, 2, 0),
+	}
+	return RenderComparisonWrapped(ctx, "Most played consoles 2023 vs 2024", "2023", "2024", data, len(data), opts)
+}
+
+type renderCase struct {
+	name  string
+	chart Chart
+	opts  Options
+}
+
+// renderCases pairs every chart with each canvas, theme, format and
+// language, each with its own theme.
+func renderCases(t *testing.T) []renderCase {
+	canvases := []string{"540x960", "600x600", "800x450@0.5"}
+	themes := []string{"default", "light", "sunset"}
+	formats := []Format{PNG, SVG}
+	locales := []*i18n.Locale{i18n.English, i18n.Portuguese}
+
+	cases := []renderCase{}
+	for i, chart := range testCharts() {
+		for j, spec := range canvases {
+			canvas, err := ParseCanvas(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			base, ok := FindTheme(themes[(i+j)%len(themes)])
+			if !ok {
+				t.Fatalf("missing theme %s", themes[(i+j)%len(themes)])
+			}
+			theme := *base
+			cases = append(cases, renderCase{
+				name:  fmt.Sprintf("%T/%s/%s", chart, spec, theme.Name),
+				chart: chart,
+				opts: Options{
+					Canvas: canvas,
+					Theme:  &theme,
+					Format: formats[(i+j)%len(formats)],
+					Locale: locales[j%len(locales)],
+				},
+			})
+		}
+	}
+	return cases
+}
+
+func render(t *testing.T, c renderCase) []byte {
+	buf := bytes.Buffer{}
+	if err := c.chart.Render(context.Background(), c.opts).Encode(&buf); err != nil {
+		t.Errorf("failed to encode %s: %v", c.name, err)
+	}
+	return buf.Bytes()
+}
+
+// TestConcurrentRenders renders the same charts serially and then all at
+// the same time, which must give the same bytes. Run it with -race to
+// check that renders share no state.
+func TestConcurrentRenders(t *testing.T) {
+	cases := renderCases(t)
+	serial := make([][]byte, len(cases))
+	for i, c := range cases {
+		serial[i] = render(t, c)
+		if len(serial[i]) == 0 {
+			t.Fatalf("%s is empty", c.name)
+		}
+	}
+
+	const copies = 3
+	concurrent := make([][]byte, len(cases)*copies)
+	wg := sync.WaitGroup{}
+	for i := range concurrent {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			concurrent[i] = render(t, cases[i%len(cases)])
+		}()
+	}
+	wg.Wait()
+
+	for i, got := range concurrent {
+		c := cases[i%len(cases)]
+		if !bytes.Equal(got, serial[i%len(cases)]) {
+			t.Errorf("%s differs when rendered concurrently", c.name)
+		}
+	}
+}
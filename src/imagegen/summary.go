@@ -0,0 +1,93 @@
+package imagegen
+
+import (
+	"context"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/i18n"
+)
+
+// Summary is the hero card of a year, with its totals, the game and
+// console played the most, the busiest month and a highlight. Parts
+// without data are left out.
+type Summary struct {
+	Title        string
+	Hours        float64
+	Played       int
+	Beaten       int
+	TopGame      MostPlayedGame
+	TopConsole   MostPlayedByPlaytime
+	BusiestMonth MostPlayedByPlaytime
+	Highlight    FactCard
+}
+
+// summaryGap is the space between the parts of the summary, in points.
+const summaryGap = 10
+
+// Layout has the totals on top, the top game below them, and the rest
+// under it on portrait canvases. Otherwise the top game takes the left
+// third, and the rest are stacked on the right, but on canvases close to
+// square, where it is beside the highlight.
+func (s Summary) Layout(canvas Canvas, l *i18n.Locale) Block {
+	totals := Grid{Columns: 3, Gap: summaryGap, Blocks: []Block{
+		StatTile{Value: hours(l, s.Hours), Label: l.T("played")},
+		StatTile{Value: l.Int(s.Played), Label: l.N(s.Played, "game", "games")},
+		StatTile{Value: l.Int(s.Beaten), Label: l.N(s.Beaten, "beaten", "beaten")},
+	}}
+
+	details := []Block{}
+	if s.TopConsole.Title != "" {
+		details = append(details, summaryDetail(l.T("Top console"), s.TopConsole.Title, s.TopConsole.RenderMetric(l)))
+	}
+	if s.BusiestMonth.Title != "" {
+		details = append(details, summaryDetail(l.T("Busiest month"), s.BusiestMonth.LocalTitle(l), s.BusiestMonth.RenderMetric(l)))
+	}
+
+	right := []Block{totals}
+	if len(details) > 0 {
+		right = append(right, Grid{Columns: len(details), Gap: summaryGap, Blocks: details})
+	}
+	if s.Highlight.Heading != "" {
+		right = append(right, Card{Stack{Blocks: []Block{
+			Text{Value: s.Highlight.Heading, Style: BoldText},
+			Text{Value: s.Highlight.Value, Style: TitleText},
+			Text{Value: s.Highlight.Text},
+		}}})
+	}
+
+	if s.TopGame.Title == "" {
+		return Stack{Gap: summaryGap, Blocks: right}
+	}
+	topGame := Card{Stack{Gap: summaryGap / 2, Blocks: []Block{
+		Text{Value: l.T("Top game"), Style: BoldText, Align: 0.5},
+		ImageSlot{Name: s.TopGame.Title, BoxArt: true, Aspect: coverAspect},
+		Text{Value: s.TopGame.Title, Style: BoldText, Align: 0.5},
+		Text{Value: s.TopGame.RenderMetric(l), Align: 0.5},
+	}}}
+	switch {
+	case canvas.Portrait():
+		return Stack{Gap: summaryGap, Blocks: append([]Block{totals, topGame}, right[1:]...)}
+	case canvas.Width < canvas.Height*3/2 && len(right) > 2:
+		// on canvases close to square, the top game is beside the highlight
+		return Stack{Gap: summaryGap, Blocks: []Block{
+			totals,
+			Stack{Horizontal: true, Gap: summaryGap, Blocks: []Block{topGame, right[2]}},
+			right[1],
+		}}
+	}
+	return Stack{Horizontal: true, Gap: summaryGap, Weights: []float64{1, 2}, Blocks: []Block{
+		topGame,
+		Stack{Gap: summaryGap, Blocks: right},
+	}}
+}
+
+func summaryDetail(heading, value, metric string) Block {
+	return Card{Stack{Blocks: []Block{
+		Text{Value: heading, Style: BoldText},
+		Text{Value: value, Style: TitleText},
+		Text{Value: metric},
+	}}}
+}
+
+func (s Summary) Render(ctx context.Context, opts Options) SaveableDrawing {
+	return RenderLayout(ctx, s.Title, s.Layout(opts.canvas(), opts.locale()), opts)
+}
@@ -0,0 +1,131 @@
+package imagegen
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fogleman/gg"
+	"golang.org/x/image/font"
+)
+
+// Format is the file format charts are encoded to.
+type Format string
+
+const (
+	PNG Format = "png"
+	SVG Format = "svg"
+	PDF Format = "pdf"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case PNG, SVG, PDF:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown format %q, use png, svg or pdf", s)
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case SVG:
+		return "image/svg+xml"
+	case PDF:
+		return "application/pdf"
+	default:
+		return "image/png"
+	}
+}
+
+// surface is what charts are drawn on. It follows the API of gg.Context,
+// so the layout code is the same for every format.
+type surface interface {
+	Width() int
+	Height() int
+	SetHexColor(x string)
+	SetFontFace(fontFace font.Face)
+	FontHeight() float64
+	MeasureString(s string) (w, h float64)
+	WordWrap(s string, w float64) []string
+	DrawRectangle(x, y, w, h float64)
+	DrawRoundedRectangle(x, y, w, h, r float64)
+	// paths for the shapes that are not rectangles, like in gg a new path
+	// starts at MoveTo or after NewSubPath
+	MoveTo(x, y float64)
+	LineTo(x, y float64)
+	DrawArc(x, y, r, angle1, angle2 float64)
+	DrawCircle(x, y, r float64)
+	ClosePath()
+	NewSubPath()
+	SetLineWidth(lineWidth float64)
+	Fill()
+	Stroke()
+	DrawString(s string, x, y float64)
+	DrawStringAnchored(s string, x, y, ax, ay float64)
+	DrawStringWrapped(s string, x, y, ax, ay, width, lineSpacing float64, align gg.Align)
+	DrawImageAnchored(im image.Image, x, y int, ax, ay float64)
+	// FillVerticalGradient fills the whole surface with the colors, evenly
+	// spaced from top to bottom.
+	FillVerticalGradient(colors []color.Color)
+	Encode(w io.Writer) error
+}
+
+func newSurface(width, height int, opts Options) surface {
+	switch {
+	case opts.Document != nil:
+		return opts.Document.newPage(width, height)
+	case opts.format() == SVG:
+		return newSVG(width, height)
+	case opts.format() == PDF:
+		return NewDocument("").newPage(width, height)
+	default:
+		return &raster{gg.NewContext(width, height)}
+	}
+}
+
+// raster draws the charts as pixels, to encode them as PNG.
+type raster struct {
+	*gg.Context
+}
+
+func (r *raster) FillVerticalGradient(colors []color.Color) {
+	gradient := gg.NewLinearGradient(0, 0, 0, float64(r.Height()))
+	for i, c := range colors {
+		gradient.AddColorStop(float64(i)/float64(len(colors)-1), c)
+	}
+	r.SetFillStyle(gradient)
+	r.DrawRectangle(0, 0, float64(r.Width()), float64(r.Height()))
+	r.Fill()
+}
+
+func (r *raster) Encode(w io.Writer) error {
+	return r.EncodePNG(w)
+}
+
+type SaveableDrawing interface {
+	Save(path string) error
+	Encode(w io.Writer) error
+	Format() Format
+}
+
+type drawing struct {
+	surface
+	format Format
+}
+
+func (d *drawing) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return d.Encode(f)
+}
+
+func (d *drawing) Format() Format {
+	return d.format
+}
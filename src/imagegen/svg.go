@@ -0,0 +1,228 @@
+package imagegen
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/fogleman/gg"
+)
+
+// SVGFontsURL, when set, makes SVG charts reference their fonts from this
+// URL followed by the font file name, instead of embedding them. Embedded
+// fonts make the files self contained, but add around 90KB per font.
+var SVGFontsURL string
+
+// svg draws the charts as SVG elements, with the text written as text so
+// it stays editable.
+type svg struct {
+	vector
+	color     color.Color
+	gradients int
+	body      bytes.Buffer
+}
+
+func newSVG(width, height int) *svg {
+	return &svg{vector: newVector(width, height), color: color.Black}
+}
+
+func (s *svg) SetHexColor(x string) {
+	c, err := parseHexColor(x)
+	if err != nil {
+		c = color.Black
+	}
+	s.color = c
+}
+
+func (s *svg) Fill() {
+	s.writeShapes(svgPaint("fill", s.color))
+}
+
+func (s *svg) Stroke() {
+	s.writeShapes(fmt.Sprintf(`fill="none" %s stroke-width="%s" stroke-linecap="round" stroke-linejoin="round"`,
+		svgPaint("stroke", s.color), num(s.lineWidth)))
+}
+
+// writeShapes writes the pending rects and paths with the paint attributes.
+func (s *svg) writeShapes(paint string) {
+	for _, r := range s.rects {
+		fmt.Fprintf(&s.body, `<rect x="%s" y="%s" width="%s" height="%s"`, num(r.x), num(r.y), num(r.w), num(r.h))
+		if r.r > 0 {
+			fmt.Fprintf(&s.body, ` rx="%s"`, num(r.r))
+		}
+		fmt.Fprintf(&s.body, " %s/>\n", paint)
+	}
+	if len(s.paths) > 0 {
+		s.body.WriteString(`<path d="`)
+		for i, p := range s.paths {
+			for j, pt := range p.points {
+				if i > 0 || j > 0 {
+					s.body.WriteByte(' ')
+				}
+				command := "L"
+				if j == 0 {
+					command = "M"
+				}
+				fmt.Fprintf(&s.body, "%s%s %s", command, num(pt.x), num(pt.y))
+			}
+			if p.closed {
+				s.body.WriteString(" Z")
+			}
+		}
+		fmt.Fprintf(&s.body, `" %s/>`+"\n", paint)
+	}
+	s.clearPath()
+}
+
+func (s *svg) DrawString(str string, x, y float64) {
+	s.DrawStringAnchored(str, x, y, 0, 0)
+}
+
+func (s *svg) DrawStringAnchored(str string, x, y, ax, ay float64) {
+	w, h := s.MeasureString(str)
+	x -= ax * w
+	y += ay * h
+	fmt.Fprintf(&s.body, `<text x="%s" y="%s" %s %s>`, num(x), num(y), s.fontAttrs(), svgPaint("fill", s.color))
+	// the runs of the fallback fonts are spans of the text
+	for _, run := range s.face.runs(str) {
+		if run.path == s.face.path {
+			xml.EscapeText(&s.body, []byte(run.text))
+			continue
+		}
+		s.useFont(run.path)
+		family, weight, style := fontStyle(run.path)
+		fmt.Fprintf(&s.body, `<tspan font-family="%s" font-weight="%s" font-style="%s">`, family, weight, style)
+		xml.EscapeText(&s.body, []byte(run.text))
+		s.body.WriteString("</tspan>")
+	}
+	s.body.WriteString("</text>\n")
+}
+
+func (s *svg) DrawStringWrapped(str string, x, y, ax, ay, width, lineSpacing float64, align gg.Align) {
+	drawStringWrapped(s, str, x, y, ax, ay, width, lineSpacing, align)
+}
+
+func (s *svg) DrawImageAnchored(im image.Image, x, y int, ax, ay float64) {
+	b := im.Bounds()
+	fx := float64(x) - ax*float64(b.Dx())
+	fy := float64(y) - ay*float64(b.Dy())
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, im); err != nil {
+		fmt.Println("failed to encode image for svg: ", err)
+		return
+	}
+	fmt.Fprintf(&s.body, `<image x="%s" y="%s" width="%d" height="%d" xlink:href="data:image/png;base64,%s"/>`+"\n",
+		num(fx), num(fy), b.Dx(), b.Dy(), base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+func (s *svg) FillVerticalGradient(colors []color.Color) {
+	s.gradients++
+	id := fmt.Sprintf("gradient%d", s.gradients)
+	fmt.Fprintf(&s.body, `<defs><linearGradient id="%s" x1="0" y1="0" x2="0" y2="1">`, id)
+	for i, c := range colors {
+		nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+		fmt.Fprintf(&s.body, `<stop offset="%s" stop-color="#%02x%02x%02x" stop-opacity="%s"/>`,
+			num(float64(i)/float64(len(colors)-1)), nc.R, nc.G, nc.B, num(float64(nc.A)/255))
+	}
+	s.body.WriteString("</linearGradient></defs>\n")
+	fmt.Fprintf(&s.body, `<rect x="0" y="0" width="%d" height="%d" fill="url(#%s)"/>`+"\n", s.width, s.height, id)
+}
+
+func (s *svg) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" xml:space="preserve" width="%d" height="%d" viewBox="0 0 %d %d">
+`, s.width, s.height, s.width, s.height)
+	if len(s.fonts) > 0 {
+		bw.WriteString("<style>\n")
+		for _, path := range s.fonts {
+			if err := writeFontFace(bw, path); err != nil {
+				return err
+			}
+		}
+		bw.WriteString("</style>\n")
+	}
+	bw.Write(s.body.Bytes())
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
+
+// fontAttrs are the attributes of the current face. Faces without a font
+// file are the fallback one, which is monospace.
+func (s *svg) fontAttrs() string {
+	if s.face.path == "" {
+		return fmt.Sprintf(`font-family="monospace" font-size="%s"`, num(s.FontHeight()))
+	}
+	family, weight, style := fontStyle(s.face.path)
+	return fmt.Sprintf(`font-family="%s" font-weight="%s" font-style="%s" font-size="%s"`, family, weight, style, num(s.face.size))
+}
+
+// writeFontFace declares the font of the file, embedded or referenced from
+// SVGFontsURL.
+func writeFontFace(w io.Writer, path string) error {
+	family, weight, style := fontStyle(path)
+	mime, format := "font/ttf", "truetype"
+	if f, err := fonts.Load(path); err == nil && f.ttf == nil {
+		mime, format = "font/otf", "opentype"
+	}
+	// the fonts keep their place in the fonts folder, like the fallback ones
+	name := filepath.Base(path)
+	if rel, err := filepath.Rel(fonts.dir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		name = filepath.ToSlash(rel)
+	}
+	src := SVGFontsURL + name
+	if SVGFontsURL == "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to embed font %s: %v", path, err)
+		}
+		src = "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(data)
+	}
+	_, err := fmt.Fprintf(w, "@font-face { font-family: '%s'; font-weight: %s; font-style: %s; src: url(%s) format('%s'); }\n", family, weight, style, src, format)
+	return err
+}
+
+// fontStyle reads the family, weight and style from the names in the font,
+// so the text keeps its font when opened in an editor with it installed.
+func fontStyle(path string) (family, weight, style string) {
+	family, weight, style = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), "normal", "normal"
+	f, err := fonts.Load(path)
+	if err != nil {
+		return family, weight, style
+	}
+	name, subfamily := f.names()
+	if name != "" {
+		family = name
+	}
+	if strings.Contains(subfamily, "Bold") {
+		weight = "bold"
+	}
+	if strings.Contains(subfamily, "Italic") {
+		style = "italic"
+	}
+	return family, weight, style
+}
+
+// svgPaint is the fill or stroke attribute of the color.
+func svgPaint(attr string, c color.Color) string {
+	nc := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if nc.A == 255 {
+		return fmt.Sprintf(`%s="#%02x%02x%02x"`, attr, nc.R, nc.G, nc.B)
+	}
+	return fmt.Sprintf(`%s="#%02x%02x%02x" %s-opacity="%s"`, attr, nc.R, nc.G, nc.B, attr, num(float64(nc.A)/255))
+}
+
+func num(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,139 @@
+package imagegen
+
+import (
+	"strings"
+
+	"github.com/fogleman/gg"
+	"golang.org/x/image/font"
+)
+
+// minTextScale is how far text is shrunk to fit its box, relative to its
+// size in the theme.
+const minTextScale = 0.6
+
+func measure(s surface, text string) float64 {
+	w, _ := s.MeasureString(text)
+	return w
+}
+
+// ellipsize shortens the text with an ellipsis until it fits the width
+// with the current font.
+func ellipsize(s surface, text string, width float64) string {
+	if measure(s, text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		short := string(runes) + "…"
+		if measure(s, short) <= width {
+			return short
+		}
+	}
+	return ""
+}
+
+type fontSize struct {
+	path string
+	size float64
+}
+
+// resize is the face at a fraction of its size, from the same font file.
+func (c *chart) resize(face font.Face, scale float64) font.Face {
+	named, ok := face.(namedFace)
+	if !ok || named.path == "" || scale == 1 {
+		return face
+	}
+	key := fontSize{named.path, named.size * scale}
+	if f, ok := c.resized[key]; ok {
+		return f
+	}
+	f := newFace(key.path, key.size)
+	c.resized[key] = f
+	return f
+}
+
+// fittedText is text wrapped to a box, with the face it fits with.
+type fittedText struct {
+	lines       []string
+	face        font.Face
+	lineSpacing float64
+}
+
+// fitText wraps the text to the width, shrinking the face down to
+// minTextScale of its size until the lines fit the height. When they still
+// don't, the lines past the height are left out, and the last one shown,
+// like any word wider than the box, ends with an ellipsis.
+func (c *chart) fitText(text string, face font.Face, width, height, lineSpacing float64) fittedText {
+	const steps = 8
+	t := fittedText{face: face, lineSpacing: lineSpacing}
+	for step := 0; step <= steps; step++ {
+		t.face = c.resize(face, 1-(1-minTextScale)*float64(step)/steps)
+		c.SetFontFace(t.face)
+		t.lines = c.WordWrap(text, width)
+		if t.height(c) <= height && t.width(c) <= width {
+			return t
+		}
+	}
+
+	fontHeight := c.FontHeight()
+	n := max(1, int((height-fontHeight)/(fontHeight*lineSpacing))+1)
+	if n < len(t.lines) {
+		rest := strings.Join(t.lines[n-1:], " ")
+		t.lines = t.lines[:n]
+		// the rest of the text is too wide, so it always ends with an ellipsis
+		t.lines[n-1] = rest
+	}
+	for i, line := range t.lines {
+		t.lines[i] = ellipsize(c, line, width)
+	}
+	return t
+}
+
+func (t fittedText) height(s surface) float64 {
+	s.SetFontFace(t.face)
+	n := float64(len(t.lines))
+	return n*s.FontHeight()*t.lineSpacing - (t.lineSpacing-1)*s.FontHeight()
+}
+
+func (t fittedText) width(s surface) float64 {
+	s.SetFontFace(t.face)
+	width := 0.0
+	for _, line := range t.lines {
+		width = max(width, measure(s, line))
+	}
+	return width
+}
+
+// draw places the lines like DrawStringWrapped.
+func (t fittedText) draw(s surface, x, y, ax, ay, width float64, align gg.Align) {
+	h := t.height(s)
+	x -= ax * width
+	y -= ay * h
+	switch align {
+	case gg.AlignLeft:
+		ax = 0
+	case gg.AlignCenter:
+		ax = 0.5
+		x += width / 2
+	case gg.AlignRight:
+		ax = 1
+		x += width
+	}
+	for _, line := range t.lines {
+		s.DrawStringAnchored(line, x, y, ax, 1)
+		y += s.FontHeight() * t.lineSpacing
+	}
+}
+
+// metricX is where a metric starts, after the end of the bar, or inside
+// it, right-aligned, when it would run past the right of the content.
+// Metrics that fit neither way stay after the bar.
+func (c *chart) metricX(metric string, barX, barWidth, gap, margin float64) (x float64, inside bool) {
+	w := measure(c, metric)
+	x = barX + barWidth + gap
+	if x+w <= c.box.x+c.box.w-margin || w > barWidth-gap {
+		return x, false
+	}
+	return barX + barWidth - gap - w, true
+}
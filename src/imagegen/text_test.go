@@ -0,0 +1,268 @@
+package imagegen
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "write the golden images in testdata")
+
+func testChart(t *testing.T) *chart {
+	canvas, err := ParseCanvas("540x960")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newChart(Options{Canvas: canvas})
+}
+
+func TestEllipsize(t *testing.T) {
+	c := testChart(t)
+	c.SetFontFace(c.faces.regular)
+
+	tests := []struct {
+		name  string
+		text  string
+		width float64
+	}{
+		{"fits", "Hades", 200},
+		{"long title", "The Legend of Zelda: Tears of the Kingdom Collector's Edition", 200},
+		{"unbreakable word", strings.Repeat("Supercalifragilistic", 5), 120},
+		{"japanese", "ゼルダの伝説 ティアーズ オブ ザ キングダム", 100},
+		{"emoji", "🎮🎮🎮🎮🎮🎮🎮🎮🎮🎮🎮🎮🎮🎮🎮🎮🎮🎮🎮🎮", 80},
+		{"narrower than the ellipsis", "Hades", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ellipsize(c, tt.text, tt.width)
+			if w := measure(c, got); w > tt.width {
+				t.Errorf("%q is %v wide, more than %v", got, w, tt.width)
+			}
+			if measure(c, tt.text) <= tt.width {
+				if got != tt.text {
+					t.Errorf("%q was shortened to %q", tt.text, got)
+				}
+				return
+			}
+			if got == "" {
+				if measure(c, "…") <= tt.width {
+					t.Errorf("%q was dropped, but the ellipsis fits", tt.text)
+				}
+				return
+			}
+			prefix, ok := strings.CutSuffix(got, "…")
+			if !ok || !strings.HasPrefix(tt.text, prefix) {
+				t.Fatalf("%q is not a prefix of %q with an ellipsis", got, tt.text)
+			}
+			// it keeps as much of the text as fits
+			next := []rune(tt.text)[len([]rune(prefix))]
+			if measure(c, prefix+string(next)+"…") <= tt.width {
+				t.Errorf("%q could keep %q", got, string(next))
+			}
+		})
+	}
+}
+
+func TestFitText(t *testing.T) {
+	c := testChart(t)
+	c.SetFontFace(c.faces.regular)
+	lineHeight := c.FontHeight()
+
+	tests := []struct {
+		name     string
+		text     string
+		width    float64
+		height   float64
+		shrunk   bool
+		ellipsis bool
+	}{
+		{"fits", "Hades (12 games)", 400, 2 * lineHeight, false, false},
+		{"wraps", "Hades, Hades II and Dead Cells (12 games)", 200, 3 * lineHeight, false, false},
+		{"shrinks", "Hades and Dead Cells", 0.8 * measure(c, "Hades and Dead Cells"), lineHeight, true, false},
+		{"very long title", strings.Repeat("The Legend of Zelda ", 20), 300, 2 * lineHeight, true, true},
+		{"unbreakable word", strings.Repeat("Supercalifragilistic", 5) + " (1 game)", 150, 2 * lineHeight, true, true},
+		{"japanese", strings.Repeat("ゼルダの伝説", 10), 150, lineHeight, true, true},
+		{"emoji", strings.Repeat("🎮", 40) + " (3 games)", 150, lineHeight, true, true},
+		{"lower than a line", "Hades", 400, lineHeight / 2, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fit := c.fitText(tt.text, c.faces.regular, tt.width, tt.height, 1)
+			if len(fit.lines) == 0 {
+				t.Fatalf("no lines")
+			}
+			if w := fit.width(c); w > tt.width {
+				t.Errorf("lines are %v wide, more than %v: %q", w, tt.width, fit.lines)
+			}
+			if h := fit.height(c); h > tt.height && len(fit.lines) > 1 {
+				t.Errorf("%d lines are %v high, more than %v", len(fit.lines), h, tt.height)
+			}
+			if shrunk := fit.face != c.faces.regular; shrunk != tt.shrunk {
+				t.Errorf("shrunk = %v, want %v", shrunk, tt.shrunk)
+			}
+
+			whole := strings.Join(fit.lines, " ") == tt.text
+			if whole == tt.ellipsis {
+				t.Errorf("lines %q are the whole text = %v, want %v", fit.lines, whole, !tt.ellipsis)
+			}
+			// what is left out is marked with an ellipsis, at the end of
+			// the last line when lines are left out
+			for i, line := range fit.lines {
+				if !tt.ellipsis && strings.Contains(line, "…") {
+					t.Errorf("line %d %q has an ellipsis", i, line)
+				}
+			}
+			if tt.ellipsis && !strings.Contains(strings.Join(fit.lines, ""), "…") {
+				t.Errorf("lines %q have no ellipsis", fit.lines)
+			}
+			shown := strings.ReplaceAll(strings.Join(fit.lines, " "), "…", "")
+			if tt.ellipsis && len(strings.Fields(shown)) < len(strings.Fields(tt.text)) && !strings.HasSuffix(fit.lines[len(fit.lines)-1], "…") {
+				t.Errorf("lines %q leave words out without an ellipsis at the end", fit.lines)
+			}
+		})
+	}
+}
+
+func TestMetricX(t *testing.T) {
+	c := testChart(t)
+	c.SetFontFace(c.faces.bold)
+	margin, gap := 20.0, 10.0
+	left := c.box.x + margin
+	right := c.box.x + c.box.w - margin
+	huge := "1,234,567,890h"
+	hugeWidth := measure(c, huge)
+
+	tests := []struct {
+		name     string
+		metric   string
+		barX     float64
+		barWidth float64
+		inside   bool
+	}{
+		{"short bar", "12h", left, 100, false},
+		{"full bar", "312h", left, right - left, true},
+		{"huge metric on a full bar", huge, left, right - left, true},
+		{"huge metric on a short bar", huge, left, 100, false},
+		{"huge metric wider than the bar at the edge", huge, right - hugeWidth, hugeWidth, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, inside := c.metricX(tt.metric, tt.barX, tt.barWidth, gap, margin)
+			w := measure(c, tt.metric)
+			if inside != tt.inside {
+				t.Errorf("inside = %v, want %v", inside, tt.inside)
+			}
+			if inside {
+				if x < tt.barX || x+w > tt.barX+tt.barWidth-gap+1e-9 {
+					t.Errorf("metric from %v to %v is outside the bar from %v to %v", x, x+w, tt.barX, tt.barX+tt.barWidth)
+				}
+				return
+			}
+			if x != tt.barX+tt.barWidth+gap {
+				t.Errorf("x = %v, want right after the bar at %v", x, tt.barX+tt.barWidth+gap)
+			}
+			// after the bar, it only runs past the content when it doesn't
+			// fit inside either
+			if x+w > right && w <= tt.barWidth-gap {
+				t.Errorf("metric runs past the content to %v, but fits the bar", x+w)
+			}
+		})
+	}
+}
+
+// TestPathologicalTitles draws bar charts with the titles and metrics that
+// are hard to fit, and compares them with the golden images in testdata.
+// Run it with -update to write them again after changing the layout.
+func TestPathologicalTitles(t *testing.T) {
+	canvas, err := ParseCanvas("540x960")
+	if err != nil {
+		t.Fatal(err)
+	}
+	games := func(first BarChartItem) []BarChartItem {
+		return []BarChartItem{
+			first,
+			MostPlayedByPlaytime{Title: "Hades", Playtime: 80, Count: 2, NoIcon: true},
+			MostPlayedByPlaytime{Title: "Celeste", Playtime: 24, Count: 1, NoIcon: true},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		items []BarChartItem
+	}{
+		{"long-title", games(MostPlayedByPlaytime{Title: "The Legend of Zelda: Tears of the Kingdom (Nintendo Switch) on Switch OLED", Playtime: 120, Count: 1, NoIcon: true})},
+		{"unbreakable-word", games(MostPlayedByPlaytime{Title: strings.Repeat("Supercalifragilistic", 5), Playtime: 120, Count: 1, NoIcon: true})},
+		{"cjk", games(MostPlayedByPlaytime{Title: "ゼルダの伝説 ティアーズ オブ ザ キングダム", Playtime: 120, Count: 1, NoIcon: true})},
+		{"emoji", games(MostPlayedByPlaytime{Title: "Pokémon 🎮✨ Scarlet & Violet 🐉", Playtime: 120, Count: 3, NoIcon: true})},
+		{"near-max-metric", []BarChartItem{
+			MostPlayedByPlaytime{Title: "Nintendo Switch", Playtime: 123456789, Count: 250, NoIcon: true},
+			MostPlayedByPlaytime{Title: "Steam Deck", Playtime: 120000000, Count: 180, NoIcon: true},
+			MostPlayedByPlaytime{Title: "PlayStation 5", Playtime: 98765432, Count: 120, NoIcon: true},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			d := RenderMostPlayedWrapped(context.Background(), "Most played games in 2024", tt.items, len(tt.items), Options{Canvas: canvas})
+			if err := d.Encode(&buf); err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join("testdata", tt.name+".png")
+			if *update {
+				if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			got, err := png.Decode(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("failed to open golden image, run with -update to write it: %v", err)
+			}
+			defer f.Close()
+			want, err := png.Decode(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := diffImages(got, want); diff != "" {
+				t.Errorf("differs from %s: %s", path, diff)
+			}
+		})
+	}
+}
+
+// diffImages describes how two images differ, or is empty when they are
+// the same.
+func diffImages(got, want image.Image) string {
+	if got.Bounds() != want.Bounds() {
+		return "size is " + got.Bounds().String() + ", want " + want.Bounds().String()
+	}
+	diff := image.Rectangle{}
+	pixels := 0
+	b := got.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r0, g0, b0, a0 := got.At(x, y).RGBA()
+			r1, g1, b1, a1 := want.At(x, y).RGBA()
+			if r0 != r1 || g0 != g1 || b0 != b1 || a0 != a1 {
+				diff = diff.Union(image.Rect(x, y, x+1, y+1))
+				pixels++
+			}
+		}
+	}
+	if pixels == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d pixels differ in %v", pixels, diff)
+}
@@ -0,0 +1,367 @@
+package imagegen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Theme is the look of the charts. Colors are hex strings like "#14213D",
+// font sizes are in points before scaling to the canvas, and fonts are TrueType
+// files. Fields left empty in a theme file use the default theme.
+type Theme struct {
+	Name string `json:"name" yaml:"name"`
+
+	Background string `json:"background" yaml:"background"`
+	// BackgroundGradient is drawn from top to bottom over the background.
+	BackgroundGradient []string `json:"background_gradient,omitempty" yaml:"background_gradient"`
+	// BackgroundImage is cropped to fill the chart, over the background.
+	BackgroundImage string `json:"background_image,omitempty" yaml:"background_image"`
+
+	Title          string `json:"title" yaml:"title"`
+	Text           string `json:"text" yaml:"text"`
+	Bar            string `json:"bar" yaml:"bar"`
+	CompareBar     string `json:"compare_bar" yaml:"compare_bar"`
+	IconBackground string `json:"icon_background" yaml:"icon_background"`
+	Card           string `json:"card" yaml:"card"`
+	CardText       string `json:"card_text" yaml:"card_text"`
+	// RankBars colors the bars by rank, starting from the top entry. The
+	// ranks past the end of the list use Bar.
+	RankBars []string `json:"rank_bars,omitempty" yaml:"rank_bars"`
+
+	TitleFont       string  `json:"title_font" yaml:"title_font"`
+	BoldFont        string  `json:"bold_font" yaml:"bold_font"`
+	RegularFont     string  `json:"regular_font" yaml:"regular_font"`
+	TitleFontSize   float64 `json:"title_font_size" yaml:"title_font_size"`
+	BoldFontSize    float64 `json:"bold_font_size" yaml:"bold_font_size"`
+	RegularFontSize float64 `json:"regular_font_size" yaml:"regular_font_size"`
+
+	// CornerRadius rounds the bars and icon frames.
+	CornerRadius float64 `json:"corner_radius" yaml:"corner_radius"`
+}
+
+var DefaultTheme = Theme{
+	Name:            "default",
+	Background:      "#14213D",
+	Title:           "#FCA311",
+	Text:            "#FCA311",
+	Bar:             "#FCA311",
+	CompareBar:      "#E5E5E5",
+	IconBackground:  "#FFF",
+	Card:            "#FCA311",
+	CardText:        "#14213D",
+	TitleFont:       BoldFontFile,
+	BoldFont:        BoldFontFile,
+	RegularFont:     RegularFontFile,
+	TitleFontSize:   48,
+	BoldFontSize:    20,
+	RegularFontSize: 14,
+}
+
+var builtinThemes = []Theme{
+	DefaultTheme,
+	{
+		Name:       "mint",
+		Background: "#000000",
+		Title:      "#B4F8C8",
+		Text:       "#B4F8C8",
+		Bar:        "#B4F8C8",
+		CompareBar: "#5C7D66",
+		Card:       "#B4F8C8",
+		CardText:   "#000000",
+	},
+	{
+		Name:           "light",
+		Background:     "#FAFAFA",
+		Title:          "#14213D",
+		Text:           "#14213D",
+		Bar:            "#2A9D8F",
+		CompareBar:     "#C9D6DF",
+		IconBackground: "#FFF",
+		Card:           "#E9F5F3",
+		CardText:       "#14213D",
+		RankBars:       []string{"#E76F51", "#F4A261", "#E9C46A"},
+		CornerRadius:   6,
+	},
+	{
+		Name:               "sunset",
+		Background:         "#2B1055",
+		BackgroundGradient: []string{"#2B1055", "#7597DE"},
+		Title:              "#FFFFFF",
+		Text:               "#FFFFFF",
+		Bar:                "#FFD166",
+		CompareBar:         "#B8C0FF",
+		Card:               "#FFFFFF",
+		CardText:           "#2B1055",
+		RankBars:           []string{"#EF476F", "#F78C6B", "#FFD166"},
+		CornerRadius:       10,
+	},
+}
+
+var (
+	themesMu sync.RWMutex
+	themes   = map[string]Theme{}
+)
+
+func init() {
+	for _, t := range builtinThemes {
+		themes[t.Name] = t.withDefaults()
+	}
+}
+
+// RegisterTheme makes a theme selectable by name, replacing the one with
+// the same name.
+func RegisterTheme(t Theme) {
+	themesMu.Lock()
+	defer themesMu.Unlock()
+	themes[t.Name] = t.withDefaults()
+}
+
+// FindTheme returns the registered theme with the name.
+func FindTheme(name string) (*Theme, bool) {
+	themesMu.RLock()
+	defer themesMu.RUnlock()
+	t, ok := themes[name]
+	if !ok {
+		return nil, false
+	}
+	return &t, true
+}
+
+func ThemeNames() []string {
+	themesMu.RLock()
+	defer themesMu.RUnlock()
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadThemes reads and registers all the *.json, *.yaml and *.yml themes in
+// dir. A missing directory is not an error, there are just no user themes.
+func LoadThemes(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read themes folder: %v", err)
+	}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		t, err := LoadTheme(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		RegisterTheme(*t)
+	}
+	return nil
+}
+
+// LoadTheme reads a theme from a JSON or YAML file. The name defaults to
+// the file name.
+func LoadTheme(path string) (*Theme, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read theme %s: %v", path, err)
+	}
+	t := Theme{}
+	if filepath.Ext(path) == ".json" {
+		err = json.Unmarshal(content, &t)
+	} else {
+		err = yaml.Unmarshal(content, &t)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse theme %s: %v", path, err)
+	}
+	if t.Name == "" {
+		t.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	t = t.withDefaults()
+	if err := t.validate(); err != nil {
+		return nil, fmt.Errorf("invalid theme %s: %v", path, err)
+	}
+	return &t, nil
+}
+
+// withDefaults fills the fields left empty with the ones of the default
+// theme.
+func (t Theme) withDefaults() Theme {
+	d := DefaultTheme
+	setDefault(&t.Background, d.Background)
+	setDefault(&t.Title, d.Title)
+	setDefault(&t.Text, d.Text)
+	setDefault(&t.Bar, d.Bar)
+	setDefault(&t.CompareBar, d.CompareBar)
+	setDefault(&t.IconBackground, d.IconBackground)
+	setDefault(&t.Card, d.Card)
+	setDefault(&t.CardText, d.CardText)
+	setDefault(&t.TitleFont, d.TitleFont)
+	setDefault(&t.BoldFont, d.BoldFont)
+	setDefault(&t.RegularFont, d.RegularFont)
+	if t.TitleFontSize <= 0 {
+		t.TitleFontSize = d.TitleFontSize
+	}
+	if t.BoldFontSize <= 0 {
+		t.BoldFontSize = d.BoldFontSize
+	}
+	if t.RegularFontSize <= 0 {
+		t.RegularFontSize = d.RegularFontSize
+	}
+	return t
+}
+
+func setDefault(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+func (t Theme) validate() error {
+	colors := []string{t.Background, t.Title, t.Text, t.Bar, t.CompareBar, t.IconBackground, t.Card, t.CardText}
+	colors = append(colors, t.BackgroundGradient...)
+	colors = append(colors, t.RankBars...)
+	for _, c := range colors {
+		if _, err := parseHexColor(c); err != nil {
+			return err
+		}
+	}
+	for _, path := range []string{t.TitleFont, t.BoldFont, t.RegularFont} {
+		if _, err := fonts.Load(path); err != nil {
+			return fmt.Errorf("failed to load font %s: %v", path, err)
+		}
+	}
+	if t.BackgroundImage != "" {
+		if _, err := loadBackgroundImage(t.BackgroundImage); err != nil {
+			return fmt.Errorf("failed to load background image: %v", err)
+		}
+	}
+	return nil
+}
+
+// BarColor is the color of the bar at the rank, starting from 0.
+func (t *Theme) BarColor(rank int) string {
+	if rank >= 0 && rank < len(t.RankBars) {
+		return t.RankBars[rank]
+	}
+	return t.Bar
+}
+
+// SliceColor is the color of a slice of a pie chart of n slices. The ones
+// past the rank colors fade from the bar color to the background, starting
+// a step away from it when there are rank colors, as it is often one of
+// them.
+func (t *Theme) SliceColor(i, n int) string {
+	if i < len(t.RankBars) {
+		return t.RankBars[i]
+	}
+	step := i - len(t.RankBars)
+	steps := n - len(t.RankBars)
+	if len(t.RankBars) > 0 {
+		step++
+		steps++
+	}
+	return mixColors(t.Bar, t.Background, 0.7*float64(step)/float64(max(steps, 1)))
+}
+
+// mixColors is the color at w of the way from the first color to the
+// second one.
+func mixColors(from, to string, w float64) string {
+	a, err := parseHexColor(from)
+	if err != nil {
+		return from
+	}
+	b, err := parseHexColor(to)
+	if err != nil {
+		return from
+	}
+	ca, cb := a.(color.NRGBA), b.(color.NRGBA)
+	mix := func(x, y uint8) uint8 {
+		return uint8(float64(x) + (float64(y)-float64(x))*w + 0.5)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", mix(ca.R, cb.R), mix(ca.G, cb.G), mix(ca.B, cb.B), ca.A)
+}
+
+func parseHexColor(s string) (color.Color, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return nil, fmt.Errorf("invalid color %q", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid color %q", s)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+var backgroundImages sync.Map
+
+func loadBackgroundImage(path string) (image.Image, error) {
+	if img, ok := backgroundImages.Load(path); ok {
+		return img.(image.Image), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	backgroundImages.Store(path, img)
+	return img, nil
+}
+
+// drawBackground fills the chart with the background color, then the
+// gradient and the image of the theme, when it has them.
+func drawBackground(s surface, t *Theme) {
+	s.SetHexColor(t.Background)
+	s.DrawRectangle(0, 0, float64(s.Width()), float64(s.Height()))
+	s.Fill()
+
+	if len(t.BackgroundGradient) > 1 {
+		colors := []color.Color{}
+		for _, hex := range t.BackgroundGradient {
+			c, err := parseHexColor(hex)
+			if err != nil {
+				continue
+			}
+			colors = append(colors, c)
+		}
+		if len(colors) > 1 {
+			s.FillVerticalGradient(colors)
+		}
+	}
+
+	if t.BackgroundImage != "" {
+		img, err := loadBackgroundImage(t.BackgroundImage)
+		if err != nil {
+			fmt.Println("failed to load background image: ", t.BackgroundImage, err)
+			return
+		}
+		s.DrawImageAnchored(ResizeAndCropImage(uint(s.Width()), uint(s.Height()), img), 0, 0, 0, 0)
+	}
+}
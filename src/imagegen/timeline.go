@@ -0,0 +1,171 @@
+package imagegen
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+)
+
+// Timeline draws each playthrough of the year as a bar from its start to
+// its end date, like a Gantt chart. Unfinished playthroughs go on until
+// today, and when there are more than fit, the ones played the longest are
+// kept.
+type Timeline struct {
+	Title        string
+	Year         int
+	Playthroughs []Playthrough
+}
+
+type span struct {
+	title      string
+	start, end time.Time
+	playtime   float64
+	abandoned  bool
+	playing    bool
+}
+
+func (t Timeline) spans() []span {
+	first := time.Date(t.Year, 1, 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(1, 0, 0)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	spans := []span{}
+	for _, p := range t.Playthroughs {
+		if p.StartDate == nil {
+			continue
+		}
+		s := span{title: p.Title, start: *p.StartDate, playtime: p.Playtime, abandoned: p.Status == "Abandoned"}
+		if p.EndDate != nil {
+			s.end = *p.EndDate
+		} else {
+			s.end, s.playing = today, !s.abandoned
+		}
+		// the bars cover whole days, and only the ones of the year
+		s.end = s.end.AddDate(0, 0, 1)
+		if s.start.Before(first) {
+			s.start = first
+		}
+		if s.end.After(last) {
+			s.end = last
+		}
+		if !s.end.After(s.start) {
+			continue
+		}
+		spans = append(spans, s)
+	}
+	return spans
+}
+
+func (t Timeline) Render(ctx context.Context, opts Options) SaveableDrawing {
+	c := newChart(opts)
+	theme := c.theme
+	margin := 20.0 * c.scale
+	top := c.drawTitle(t.Title, margin)
+
+	spans := t.spans()
+	first := time.Date(t.Year, 1, 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(1, 0, 0)
+
+	c.SetFontFace(c.faces.regular)
+	headerHeight := c.FontHeight() * 2
+	legendHeight := c.FontHeight() * 2.5
+	minRow := c.FontHeight() * 1.3
+	left := c.box.x + 1.5*margin
+	width := c.box.w - 3*margin
+	rowsTop := top + headerHeight
+	rowsBottom := c.box.y + c.box.h - margin - legendHeight
+
+	n := min(len(spans), max(1, int((rowsBottom-rowsTop)/minRow)))
+	if n < len(spans) {
+		slices.SortStableFunc(spans, func(a, b span) int {
+			return cmp.Compare(b.playtime, a.playtime)
+		})
+		spans = spans[:n]
+	}
+	slices.SortStableFunc(spans, func(a, b span) int {
+		return a.start.Compare(b.start)
+	})
+	rowHeight := 40 * c.scale
+	if n > 0 {
+		rowHeight = min(rowHeight, (rowsBottom-rowsTop)/float64(n))
+	}
+
+	// the titles take the left of the chart, up to a third of it
+	labelWidth := 0.0
+	for _, s := range spans {
+		labelWidth = max(labelWidth, measure(c, s.title))
+	}
+	labelWidth = min(labelWidth, width/3)
+	timelineX := left + labelWidth + margin
+	timelineWidth := left + width - timelineX
+	xAt := func(d time.Time) float64 {
+		return timelineX + timelineWidth*d.Sub(first).Hours()/last.Sub(first).Hours()
+	}
+
+	// months are marked by a line at their start, with their initial when
+	// the name doesn't fit
+	monthWidth := timelineWidth / 12
+	line := max(1, c.scale)
+	for month := time.January; month <= time.December; month++ {
+		x := xAt(time.Date(t.Year, month, 1, 0, 0, 0, 0, time.UTC))
+		c.SetHexColor(withAlpha(theme.Text, 0.2))
+		c.DrawRectangle(x, rowsTop, line, float64(n)*rowHeight)
+		c.Fill()
+		name := c.locale.ShortMonth(month)
+		if measure(c, name) > monthWidth*0.9 {
+			name = string([]rune(name)[:1])
+		}
+		c.SetHexColor(theme.Text)
+		c.DrawStringAnchored(name, x+monthWidth/2, top+headerHeight/2, 0.5, 0.35)
+	}
+	c.Fill()
+
+	for i, s := range spans {
+		y := rowsTop + float64(i)*rowHeight
+		c.SetHexColor(theme.Text)
+		c.DrawStringAnchored(ellipsize(c, s.title, labelWidth), left, y+rowHeight/2, 0, 0.35)
+
+		c.SetHexColor(timelineColor(theme, s))
+		barHeight := rowHeight * 0.6
+		x := xAt(s.start)
+		c.rect(x, y+(rowHeight-barHeight)/2, max(xAt(s.end)-x, 2*c.scale), barHeight)
+		c.Fill()
+	}
+
+	// the legend only has the kinds of playthroughs shown
+	legend := []span{
+		{title: c.locale.T("Finished")},
+		{title: c.locale.T("Abandoned"), abandoned: true},
+		{title: c.locale.T("Playing"), playing: true},
+	}
+	legend = slices.DeleteFunc(legend, func(l span) bool {
+		return !slices.ContainsFunc(spans, func(s span) bool {
+			return s.abandoned == l.abandoned && s.playing == l.playing
+		})
+	})
+	swatch := c.FontHeight()
+	x := left
+	y := c.box.y + c.box.h - margin - legendHeight/2
+	for _, l := range legend {
+		c.SetHexColor(timelineColor(theme, l))
+		c.rect(x, y-swatch/2, swatch, swatch)
+		c.Fill()
+		c.SetHexColor(theme.Text)
+		c.DrawStringAnchored(l.title, x+swatch+margin/2, y, 0, 0.35)
+		x += swatch + margin/2 + measure(c, l.title) + 1.5*margin
+	}
+	c.Fill()
+
+	return c.drawing()
+}
+
+func timelineColor(theme *Theme, s span) string {
+	switch {
+	case s.abandoned:
+		return theme.CompareBar
+	case s.playing:
+		return withAlpha(theme.Bar, 0.5)
+	default:
+		return theme.Bar
+	}
+}
@@ -0,0 +1,169 @@
+package imagegen
+
+import (
+	"math"
+	"slices"
+
+	"github.com/fogleman/gg"
+	"golang.org/x/image/font"
+)
+
+// vector is what the formats that write shapes and text, instead of
+// drawing pixels, have in common. Text is measured with the same faces as
+// the raster charts, so the layout matches.
+type vector struct {
+	width, height int
+	// measure is only used for the text metrics
+	measure *gg.Context
+	face    namedFace
+	// fonts are the font files used, in order
+	fonts []string
+	// rects and paths are waiting for Fill or Stroke to know their color
+	rects []rectangle
+	paths []subpath
+	// current is false when the next point starts a new subpath
+	current   bool
+	lineWidth float64
+}
+
+type rectangle struct {
+	x, y, w, h, r float64
+}
+
+type point struct {
+	x, y float64
+}
+
+type subpath struct {
+	points []point
+	closed bool
+}
+
+func newVector(width, height int) vector {
+	return vector{width: width, height: height, measure: gg.NewContext(1, 1), lineWidth: 1}
+}
+
+func (v *vector) Width() int {
+	return v.width
+}
+
+func (v *vector) Height() int {
+	return v.height
+}
+
+func (v *vector) SetFontFace(fontFace font.Face) {
+	v.measure.SetFontFace(fontFace)
+	v.face, _ = fontFace.(namedFace)
+	v.useFont(v.face.path)
+}
+
+func (v *vector) useFont(path string) {
+	if path != "" && !slices.Contains(v.fonts, path) {
+		v.fonts = append(v.fonts, path)
+	}
+}
+
+func (v *vector) FontHeight() float64 {
+	return v.measure.FontHeight()
+}
+
+func (v *vector) MeasureString(s string) (w, h float64) {
+	return v.measure.MeasureString(s)
+}
+
+func (v *vector) WordWrap(s string, w float64) []string {
+	return v.measure.WordWrap(s, w)
+}
+
+func (v *vector) DrawRectangle(x, y, w, h float64) {
+	v.rects = append(v.rects, rectangle{x, y, w, h, 0})
+}
+
+func (v *vector) DrawRoundedRectangle(x, y, w, h, r float64) {
+	v.rects = append(v.rects, rectangle{x, y, w, h, r})
+}
+
+func (v *vector) MoveTo(x, y float64) {
+	v.paths = append(v.paths, subpath{points: []point{{x, y}}})
+	v.current = true
+}
+
+func (v *vector) LineTo(x, y float64) {
+	if !v.current {
+		v.MoveTo(x, y)
+		return
+	}
+	last := &v.paths[len(v.paths)-1]
+	if last.closed {
+		// after closing, gg goes on from the start of the subpath
+		v.MoveTo(last.points[0].x, last.points[0].y)
+		last = &v.paths[len(v.paths)-1]
+	}
+	last.points = append(last.points, point{x, y})
+}
+
+// DrawArc adds the arc as short segments, a line to its start is added
+// when there is a current point, as gg does.
+func (v *vector) DrawArc(x, y, r, angle1, angle2 float64) {
+	n := max(8, int(math.Ceil(math.Abs(angle2-angle1)/(math.Pi/90))))
+	for i := 0; i <= n; i++ {
+		a := angle1 + (angle2-angle1)*float64(i)/float64(n)
+		px, py := x+r*math.Cos(a), y+r*math.Sin(a)
+		if i == 0 && !v.current {
+			v.MoveTo(px, py)
+			continue
+		}
+		v.LineTo(px, py)
+	}
+}
+
+func (v *vector) DrawCircle(x, y, r float64) {
+	v.NewSubPath()
+	v.DrawArc(x, y, r, 0, 2*math.Pi)
+	v.ClosePath()
+}
+
+func (v *vector) ClosePath() {
+	if v.current {
+		v.paths[len(v.paths)-1].closed = true
+	}
+}
+
+func (v *vector) NewSubPath() {
+	v.current = false
+}
+
+func (v *vector) SetLineWidth(lineWidth float64) {
+	v.lineWidth = lineWidth
+}
+
+// clearPath drops the shapes once they are drawn.
+func (v *vector) clearPath() {
+	v.rects = v.rects[:0]
+	v.paths = v.paths[:0]
+	v.current = false
+}
+
+// drawStringWrapped lays out the lines the same way gg does, for the
+// surfaces that only know how to draw a single line.
+func drawStringWrapped(s surface, str string, x, y, ax, ay, width, lineSpacing float64, align gg.Align) {
+	lines := s.WordWrap(str, width)
+	fontHeight := s.FontHeight()
+	h := float64(len(lines))*fontHeight*lineSpacing - (lineSpacing-1)*fontHeight
+	x -= ax * width
+	y -= ay * h
+	switch align {
+	case gg.AlignLeft:
+		ax = 0
+	case gg.AlignCenter:
+		ax = 0.5
+		x += width / 2
+	case gg.AlignRight:
+		ax = 1
+		x += width
+	}
+	for _, line := range lines {
+		s.DrawStringAnchored(line, x, y, ax, 1)
+		y += fontHeight * lineSpacing
+	}
+}
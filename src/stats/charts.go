@@ -0,0 +1,57 @@
+package stats
+
+import (
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/i18n"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/imagegen"
+)
+
+// Chart is a bar chart of the wrapped, with the items to draw and how many
+// of them fit.
+type Chart struct {
+	Title string
+	Items []imagegen.BarChartItem
+	Limit int
+}
+
+// Charts lists the bar charts of the wrapped of the range, in the order
+// they are shared, followed by the user defined stats, whose titles are
+// kept as they are written.
+func (r *Report) Charts(rg Range, l *i18n.Locale, defs ...Definition) []Chart {
+	year := rg.String()
+	charts := []Chart{
+		all(l.T("Most played consoles in %s", year), r.MostPlayedConsoles),
+		top(l.T("Most played platform in %s", year), r.MostPlayedPlatform, 9),
+		top(l.T("Most played games in %s", year), r.MostPlayedGames, 8),
+		top(l.T("Most played game serie in %s", year), r.MostPlayedSeries, 8),
+		all(l.T("Games beaten in %s", year), r.GamesByStatus),
+		all(l.T("Busiest months in %s", year), r.BusiestMonths),
+		all(l.T("Completion rate until %s", year), r.CompletionRate),
+		all(l.T("Days to finish in %s", year), r.TimeToBeat),
+		top(l.T("Abandoned games by platform in %s", year), r.AbandonmentRate, 9),
+		all(l.T("Backlog size in %s", year), r.Backlog),
+		all(l.T("Backlog burn-down in %s", year), r.BurnDown),
+		top(l.T("Best rated platforms in %s", year), r.AverageRatingByPlatform, 9),
+		top(l.T("Best rated game series in %s", year), r.AverageRatingBySeries, 8),
+		all(l.T("Average rating until %s", year), r.AverageRatingByYear),
+		top(l.T("Best rated games in %s", year), r.BestRatedGames, 8),
+		top(l.T("Hidden gems of %s", year), r.HiddenGems, 8),
+		all(l.T("Playtime by rating in %s", year), r.RatingVsPlaytime.ByRating),
+	}
+	for _, def := range defs {
+		rows := r.Custom[def.ID]
+		charts = append(charts, Chart{Title: def.RenderTitle(rg), Items: def.ChartItems(rows), Limit: def.ChartLimit(len(rows))})
+	}
+	return charts
+}
+
+func top[T imagegen.BarChartItem](title string, rows []T, n int) Chart {
+	items := make([]imagegen.BarChartItem, len(rows))
+	for i, row := range rows {
+		items[i] = row
+	}
+	return Chart{Title: title, Items: items, Limit: n}
+}
+
+func all[T imagegen.BarChartItem](title string, rows []T) Chart {
+	return top(title, rows, len(rows))
+}
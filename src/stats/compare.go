@@ -0,0 +1,212 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/imagegen"
+	"golang.org/x/sync/errgroup"
+)
+
+// Comparison holds each stat computed for two periods, with the deltas
+// between them. The completion rate, the average rating by year and the
+// burn-down are left out, as they already span every year up to the
+// period, and so are the ratings, which are averages rather than totals.
+type Comparison struct {
+	From               int                       `json:"from"`
+	To                 int                       `json:"to"`
+	MostPlayedConsoles []imagegen.ComparisonItem `json:"most_played_consoles"`
+	MostPlayedPlatform []imagegen.ComparisonItem `json:"most_played_platforms"`
+	MostPlayedGames    []imagegen.ComparisonItem `json:"most_played_games"`
+	MostPlayedSeries   []imagegen.ComparisonItem `json:"most_played_series"`
+	GamesByStatus      []imagegen.ComparisonItem `json:"games_by_status"`
+	BusiestMonths      []imagegen.ComparisonItem `json:"busiest_months"`
+	TimeToBeat         []imagegen.ComparisonItem `json:"time_to_beat"`
+	AbandonmentRate    []imagegen.ComparisonItem `json:"abandonment_rate"`
+	Backlog            []imagegen.ComparisonItem `json:"backlog"`
+
+	Custom map[string][]imagegen.ComparisonItem `json:"custom,omitempty"`
+}
+
+// Compare queries the compared stats for both periods, including the given
+// user defined stats, and computes the deltas.
+func Compare(ctx context.Context, repo Repository, from, to Range, defs ...Definition) (*Comparison, error) {
+	var fromReport, toReport *Report
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		fromReport, err = collectCompared(ctx, repo, from, defs)
+		return err
+	})
+	g.Go(func() (err error) {
+		toReport, err = collectCompared(ctx, repo, to, defs)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	playtimeKey := func(mp imagegen.MostPlayedByPlaytime) string { return mp.Title }
+	gameKey := func(mpg imagegen.MostPlayedGame) string {
+		return fmt.Sprintf("%s (%s) on %s", mpg.Title, mpg.Platform, mpg.Console)
+	}
+	statusKey := func(mp imagegen.MostPlayedByNumGames) string { return mp.Title }
+	durationKey := func(ad imagegen.AverageDuration) string { return ad.Title }
+	shareKey := func(s imagegen.ShareOfGames) string { return s.Title }
+	backlogKey := func(bm imagegen.BacklogMonth) string { return bm.Month }
+
+	comparison := &Comparison{
+		From:               from.Year,
+		To:                 to.Year,
+		MostPlayedConsoles: CompareItems(fromReport.MostPlayedConsoles, toReport.MostPlayedConsoles, playtimeKey, "h"),
+		MostPlayedPlatform: CompareItems(fromReport.MostPlayedPlatform, toReport.MostPlayedPlatform, playtimeKey, "h"),
+		MostPlayedGames:    CompareItems(fromReport.MostPlayedGames, toReport.MostPlayedGames, gameKey, "h"),
+		MostPlayedSeries:   CompareItems(fromReport.MostPlayedSeries, toReport.MostPlayedSeries, playtimeKey, "h"),
+		GamesByStatus:      CompareItems(fromReport.GamesByStatus, toReport.GamesByStatus, statusKey, ""),
+		BusiestMonths:      CompareItems(fromReport.BusiestMonths, toReport.BusiestMonths, playtimeKey, "h"),
+		TimeToBeat:         CompareItems(fromReport.TimeToBeat, toReport.TimeToBeat, durationKey, "d"),
+		AbandonmentRate:    CompareItems(fromReport.AbandonmentRate, toReport.AbandonmentRate, shareKey, "%"),
+		Backlog:            CompareItems(fromReport.Backlog, toReport.Backlog, backlogKey, ""),
+	}
+
+	if len(defs) > 0 {
+		comparison.Custom = map[string][]imagegen.ComparisonItem{}
+	}
+	for _, def := range defs {
+		unit := "h"
+		if def.Metric == MetricCount {
+			unit = ""
+		}
+		fromItems := def.ChartItems(fromReport.Custom[def.ID])
+		toItems := def.ChartItems(toReport.Custom[def.ID])
+		comparison.Custom[def.ID] = CompareItems(fromItems, toItems, customKey, unit)
+	}
+	return comparison, nil
+}
+
+// customKey matches the rows of a user defined stat by their title.
+func customKey(item imagegen.BarChartItem) string {
+	switch item := item.(type) {
+	case imagegen.MostPlayedByPlaytime:
+		return item.Title
+	case imagegen.MostPlayedByNumGames:
+		return item.Title
+	}
+	return ""
+}
+
+// collectCompared runs only the queries of the stats that are compared,
+// concurrently, and returns them as a partial report.
+func collectCompared(ctx context.Context, repo Repository, r Range, defs []Definition) (*Report, error) {
+	r, err := ResolvePlayer(ctx, repo, r)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Year: r.Year, Player: r.Player, Custom: map[string][]imagegen.MostPlayedByPlaytime{}}
+	custom := make([][]imagegen.MostPlayedByPlaytime, len(defs))
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		report.MostPlayedConsoles, err = repo.MostPlayedConsoles(ctx, r)
+		return err
+	})
+	g.Go(func() (err error) {
+		report.MostPlayedPlatform, err = repo.MostPlayedPlatforms(ctx, r)
+		return err
+	})
+	g.Go(func() (err error) {
+		report.MostPlayedGames, err = repo.MostPlayedGames(ctx, r)
+		return err
+	})
+	g.Go(func() (err error) {
+		report.MostPlayedSeries, err = repo.MostPlayedSeries(ctx, r)
+		return err
+	})
+	g.Go(func() (err error) {
+		report.GamesByStatus, err = repo.GamesByStatus(ctx, r)
+		return err
+	})
+	g.Go(func() (err error) {
+		report.BusiestMonths, err = repo.BusiestMonths(ctx, r)
+		return err
+	})
+	g.Go(func() (err error) {
+		report.TimeToBeat, err = repo.TimeToBeat(ctx, r)
+		return err
+	})
+	g.Go(func() (err error) {
+		report.AbandonmentRate, err = repo.AbandonmentRate(ctx, r)
+		return err
+	})
+	g.Go(func() (err error) {
+		report.Backlog, err = repo.Backlog(ctx, r)
+		return err
+	})
+	for i, def := range defs {
+		g.Go(func() (err error) {
+			custom[i], err = repo.Custom(ctx, def, r)
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	for i, def := range defs {
+		report.Custom[def.ID] = custom[i]
+	}
+	return report, nil
+}
+
+// CompareItems matches the entries of both periods by key, summing
+// repeated entries, and returns them ordered by the most recent period,
+// with the entries that dropped out at the end.
+func CompareItems[T imagegen.BarChartItem](from, to []T, key func(T) string, unit string) []imagegen.ComparisonItem {
+	type entry struct {
+		item   imagegen.BarChartItem
+		metric int
+		rank   int
+	}
+	index := func(items []T) (map[string]*entry, []string) {
+		entries := map[string]*entry{}
+		keys := []string{}
+		for _, item := range items {
+			k := key(item)
+			if e, ok := entries[k]; ok {
+				e.metric += item.GetMetric()
+				continue
+			}
+			entries[k] = &entry{item: item, metric: item.GetMetric()}
+			keys = append(keys, k)
+		}
+		sort.SliceStable(keys, func(i, j int) bool {
+			return entries[keys[i]].metric > entries[keys[j]].metric
+		})
+		for i, k := range keys {
+			entries[k].rank = i + 1
+		}
+		return entries, keys
+	}
+
+	fromEntries, fromKeys := index(from)
+	toEntries, toKeys := index(to)
+
+	items := []imagegen.ComparisonItem{}
+	for _, k := range toKeys {
+		t := toEntries[k]
+		fromMetric, fromRank := 0, 0
+		if f, ok := fromEntries[k]; ok {
+			fromMetric, fromRank = f.metric, f.rank
+		}
+		items = append(items, imagegen.NewComparisonItem(k, unit, t.item, fromMetric, t.metric, fromRank, t.rank))
+	}
+	for _, k := range fromKeys {
+		if _, ok := toEntries[k]; ok {
+			continue
+		}
+		f := fromEntries[k]
+		items = append(items, imagegen.NewComparisonItem(k, unit, f.item, f.metric, 0, f.rank, 0))
+	}
+	return items
+}
@@ -0,0 +1,97 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/i18n"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/imagegen"
+)
+
+func TestCompareItems(t *testing.T) {
+	playtime := func(title string, hours float64) imagegen.MostPlayedByPlaytime {
+		return imagegen.MostPlayedByPlaytime{Title: title, Playtime: hours}
+	}
+	key := func(mp imagegen.MostPlayedByPlaytime) string { return mp.Title }
+
+	tests := []struct {
+		name     string
+		from, to []imagegen.MostPlayedByPlaytime
+		want     []imagegen.ComparisonItem
+	}{
+		{
+			name: "empty",
+			want: []imagegen.ComparisonItem{},
+		},
+		{
+			name: "new, dropped and repeated entries",
+			from: []imagegen.MostPlayedByPlaytime{playtime("A", 10), playtime("B", 5), playtime("A", 2), playtime("D", 1)},
+			to:   []imagegen.MostPlayedByPlaytime{playtime("C", 3), playtime("B", 20)},
+			want: []imagegen.ComparisonItem{
+				{Title: "B", From: 5, To: 20, FromRank: 2, ToRank: 1, Change: 15, ChangePercent: 300, RankChange: 1, Unit: "h"},
+				{Title: "C", From: 0, To: 3, FromRank: 0, ToRank: 2, Change: 3, New: true, Unit: "h"},
+				{Title: "A", From: 12, To: 0, FromRank: 1, ToRank: 0, Change: -12, ChangePercent: -100, Dropped: true, Unit: "h"},
+				{Title: "D", From: 1, To: 0, FromRank: 3, ToRank: 0, Change: -1, ChangePercent: -100, Dropped: true, Unit: "h"},
+			},
+		},
+		{
+			name: "same ranks",
+			from: []imagegen.MostPlayedByPlaytime{playtime("A", 3), playtime("B", 2)},
+			to:   []imagegen.MostPlayedByPlaytime{playtime("A", 4), playtime("B", 1)},
+			want: []imagegen.ComparisonItem{
+				{Title: "A", From: 3, To: 4, FromRank: 1, ToRank: 1, Change: 1, ChangePercent: 33.3, Unit: "h"},
+				{Title: "B", From: 2, To: 1, FromRank: 2, ToRank: 2, Change: -1, ChangePercent: -50, Unit: "h"},
+			},
+		},
+		{
+			name: "ties keep their order",
+			to:   []imagegen.MostPlayedByPlaytime{playtime("A", 1), playtime("B", 1)},
+			want: []imagegen.ComparisonItem{
+				{Title: "A", To: 1, ToRank: 1, Change: 1, New: true, Unit: "h"},
+				{Title: "B", To: 1, ToRank: 2, Change: 1, New: true, Unit: "h"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CompareItems(tt.from, tt.to, key, "h")
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d items, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if got[i].Item == nil {
+					t.Errorf("%s has no item", got[i].Title)
+				}
+				got[i].Item = nil
+				if got[i] != tt.want[i] {
+					t.Errorf("item %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRenderComparisonTitle(t *testing.T) {
+	tests := []struct {
+		title string
+		from  Range
+		to    Range
+		want  string
+	}{
+		{"Most played on weekends in {{.Year}}", Year(2023), Year(2024), "Most played on weekends in 2023 vs 2024"},
+		{"", Year(2023), Year(2024), "weekends in 2023 vs 2024"},
+		{"Weekend favorites", Year(2023), Year(2024), "Weekend favorites 2023 vs 2024"},
+		{"{{.Player}} on weekends in {{.Year}}", Range{Year: 2022, Player: "Ana"}, Range{Year: 2024, Player: "Ana"}, "Ana on weekends in 2022 vs 2024"},
+		{"{{.Year}} on weekends, {{.Missing}}", Year(2023), Year(2024), "{{.Year}} on weekends, {{.Missing}} 2023 vs 2024"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			def := Definition{ID: "weekends", Title: tt.title, Query: "select 1"}
+			if err := def.validate(); err != nil {
+				t.Fatal(err)
+			}
+			if got := def.RenderComparisonTitle(i18n.English, tt.from, tt.to); got != tt.want {
+				t.Errorf("title = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
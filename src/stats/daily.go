@@ -0,0 +1,58 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/i18n"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/imagegen"
+)
+
+// DailyPlaytime spreads the playtime of each playthrough evenly over the
+// days it was played, from its start to its end date, or until today when
+// it is not finished. Only the days in the year are added, so playthroughs
+// that went on from or into another year count just the share of their
+// days in this one.
+func DailyPlaytime(year int, games []imagegen.Playthrough) []float64 {
+	first := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	days := make([]float64, int(first.AddDate(1, 0, 0).Sub(first).Hours()/24))
+	last := len(days) - 1
+	for _, g := range games {
+		if g.StartDate == nil || g.Playtime <= 0 {
+			continue
+		}
+		end := time.Now()
+		if g.EndDate != nil {
+			end = *g.EndDate
+		}
+		start, stop := dayOfYear(first, *g.StartDate), dayOfYear(first, end)
+		stop = max(stop, start)
+		if start > last || stop < 0 {
+			continue
+		}
+		perDay := g.Playtime / float64(stop-start+1)
+		for day := max(start, 0); day <= min(stop, last); day++ {
+			days[day] += perDay
+		}
+	}
+	return days
+}
+
+func dayOfYear(first, t time.Time) int {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return int(day.Sub(first).Hours() / 24)
+}
+
+// CumulativePlaytime adds up the daily playtime, with the months as labels.
+func CumulativePlaytime(year int, daily []float64, l *i18n.Locale) []imagegen.LinePoint {
+	first := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := make([]imagegen.LinePoint, len(daily))
+	total := 0.0
+	for i, hours := range daily {
+		total += hours
+		points[i].Value = total
+		if day := first.AddDate(0, 0, i); day.Day() == 1 {
+			points[i].Label = l.ShortMonth(day.Month())
+		}
+	}
+	return points
+}
@@ -0,0 +1,99 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/imagegen"
+)
+
+func date(year int, month time.Month, day int) *time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func TestDailyPlaytime(t *testing.T) {
+	tests := []struct {
+		name   string
+		game   imagegen.Playthrough
+		total  float64
+		first  int
+		perDay float64
+	}{
+		{
+			name:   "within the year",
+			game:   imagegen.Playthrough{Playtime: 10, StartDate: date(2024, 1, 1), EndDate: date(2024, 1, 10)},
+			total:  10,
+			first:  0,
+			perDay: 1,
+		},
+		{
+			name:   "into the next year",
+			game:   imagegen.Playthrough{Playtime: 20, StartDate: date(2024, 12, 22), EndDate: date(2025, 1, 10)},
+			total:  10,
+			first:  356,
+			perDay: 1,
+		},
+		{
+			name:   "from the previous year",
+			game:   imagegen.Playthrough{Playtime: 10, StartDate: date(2023, 12, 27), EndDate: date(2024, 1, 5)},
+			total:  5,
+			first:  0,
+			perDay: 1,
+		},
+		{
+			name:   "over the whole year",
+			game:   imagegen.Playthrough{Playtime: 736, StartDate: date(2023, 12, 31), EndDate: date(2025, 1, 1)},
+			total:  366 * 2,
+			first:  0,
+			perDay: 2,
+		},
+		{
+			name:   "ended before it started",
+			game:   imagegen.Playthrough{Playtime: 3, StartDate: date(2024, 3, 1), EndDate: date(2024, 2, 1)},
+			total:  3,
+			first:  60,
+			perDay: 3,
+		},
+		{
+			name:  "another year",
+			game:  imagegen.Playthrough{Playtime: 10, StartDate: date(2023, 1, 1), EndDate: date(2023, 1, 10)},
+			total: 0,
+			first: -1,
+		},
+		{
+			name:  "not started",
+			game:  imagegen.Playthrough{Playtime: 10, EndDate: date(2024, 1, 10)},
+			total: 0,
+			first: -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days := DailyPlaytime(2024, []imagegen.Playthrough{tt.game})
+			if len(days) != 366 {
+				t.Fatalf("got %d days, want 366", len(days))
+			}
+			total, first := 0.0, -1
+			for i, hours := range days {
+				if hours == 0 {
+					continue
+				}
+				if first < 0 {
+					first = i
+				}
+				if math.Abs(hours-tt.perDay) > 1e-9 {
+					t.Errorf("day %d has %vh, want %vh", i, hours, tt.perDay)
+				}
+				total += hours
+			}
+			if math.Abs(total-tt.total) > 1e-9 {
+				t.Errorf("total = %vh, want %vh", total, tt.total)
+			}
+			if first != tt.first {
+				t.Errorf("first day = %d, want %d", first, tt.first)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package stats
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/i18n"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/imagegen"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	MetricPlaytime = "playtime"
+	MetricCount    = "count"
+
+	IconNone   = "none"
+	IconLogo   = "icon"
+	IconBoxArt = "boxart"
+
+	ChartBar = "bar"
+)
+
+// Definition is a user defined stat, loaded from a YAML file so new stats
+// can be added without recompiling. The query must return `title`,
+// `playtime` (in hours) and `count` columns, and every `?` placeholder is
+// bound to the year being rendered.
+type Definition struct {
+	ID     string `yaml:"id" json:"id"`
+	Title  string `yaml:"title" json:"title"`
+	Metric string `yaml:"metric" json:"metric"`
+	Limit  int    `yaml:"limit" json:"limit"`
+	Icon   string `yaml:"icon" json:"icon"`
+	Chart  string `yaml:"chart" json:"chart"`
+	Query  string `yaml:"query" json:"-"`
+
+	title *template.Template
+}
+
+// LoadDefinitions reads all the *.yaml and *.yml stat definitions from dir.
+// A missing directory is not an error, it just means there are no user
+// defined stats.
+func LoadDefinitions(dir string) ([]Definition, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stats folder: %v", err)
+	}
+
+	defs := []Definition{}
+	seen := map[string]string{}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		def, err := LoadDefinition(path)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := seen[def.ID]; ok {
+			return nil, fmt.Errorf("stat %q defined in both %s and %s", def.ID, other, path)
+		}
+		seen[def.ID] = path
+		defs = append(defs, *def)
+	}
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].ID < defs[j].ID
+	})
+	return defs, nil
+}
+
+func LoadDefinition(path string) (*Definition, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stat definition %s: %v", path, err)
+	}
+	def := &Definition{}
+	if err := yaml.Unmarshal(content, def); err != nil {
+		return nil, fmt.Errorf("failed to parse stat definition %s: %v", path, err)
+	}
+	if def.ID == "" {
+		def.ID = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := def.validate(); err != nil {
+		return nil, fmt.Errorf("invalid stat definition %s: %v", path, err)
+	}
+	return def, nil
+}
+
+func (d *Definition) validate() error {
+	if strings.TrimSpace(d.Query) == "" {
+		return fmt.Errorf("query is required")
+	}
+	if d.Title == "" {
+		d.Title = d.ID + " in {{.Year}}"
+	}
+	if d.Metric == "" {
+		d.Metric = MetricPlaytime
+	}
+	if d.Metric != MetricPlaytime && d.Metric != MetricCount {
+		return fmt.Errorf("unknown metric %q", d.Metric)
+	}
+	if d.Icon == "" {
+		d.Icon = IconNone
+	}
+	if d.Icon != IconNone && d.Icon != IconLogo && d.Icon != IconBoxArt {
+		return fmt.Errorf("unknown icon source %q", d.Icon)
+	}
+	if d.Chart == "" {
+		d.Chart = ChartBar
+	}
+	if d.Chart != ChartBar {
+		return fmt.Errorf("unknown chart type %q", d.Chart)
+	}
+	title, err := template.New(d.ID).Parse(d.Title)
+	if err != nil {
+		return fmt.Errorf("failed to parse title template: %v", err)
+	}
+	d.title = title
+	return nil
+}
+
+// RenderTitle executes the title template, which has access to the Range
+// being rendered, e.g. "Most played on weekends in {{.Year}}".
+func (d Definition) RenderTitle(r Range) string {
+	if d.title == nil {
+		return d.Title
+	}
+	buf := bytes.Buffer{}
+	if err := d.title.Execute(&buf, r); err != nil {
+		return d.Title
+	}
+	return buf.String()
+}
+
+// RenderComparisonTitle titles the comparison of the stat between two
+// ranges. The years of the title template are replaced by both, like "Most
+// played on weekends in 2023 vs 2024", and titles without them are
+// followed by the years.
+func (d Definition) RenderComparisonTitle(l *i18n.Locale, from, to Range) string {
+	if d.title == nil || !strings.Contains(d.Title, ".Year") {
+		return l.T("%s %s vs %s", d.RenderTitle(to), from, to)
+	}
+	// the template only sees the fields of a Range, with both years
+	r := struct {
+		Year   string
+		Player string
+	}{l.T("%s vs %s", from, to), to.Player}
+	buf := bytes.Buffer{}
+	if err := d.title.Execute(&buf, r); err != nil {
+		return l.T("%s %s vs %s", d.Title, from, to)
+	}
+	return buf.String()
+}
+
+// ChartItems converts the rows of the stat into chart items, according to
+// the metric and icon source of the definition.
+func (d Definition) ChartItems(rows []imagegen.MostPlayedByPlaytime) []imagegen.BarChartItem {
+	items := make([]imagegen.BarChartItem, len(rows))
+	for i, row := range rows {
+		if d.Metric == MetricCount {
+			items[i] = imagegen.MostPlayedByNumGames{Title: row.Title, Playtime: row.Playtime, Count: row.Count}
+			continue
+		}
+		row.NoIcon = d.Icon == IconNone
+		row.BoxArt = d.Icon == IconBoxArt
+		items[i] = row
+	}
+	return items
+}
+
+// ChartLimit is the amount of bars to render, defaulting to all rows.
+func (d Definition) ChartLimit(rows int) int {
+	if d.Limit <= 0 || d.Limit > rows {
+		return rows
+	}
+	return d.Limit
+}
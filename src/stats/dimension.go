@@ -0,0 +1,166 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/airtablesql"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/imagegen"
+)
+
+// Dimension is a link field on games or playthroughs that playtime can be
+// grouped by, like consoles, platforms, genres or developers.
+type Dimension struct {
+	ID          string `json:"id"`
+	Table       string `json:"table"`
+	Column      string `json:"column"`
+	LinkedTable string `json:"linked_table"`
+	LabelColumn string `json:"label_column"`
+}
+
+var (
+	DimensionConsoles  = Dimension{ID: "consoles", Table: "playthroughs", Column: "console", LinkedTable: "consoles", LabelColumn: "name"}
+	DimensionPlatforms = Dimension{ID: "platforms", Table: "games", Column: "platforms", LinkedTable: "platforms", LabelColumn: "name"}
+	DimensionSeries    = Dimension{ID: "series", Table: "games", Column: "serie", LinkedTable: "serie", LabelColumn: "name"}
+
+	// builtinDimensions keep their IDs when they are discovered from the
+	// schema, where the series are linked from the serie table.
+	builtinDimensions = []Dimension{DimensionConsoles, DimensionPlatforms, DimensionSeries}
+)
+
+// playtimeByDimensionQuery builds the query that sums the playtime of the
+// playthroughs of a year grouped by the linked records of the dimension.
+func playtimeByDimensionQuery(d Dimension) (string, error) {
+	if d.Table != "playthroughs" && d.Table != "games" {
+		return "", fmt.Errorf("dimension %q must be a link on games or playthroughs, got %q", d.ID, d.Table)
+	}
+	for _, identifier := range []string{d.Column, d.LinkedTable, d.LabelColumn} {
+		if !validIdentifier(identifier) {
+			return "", fmt.Errorf("invalid identifier %q for dimension %q", identifier, d.ID)
+		}
+	}
+
+	link := "p." + quoteIdentifier(d.Column)
+	join := ""
+	if d.Table == "games" {
+		link = "g." + quoteIdentifier(d.Column)
+		join = `inner join games g on JSON_CONTAINS(p.games, CONCAT('"', g.record_id, '"'))`
+	}
+	label := "d." + quoteIdentifier(d.LabelColumn)
+
+	return fmt.Sprintf(`
+		select %s as title, ROUND(sum(p.playtime)/(60*60), 0) as playtime, count(*) as count
+		from playthroughs p
+			%s
+			inner join %s d on JSON_CONTAINS(%s, CONCAT('"', d.record_id, '"'))
+		where p.year_start_date = ?
+		group by %s
+		order by playtime desc;`, label, join, quoteIdentifier(d.LinkedTable), link, label), nil
+}
+
+func validIdentifier(name string) bool {
+	return name != "" && !strings.ContainsAny(name, "`\"'")
+}
+
+func quoteIdentifier(name string) string {
+	return "`" + name + "`"
+}
+
+type schemaColumn struct {
+	Table   string `db:"table_name"`
+	Column  string `db:"column_name"`
+	Comment string `db:"column_comment"`
+	Key     string `db:"column_key"`
+}
+
+func (r *repository) Dimensions(ctx context.Context) ([]Dimension, error) {
+	columns := []schemaColumn{}
+	if err := r.db.SelectContext(ctx, &columns, querySchemaColumns); err != nil {
+		return nil, fmt.Errorf("failed to query schema: %v", err)
+	}
+	return schemaDimensions(columns), nil
+}
+
+// schemaDimensions finds the link fields of games and playthroughs in the
+// columns of the schema.
+func schemaDimensions(columns []schemaColumn) []Dimension {
+	labels := map[string]string{}
+	for _, c := range columns {
+		if c.Key == "PRI" && c.Column != "record_id" {
+			labels[c.Table] = c.Column
+		}
+	}
+
+	dimensions := []Dimension{}
+	seen := map[string]bool{}
+	for _, c := range columns {
+		if c.Table != "playthroughs" && c.Table != "games" {
+			continue
+		}
+		_, linkedTable, ok := strings.Cut(c.Comment, airtablesql.LinkedTableComment)
+		if !ok {
+			continue
+		}
+		linkedTable, _, _ = strings.Cut(linkedTable, ";")
+		linkedTable = strings.TrimSpace(linkedTable)
+		// games and playthroughs are the base tables, not dimensions
+		if linkedTable == "games" || linkedTable == "playthroughs" {
+			continue
+		}
+		label, ok := labels[linkedTable]
+		if !ok {
+			label = "name"
+		}
+		d := Dimension{
+			ID:          linkedTable,
+			Table:       c.Table,
+			Column:      c.Column,
+			LinkedTable: linkedTable,
+			LabelColumn: label,
+		}
+		for _, builtin := range builtinDimensions {
+			if builtin.Table == d.Table && builtin.Column == d.Column && builtin.LinkedTable == d.LinkedTable {
+				d.ID = builtin.ID
+			}
+		}
+		if seen[d.ID] {
+			d.ID = c.Table + "_" + c.Column
+		}
+		seen[d.ID] = true
+		dimensions = append(dimensions, d)
+	}
+	sort.Slice(dimensions, func(i, j int) bool {
+		return dimensions[i].ID < dimensions[j].ID
+	})
+	return dimensions
+}
+
+func (r *repository) PlaytimeBy(ctx context.Context, d Dimension, rg Range) ([]imagegen.MostPlayedByPlaytime, error) {
+	query, err := playtimeByDimensionQuery(d)
+	if err != nil {
+		return nil, err
+	}
+	rows := []imagegen.MostPlayedByPlaytime{}
+	if err := r.selectInRange(ctx, &rows, query, rg, rg.String()); err != nil {
+		return nil, fmt.Errorf("failed to query playtime by %s: %v", d.ID, err)
+	}
+	return rows, nil
+}
+
+// FindDimension looks up a dimension by its ID, or by the column name of
+// the link field.
+func FindDimension(dimensions []Dimension, id string) (Dimension, bool) {
+	for _, d := range dimensions {
+		if d.ID == id {
+			return d, true
+		}
+	}
+	for _, d := range dimensions {
+		if d.Column == id {
+			return d, true
+		}
+	}
+	return Dimension{}, false
+}
@@ -0,0 +1,51 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchemaDimensions(t *testing.T) {
+	link := func(table, column, linkedTable string) schemaColumn {
+		return schemaColumn{Table: table, Column: column, Comment: "linked table: " + linkedTable}
+	}
+	columns := []schemaColumn{
+		link("playthroughs", "console", "consoles"),
+		link("playthroughs", "games", "games"),
+		link("games", "platforms", "platforms"),
+		link("games", "serie", "serie"),
+		link("games", "developers", "companies"),
+		link("games", "publishers", "companies"),
+		link("consoles", "games", "games"),
+		{Table: "games", Column: "name"},
+		{Table: "companies", Column: "title", Key: "PRI"},
+	}
+	dimensions := schemaDimensions(columns)
+
+	ids := []string{}
+	for _, d := range dimensions {
+		ids = append(ids, d.ID)
+	}
+	want := []string{"companies", "consoles", "games_publishers", "platforms", "series"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+
+	tests := []struct {
+		id   string
+		want Dimension
+	}{
+		{"consoles", DimensionConsoles},
+		{"platforms", DimensionPlatforms},
+		{"series", DimensionSeries},
+		{"serie", DimensionSeries},
+		{"companies", Dimension{ID: "companies", Table: "games", Column: "developers", LinkedTable: "companies", LabelColumn: "title"}},
+		{"publishers", Dimension{ID: "games_publishers", Table: "games", Column: "publishers", LinkedTable: "companies", LabelColumn: "title"}},
+	}
+	for _, tt := range tests {
+		got, ok := FindDimension(dimensions, tt.id)
+		if !ok || got != tt.want {
+			t.Errorf("FindDimension(%q) = %+v, %v, want %+v", tt.id, got, ok, tt.want)
+		}
+	}
+}
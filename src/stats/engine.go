@@ -0,0 +1,210 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	sqle "github.com/dolthub/go-mysql-server"
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/vitess/go/sqltypes"
+	querypb "github.com/dolthub/vitess/go/vt/proto/query"
+)
+
+// engineSelecter runs queries directly on a go-mysql-server engine,
+// scanning rows into structs using the same `db` tags as sqlx.
+type engineSelecter struct {
+	engine   *sqle.Engine
+	database string
+	pid      atomic.Uint64
+}
+
+// NewEngineRepository returns a Repository that queries the given database
+// on an in-process go-mysql-server engine, without going through the
+// MySQL wire protocol.
+func NewEngineRepository(engine *sqle.Engine, database string) Repository {
+	return &repository{db: &engineSelecter{engine: engine, database: database}}
+}
+
+func (e *engineSelecter) SelectContext(ctx context.Context, dest any, query string, args ...any) error {
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Pointer || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("expected pointer to slice as destination, got %T", dest)
+	}
+	slice = slice.Elem()
+	elemType := slice.Type().Elem()
+	if elemType.Kind() != reflect.Struct {
+		return fmt.Errorf("expected slice of structs as destination, got %T", dest)
+	}
+
+	bindings := map[string]*querypb.BindVariable{}
+	for i, arg := range args {
+		bv, err := sqltypes.BuildBindVariable(arg)
+		if err != nil {
+			return fmt.Errorf("failed to bind argument %d: %v", i, err)
+		}
+		bindings[fmt.Sprintf("v%d", i+1)] = bv
+	}
+
+	session := sql.NewBaseSession()
+	session.SetCurrentDatabase(e.database)
+	sctx := sql.NewContext(ctx,
+		sql.WithSession(session),
+		sql.WithQuery(query),
+		sql.WithPid(e.pid.Add(1)),
+		sql.WithMemoryManager(e.engine.MemoryManager),
+		sql.WithProcessList(e.engine.ProcessList),
+	)
+
+	schema, iter, err := e.engine.QueryWithBindings(sctx, query, bindings)
+	if err != nil {
+		return err
+	}
+	rows, err := sql.RowIterToRows(sctx, schema, iter)
+	if err != nil {
+		return err
+	}
+
+	fields := make([]int, len(schema))
+	for i, col := range schema {
+		fields[i] = fieldIndexForColumn(elemType, col.Name)
+	}
+
+	for _, row := range rows {
+		elem := reflect.New(elemType).Elem()
+		for i, v := range row {
+			if fields[i] < 0 {
+				continue
+			}
+			if err := assignValue(elem.Field(fields[i]), v); err != nil {
+				return fmt.Errorf("failed to scan column %q: %v", schema[i].Name, err)
+			}
+		}
+		slice.Set(reflect.Append(slice, elem))
+	}
+	return nil
+}
+
+func fieldIndexForColumn(t reflect.Type, column string) int {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Tag.Get("db")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		if strings.EqualFold(name, column) {
+			return i
+		}
+	}
+	return -1
+}
+
+func assignValue(field reflect.Value, v any) error {
+	if v == nil {
+		return nil
+	}
+	if field.Kind() == reflect.Pointer {
+		ptr := reflect.New(field.Type().Elem())
+		if err := assignValue(ptr.Elem(), v); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		switch s := v.(type) {
+		case string:
+			field.SetString(s)
+		case []byte:
+			field.SetString(string(s))
+		default:
+			field.SetString(fmt.Sprint(v))
+		}
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat(v)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f, err := toFloat(v)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(math.Round(f)))
+	case reflect.Bool:
+		f, err := toFloat(v)
+		if err != nil {
+			return err
+		}
+		field.SetBool(f != 0)
+	default:
+		rv := reflect.ValueOf(v)
+		if !rv.Type().AssignableTo(field.Type()) {
+			return fmt.Errorf("can't assign %T to %s", v, field.Type())
+		}
+		field.Set(rv)
+	}
+	return nil
+}
+
+type floater interface {
+	Float64() (float64, bool)
+}
+
+func toFloat(v any) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case int:
+		return float64(n), nil
+	case int8:
+		return float64(n), nil
+	case int16:
+		return float64(n), nil
+	case int32:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case uint:
+		return float64(n), nil
+	case uint8:
+		return float64(n), nil
+	case uint16:
+		return float64(n), nil
+	case uint32:
+		return float64(n), nil
+	case uint64:
+		return float64(n), nil
+	case bool:
+		if n {
+			return 1, nil
+		}
+		return 0, nil
+	case floater:
+		f, _ := n.Float64()
+		return f, nil
+	case time.Time:
+		return float64(n.Unix()), nil
+	case []byte:
+		return strconv.ParseFloat(string(n), 64)
+	case string:
+		return strconv.ParseFloat(n, 64)
+	default:
+		return 0, fmt.Errorf("can't convert %T to number", v)
+	}
+}
@@ -0,0 +1,233 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/i18n"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/imagegen"
+	"golang.org/x/sync/errgroup"
+)
+
+// Player is a household member, from the table linked by the player field
+// of playthroughs.
+type Player struct {
+	ID   string `db:"id" json:"id"`
+	Name string `db:"name" json:"name"`
+}
+
+var (
+	// All queries refer to the playthroughs table as `playthroughs p`.
+	playthroughsTableRegexp = regexp.MustCompile(`(?i)\b(from|join)\s+playthroughs\s+p\b`)
+	recordIDRegexp          = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+)
+
+func playerDimension(dimensions []Dimension) (Dimension, bool) {
+	for _, d := range dimensions {
+		if d.Table == "playthroughs" && (d.Column == "player" || d.Column == "players") {
+			return d, true
+		}
+	}
+	return Dimension{}, false
+}
+
+func (r *repository) Players(ctx context.Context) ([]Player, error) {
+	dimensions, err := r.Dimensions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d, ok := playerDimension(dimensions)
+	if !ok {
+		return []Player{}, nil
+	}
+	if !validIdentifier(d.LinkedTable) || !validIdentifier(d.LabelColumn) {
+		return nil, fmt.Errorf("invalid players table %q", d.LinkedTable)
+	}
+	query := fmt.Sprintf(`
+		select pl.record_id as id, pl.%s as name
+		from %s pl
+		order by name asc;`, quoteIdentifier(d.LabelColumn), quoteIdentifier(d.LinkedTable))
+	players := []Player{}
+	if err := r.db.SelectContext(ctx, &players, query); err != nil {
+		return nil, fmt.Errorf("failed to query players: %v", err)
+	}
+	return players, nil
+}
+
+// ErrUnknownPlayer is returned for ranges of a player that is not in the
+// players table.
+var ErrUnknownPlayer = errors.New("unknown player")
+
+// ResolvePlayer looks up the player of the range, so the queries run with
+// the returned range don't look it up again. Ranges of the whole household
+// and ranges already resolved are returned as they are.
+func ResolvePlayer(ctx context.Context, repo Repository, r Range) (Range, error) {
+	if r.Player == "" || r.playthroughs != "" {
+		return r, nil
+	}
+	column, err := playerColumn(ctx, repo)
+	if err != nil {
+		return r, err
+	}
+	players, err := repo.Players(ctx)
+	if err != nil {
+		return r, err
+	}
+	player, ok := FindPlayer(players, r.Player)
+	if !ok {
+		return r, fmt.Errorf("%w %q", ErrUnknownPlayer, r.Player)
+	}
+	r.playthroughs, err = playerPlaythroughs(column, player)
+	return r, err
+}
+
+// playerColumn is the player field of playthroughs.
+func playerColumn(ctx context.Context, repo Repository) (string, error) {
+	dimensions, err := repo.Dimensions(ctx)
+	if err != nil {
+		return "", err
+	}
+	d, ok := playerDimension(dimensions)
+	if !ok {
+		return "", fmt.Errorf("playthroughs have no player field")
+	}
+	return d.Column, nil
+}
+
+// playerPlaythroughs is the subquery of the playthroughs linked to the
+// player, which replaces the playthroughs table of the queries.
+func playerPlaythroughs(column string, player Player) (string, error) {
+	if !recordIDRegexp.MatchString(player.ID) {
+		return "", fmt.Errorf("invalid record id %q for player %q", player.ID, player.Name)
+	}
+	return fmt.Sprintf(`${1} (select * from playthroughs where JSON_CONTAINS(%s, '"%s"')) p`,
+		quoteIdentifier(column), player.ID), nil
+}
+
+// selectInRange runs the query with only the playthroughs of the player of
+// the range, if any.
+func (r *repository) selectInRange(ctx context.Context, dest any, query string, rg Range, args ...any) error {
+	if rg.Player != "" {
+		rg, err := ResolvePlayer(ctx, r, rg)
+		if err != nil {
+			return err
+		}
+		scoped, err := scopeToPlayer(query, rg.playthroughs)
+		if err != nil {
+			return err
+		}
+		query = scoped
+	}
+	return r.db.SelectContext(ctx, dest, query, args...)
+}
+
+// scopeToPlayer replaces the playthroughs table in the query with the
+// subquery of the playthroughs of the player, so the queries don't need to
+// know about players and bases without a player field keep working.
+// Queries that don't read the table as `playthroughs p` can't be limited
+// to the player, so they fail instead of returning the whole household.
+func scopeToPlayer(query, playthroughs string) (string, error) {
+	if !playthroughsTableRegexp.MatchString(query) {
+		return "", fmt.Errorf("query doesn't read the playthroughs table as `playthroughs p`, so it can't be limited to a player")
+	}
+	return playthroughsTableRegexp.ReplaceAllString(query, playthroughs), nil
+}
+
+// FindPlayer looks up a player by name, ignoring case.
+func FindPlayer(players []Player, name string) (Player, bool) {
+	for _, p := range players {
+		if strings.EqualFold(p.Name, name) {
+			return p, true
+		}
+	}
+	return Player{}, false
+}
+
+// Household has the report of each player for the same year, to compare
+// them side by side.
+type Household struct {
+	Year int `json:"year"`
+	// Playtime is the total playtime of each player, most played first.
+	Playtime []imagegen.MostPlayedByPlaytime `json:"playtime"`
+	Players  []*Report                       `json:"players"`
+}
+
+// CollectHousehold collects the report of every player concurrently.
+func CollectHousehold(ctx context.Context, repo Repository, r Range, defs ...Definition) (*Household, error) {
+	players, err := repo.Players(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ranges := make([]Range, len(players))
+	if len(players) > 0 {
+		column, err := playerColumn(ctx, repo)
+		if err != nil {
+			return nil, err
+		}
+		for i, p := range players {
+			ranges[i] = r.ForPlayer(p.Name)
+			ranges[i].playthroughs, err = playerPlaythroughs(column, p)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	reports := make([]*Report, len(players))
+	g, ctx := errgroup.WithContext(ctx)
+	for i := range players {
+		g.Go(func() (err error) {
+			reports[i], err = Collect(ctx, repo, ranges[i], defs...)
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	household := &Household{Year: r.Year, Players: reports, Playtime: []imagegen.MostPlayedByPlaytime{}}
+	for _, report := range reports {
+		// every playthrough shows up in a single month, so the months add
+		// up to the totals of the year
+		total := imagegen.MostPlayedByPlaytime{Title: report.Player, NoIcon: true}
+		for _, month := range report.BusiestMonths {
+			total.Playtime += month.Playtime
+			total.Count += month.Count
+		}
+		household.Playtime = append(household.Playtime, total)
+	}
+	sort.SliceStable(household.Playtime, func(i, j int) bool {
+		return household.Playtime[i].Playtime > household.Playtime[j].Playtime
+	})
+	return household, nil
+}
+
+// Cards summarizes each player in a fact card, in the same order as
+// Playtime.
+func (h *Household) Cards(l *i18n.Locale) []imagegen.FactCard {
+	reports := map[string]*Report{}
+	for _, report := range h.Players {
+		reports[report.Player] = report
+	}
+	cards := []imagegen.FactCard{}
+	for _, total := range h.Playtime {
+		played := l.N(total.Count, "%s game played", "%s games played")
+		card := imagegen.FactCard{
+			Heading: total.Title,
+			Value:   total.RenderMetric(l),
+			Text:    played,
+		}
+		if report := reports[total.Title]; report != nil && len(report.MostPlayedGames) > 0 {
+			top := report.MostPlayedGames[0]
+			card.Text = l.T("%s, mostly %s", played, top.Title)
+			card.Subject = top.Title
+			card.BoxArt = true
+		}
+		cards = append(cards, card)
+	}
+	return cards
+}
@@ -0,0 +1,99 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// playersRepository answers only the lookups of the players, counting them.
+type playersRepository struct {
+	Repository
+	dimensions int
+	players    int
+}
+
+func (r *playersRepository) Dimensions(ctx context.Context) ([]Dimension, error) {
+	r.dimensions++
+	return []Dimension{{Table: "playthroughs", Column: "player", LinkedTable: "players", LabelColumn: "name"}}, nil
+}
+
+func (r *playersRepository) Players(ctx context.Context) ([]Player, error) {
+	r.players++
+	return []Player{{ID: "rec1", Name: "Alvaro"}, {ID: "rec2", Name: "Bia"}}, nil
+}
+
+func TestResolvePlayer(t *testing.T) {
+	ctx := context.Background()
+	repo := &playersRepository{}
+
+	r, err := ResolvePlayer(ctx, repo, Year(2024).ForPlayer("bia"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(r.playthroughs, `JSON_CONTAINS(`+"`player`"+`, '"rec2"')`) {
+		t.Errorf("playthroughs = %q", r.playthroughs)
+	}
+	if repo.dimensions != 1 || repo.players != 1 {
+		t.Errorf("looked up %d dimensions and %d players, want 1 and 1", repo.dimensions, repo.players)
+	}
+
+	// resolved ranges keep the player in other years without new lookups
+	other, err := ResolvePlayer(ctx, repo, r.InYear(2023))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.playthroughs != r.playthroughs || other.Year != 2023 {
+		t.Errorf("got %+v", other)
+	}
+	if repo.dimensions != 1 || repo.players != 1 {
+		t.Errorf("looked up the player again")
+	}
+
+	if r.ForPlayer("Alvaro").playthroughs != "" {
+		t.Errorf("another player kept the resolved playthroughs")
+	}
+	if r.ForPlayer("Bia").playthroughs == "" {
+		t.Errorf("the same player lost the resolved playthroughs")
+	}
+
+	household, err := ResolvePlayer(ctx, repo, Year(2024))
+	if err != nil || household.playthroughs != "" {
+		t.Errorf("household = %+v, %v", household, err)
+	}
+
+	if _, err := ResolvePlayer(ctx, repo, Year(2024).ForPlayer("Nobody")); !errors.Is(err, ErrUnknownPlayer) {
+		t.Errorf("unknown player error = %v", err)
+	}
+}
+
+func TestScopeToPlayer(t *testing.T) {
+	playthroughs, err := playerPlaythroughs("player", Player{ID: "rec1", Name: "Alvaro"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scoped, err := scopeToPlayer("select count(*) from playthroughs p join games g on g.id = p.game", playthroughs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "select count(*) from (select * from playthroughs where JSON_CONTAINS(`player`, '\"rec1\"')) p join games g on g.id = p.game"
+	if scoped != want {
+		t.Errorf("scoped = %q, want %q", scoped, want)
+	}
+
+	for _, query := range []string{
+		"select count(*) from games",
+		"select count(*) from playthroughs",
+		"select count(*) from playthroughs pt",
+	} {
+		if _, err := scopeToPlayer(query, playthroughs); err == nil {
+			t.Errorf("scopeToPlayer(%q) didn't fail", query)
+		}
+	}
+
+	if _, err := playerPlaythroughs("player", Player{ID: "rec1') or 1=1 --", Name: "Mallory"}); err == nil {
+		t.Errorf("invalid record id was accepted")
+	}
+}
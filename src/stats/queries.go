@@ -0,0 +1,184 @@
+package stats
+
+const (
+	queryMostPlayedGames = `
+		select g.name as title, pt.name as platform, c.name as console, ROUND(p.playtime/(60*60), 0) as playtime
+		from playthroughs p	
+			inner join games g on JSON_CONTAINS(p.games, CONCAT('"', g.record_id, '"'))
+			inner join consoles c on JSON_CONTAINS(p.console, CONCAT('"', c.record_id, '"'))
+			inner join platforms pt on JSON_CONTAINS(g.platforms, CONCAT('"', pt.record_id, '"'))
+		where p.year_start_date = ?
+			and p.status not in ('Abandoned')
+		order by playtime desc;`
+
+	queryGamesByStatus = `
+		select p.status as title, ROUND(sum(p.playtime)/(60*60), 0) as playtime, count(*) as count
+		from playthroughs p	
+		where p.year_start_date = ?
+			and p.status not in ('Playing')
+		group by p.status
+		order by count desc;`
+
+	queryBusiestMonths = `
+		WITH RECURSIVE months AS (
+			SELECT 1 AS m
+			UNION ALL
+			SELECT m + 1 FROM months WHERE m < 12
+		)
+		select 
+			months.m as title, 
+			COALESCE(ROUND(sum(p.playtime)/(60*60), 0), 0) as playtime, 
+			COUNT(p.record_id) as count
+		from months
+		left join playthroughs p on EXTRACT(MONTH from p.start_date) = months.m and p.year_start_date = ?
+		group by months.m
+		order by months.m asc;`
+)
+
+const (
+	queryCompletionRate = `
+		select p.year_start_date as title,
+			SUM(CASE WHEN p.end_date is not null and p.status not in ('Abandoned') THEN 1 ELSE 0 END) as count,
+			count(*) as total
+		from playthroughs p
+		where p.year_start_date <= ?
+			and p.year_start_date is not null
+		group by p.year_start_date
+		order by p.year_start_date asc;`
+
+	queryTimeToBeat = `
+		select p.status as title, AVG(DATEDIFF(p.end_date, p.start_date)) as days, count(*) as count
+		from playthroughs p
+		where p.year_start_date = ?
+			and p.start_date is not null
+			and p.end_date is not null
+		group by p.status
+		order by days desc;`
+
+	queryAbandonmentRate = `
+		select pt.name as title,
+			SUM(CASE WHEN p.status = 'Abandoned' THEN 1 ELSE 0 END) as count,
+			count(*) as total
+		from playthroughs p
+			inner join games g on JSON_CONTAINS(p.games, CONCAT('"', g.record_id, '"'))
+			inner join platforms pt on JSON_CONTAINS(g.platforms, CONCAT('"', pt.record_id, '"'))
+		where p.year_start_date = ?
+		group by pt.name
+		order by count desc;`
+
+	// A playthrough is in the backlog from its start date until it gets an
+	// end date. Abandoned playthroughs without an end date are ignored.
+	queryBacklogAdded = `
+		select EXTRACT(MONTH from p.start_date) as title, count(*) as count
+		from playthroughs p
+		where p.year_start_date = ?
+			and not (p.end_date is null and p.status = 'Abandoned')
+		group by EXTRACT(MONTH from p.start_date);`
+
+	// Only the playthroughs that were carried over or added are finished,
+	// with the same dates as those queries.
+	queryBacklogFinished = `
+		select EXTRACT(MONTH from p.end_date) as title, count(*) as count
+		from playthroughs p
+		where YEAR(p.end_date) = ?
+			and p.start_date is not null
+			and (p.start_date < ? or p.year_start_date = ?)
+		group by EXTRACT(MONTH from p.end_date);`
+
+	queryBacklogCarryOver = `
+		select 'carry_over' as title, count(*) as count
+		from playthroughs p
+		where p.start_date < ?
+			and (p.end_date >= ? or (p.end_date is null and p.status not in ('Abandoned')));`
+)
+
+const (
+	queryPlaythroughsColumns = `
+		select p.record_id as id, g.name as title, COALESCE(s.name, '') as serie,
+			COALESCE(c.name, '') as console, COALESCE(pt.name, '') as platform,
+			COALESCE(p.status, '') as status, ROUND(COALESCE(p.playtime, 0)/(60*60), 1) as playtime,
+			p.year_start_date as year, p.start_date as start_date, p.end_date as end_date
+		from playthroughs p
+			inner join games g on JSON_CONTAINS(p.games, CONCAT('"', g.record_id, '"'))
+			left join serie s on JSON_CONTAINS(g.serie, CONCAT('"', s.record_id, '"'))
+			left join consoles c on JSON_CONTAINS(p.console, CONCAT('"', c.record_id, '"'))
+			left join platforms pt on JSON_CONTAINS(g.platforms, CONCAT('"', pt.record_id, '"'))`
+
+	queryPlaythroughs = queryPlaythroughsColumns + `
+		where p.year_start_date = ?
+		order by p.start_date asc;`
+
+	queryPlaythroughsUntil = queryPlaythroughsColumns + `
+		where p.year_start_date <= ?
+		order by p.start_date asc;`
+)
+
+const (
+	// Each game is counted once per year, with the playtime of all its
+	// playthroughs in the year.
+	queryRatedGamesOfYear = `
+		with rated as (
+			select g.record_id as id, MAX(g.name) as name, MAX(g.rating) as rating,
+				ROUND(sum(p.playtime)/(60*60), 0) as playtime
+			from playthroughs p
+				inner join games g on JSON_CONTAINS(p.games, CONCAT('"', g.record_id, '"'))
+			where p.year_start_date = ?
+				and g.rating is not null
+				and g.rating > 0
+			group by g.record_id
+		)`
+
+	queryAverageRatingByPlatform = queryRatedGamesOfYear + `
+		select pt.name as title, AVG(r.rating) as rating, count(*) as count, sum(r.playtime) as playtime
+		from rated r
+			inner join games g on g.record_id = r.id
+			inner join platforms pt on JSON_CONTAINS(g.platforms, CONCAT('"', pt.record_id, '"'))
+		group by pt.name
+		order by rating desc, count desc;`
+
+	queryAverageRatingBySeries = queryRatedGamesOfYear + `
+		select s.name as title, AVG(r.rating) as rating, count(*) as count, sum(r.playtime) as playtime
+		from rated r
+			inner join games g on g.record_id = r.id
+			inner join serie s on JSON_CONTAINS(g.serie, CONCAT('"', s.record_id, '"'))
+		group by s.name
+		order by rating desc, count desc;`
+
+	queryAverageRatingByYear = `
+		select y.year as title, AVG(y.rating) as rating, count(*) as count, sum(y.playtime) as playtime
+		from (
+			select p.year_start_date as year, g.record_id as id, MAX(g.rating) as rating,
+				ROUND(sum(p.playtime)/(60*60), 0) as playtime
+			from playthroughs p
+				inner join games g on JSON_CONTAINS(p.games, CONCAT('"', g.record_id, '"'))
+			where p.year_start_date <= ?
+				and p.year_start_date is not null
+				and g.rating is not null
+				and g.rating > 0
+			group by p.year_start_date, g.record_id
+		) y
+		group by y.year
+		order by y.year asc;`
+
+	queryRatedGames = queryRatedGamesOfYear + `
+		select r.name as title, r.rating as rating, r.playtime as playtime
+		from rated r
+		order by rating desc, playtime desc;`
+
+	// Hidden gems are games rated 4 stars or more that got less playtime
+	// than the average rated game of the year.
+	queryHiddenGems = queryRatedGamesOfYear + `
+		select r.name as title, r.rating as rating, r.playtime as playtime
+		from rated r
+		where r.rating >= 4
+			and r.playtime < (select AVG(a.playtime) from rated a)
+		order by rating desc, playtime asc;`
+)
+
+const (
+	querySchemaColumns = `
+		select c.table_name as table_name, c.column_name as column_name,
+			COALESCE(c.column_comment, '') as column_comment, COALESCE(c.column_key, '') as column_key
+		from information_schema.columns c
+		where c.table_schema = database();`
+)
@@ -0,0 +1,122 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/imagegen"
+)
+
+// RatingCorrelation tells whether the best rated games of the year were
+// also the most played ones.
+type RatingCorrelation struct {
+	// Coefficient is the Pearson correlation between the rating and the
+	// playtime of each game, from -1 to 1.
+	Coefficient float64 `json:"coefficient"`
+	// ByRating has the average playtime of the games with each star rating.
+	ByRating []imagegen.MostPlayedByPlaytime `json:"by_rating"`
+}
+
+func (r *repository) AverageRatingByPlatform(ctx context.Context, rg Range) ([]imagegen.RatedItem, error) {
+	rows := []imagegen.RatedItem{}
+	if err := r.selectInRange(ctx, &rows, queryAverageRatingByPlatform, rg, rg.String()); err != nil {
+		return nil, fmt.Errorf("failed to query average rating by platform: %v", err)
+	}
+	return rows, nil
+}
+
+func (r *repository) AverageRatingBySeries(ctx context.Context, rg Range) ([]imagegen.RatedItem, error) {
+	rows := []imagegen.RatedItem{}
+	if err := r.selectInRange(ctx, &rows, queryAverageRatingBySeries, rg, rg.String()); err != nil {
+		return nil, fmt.Errorf("failed to query average rating by series: %v", err)
+	}
+	return rows, nil
+}
+
+func (r *repository) AverageRatingByYear(ctx context.Context, rg Range) ([]imagegen.RatedItem, error) {
+	rows := []imagegen.RatedItem{}
+	if err := r.selectInRange(ctx, &rows, queryAverageRatingByYear, rg, rg.String()); err != nil {
+		return nil, fmt.Errorf("failed to query average rating by year: %v", err)
+	}
+	for i := range rows {
+		rows[i].NoIcon = true
+	}
+	return rows, nil
+}
+
+func (r *repository) BestRatedGames(ctx context.Context, rg Range) ([]imagegen.RatedItem, error) {
+	return r.ratedGames(ctx, queryRatedGames, rg)
+}
+
+func (r *repository) HiddenGems(ctx context.Context, rg Range) ([]imagegen.RatedItem, error) {
+	return r.ratedGames(ctx, queryHiddenGems, rg)
+}
+
+func (r *repository) ratedGames(ctx context.Context, query string, rg Range) ([]imagegen.RatedItem, error) {
+	rows := []imagegen.RatedItem{}
+	if err := r.selectInRange(ctx, &rows, query, rg, rg.String()); err != nil {
+		return nil, fmt.Errorf("failed to query rated games: %v", err)
+	}
+	for i := range rows {
+		rows[i].BoxArt = true
+	}
+	return rows, nil
+}
+
+func (r *repository) RatingVsPlaytime(ctx context.Context, rg Range) (RatingCorrelation, error) {
+	games := []imagegen.RatedItem{}
+	if err := r.selectInRange(ctx, &games, queryRatedGames, rg, rg.String()); err != nil {
+		return RatingCorrelation{}, fmt.Errorf("failed to query rating vs playtime: %v", err)
+	}
+	return CorrelateRatings(games), nil
+}
+
+// CorrelateRatings computes the correlation between rating and playtime of
+// the given games, grouping them by their rating rounded to whole stars.
+func CorrelateRatings(games []imagegen.RatedItem) RatingCorrelation {
+	correlation := RatingCorrelation{ByRating: []imagegen.MostPlayedByPlaytime{}}
+
+	playtimes := map[int][]float64{}
+	for _, g := range games {
+		stars := int(math.Round(g.Rating))
+		playtimes[stars] = append(playtimes[stars], g.Playtime)
+	}
+	for stars := 5; stars >= 1; stars-- {
+		if len(playtimes[stars]) == 0 {
+			continue
+		}
+		total := 0.0
+		for _, p := range playtimes[stars] {
+			total += p
+		}
+		correlation.ByRating = append(correlation.ByRating, imagegen.MostPlayedByPlaytime{
+			Title:    imagegen.StarRating(float64(stars)),
+			Playtime: total / float64(len(playtimes[stars])),
+			Count:    len(playtimes[stars]),
+			NoIcon:   true,
+		})
+	}
+
+	if len(games) < 2 {
+		return correlation
+	}
+	var sumX, sumY float64
+	for _, g := range games {
+		sumX += g.Rating
+		sumY += g.Playtime
+	}
+	meanX, meanY := sumX/float64(len(games)), sumY/float64(len(games))
+	var cov, varX, varY float64
+	for _, g := range games {
+		dx, dy := g.Rating-meanX, g.Playtime-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return correlation
+	}
+	correlation.Coefficient = math.Round(cov/math.Sqrt(varX*varY)*100) / 100
+	return correlation
+}
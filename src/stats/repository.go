@@ -0,0 +1,227 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/imagegen"
+	"github.com/jmoiron/sqlx"
+)
+
+// selecter is the minimal query interface shared by *sqlx.DB and the
+// in-process engine, so all queries are written only once.
+type selecter interface {
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+}
+
+type repository struct {
+	db selecter
+}
+
+var _ Repository = &repository{}
+
+// NewSQLRepository returns a Repository that queries a MySQL compatible
+// server, like the one started by cmd/server.
+func NewSQLRepository(db *sqlx.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) MostPlayedConsoles(ctx context.Context, rg Range) ([]imagegen.MostPlayedByPlaytime, error) {
+	return r.PlaytimeBy(ctx, DimensionConsoles, rg)
+}
+
+func (r *repository) MostPlayedPlatforms(ctx context.Context, rg Range) ([]imagegen.MostPlayedByPlaytime, error) {
+	return r.PlaytimeBy(ctx, DimensionPlatforms, rg)
+}
+
+func (r *repository) MostPlayedGames(ctx context.Context, rg Range) ([]imagegen.MostPlayedGame, error) {
+	rows := []imagegen.MostPlayedGame{}
+	if err := r.selectInRange(ctx, &rows, queryMostPlayedGames, rg, rg.String()); err != nil {
+		return nil, fmt.Errorf("failed to query most played games: %v", err)
+	}
+	return rows, nil
+}
+
+func (r *repository) MostPlayedSeries(ctx context.Context, rg Range) ([]imagegen.MostPlayedByPlaytime, error) {
+	return r.PlaytimeBy(ctx, DimensionSeries, rg)
+}
+
+func (r *repository) GamesByStatus(ctx context.Context, rg Range) ([]imagegen.MostPlayedByNumGames, error) {
+	rows := []imagegen.MostPlayedByNumGames{}
+	if err := r.selectInRange(ctx, &rows, queryGamesByStatus, rg, rg.String()); err != nil {
+		return nil, fmt.Errorf("failed to query games by status: %v", err)
+	}
+	return rows, nil
+}
+
+func (r *repository) BusiestMonths(ctx context.Context, rg Range) ([]imagegen.MostPlayedByPlaytime, error) {
+	rows := []imagegen.MostPlayedByPlaytime{}
+	if err := r.selectInRange(ctx, &rows, queryBusiestMonths, rg, rg.String()); err != nil {
+		return nil, fmt.Errorf("failed to query busiest months: %v", err)
+	}
+	for i, d := range rows {
+		monthNum, _ := strconv.ParseInt(d.Title, 10, 64)
+		rows[i].Title = time.Month(int(monthNum)).String()
+		rows[i].Month = time.Month(int(monthNum))
+		rows[i].NoIcon = true
+	}
+	return rows, nil
+}
+
+func (r *repository) CompletionRate(ctx context.Context, rg Range) ([]imagegen.ShareOfGames, error) {
+	rows := []imagegen.ShareOfGames{}
+	if err := r.selectInRange(ctx, &rows, queryCompletionRate, rg, rg.String()); err != nil {
+		return nil, fmt.Errorf("failed to query completion rate: %v", err)
+	}
+	return rows, nil
+}
+
+func (r *repository) TimeToBeat(ctx context.Context, rg Range) ([]imagegen.AverageDuration, error) {
+	rows := []imagegen.AverageDuration{}
+	if err := r.selectInRange(ctx, &rows, queryTimeToBeat, rg, rg.String()); err != nil {
+		return nil, fmt.Errorf("failed to query time to beat: %v", err)
+	}
+	return rows, nil
+}
+
+func (r *repository) AbandonmentRate(ctx context.Context, rg Range) ([]imagegen.ShareOfGames, error) {
+	rows := []imagegen.ShareOfGames{}
+	if err := r.selectInRange(ctx, &rows, queryAbandonmentRate, rg, rg.String()); err != nil {
+		return nil, fmt.Errorf("failed to query abandonment rate: %v", err)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Rate() > rows[j].Rate()
+	})
+	return rows, nil
+}
+
+type monthCount struct {
+	Title string `db:"title"`
+	Count int    `db:"count"`
+}
+
+func (r *repository) Backlog(ctx context.Context, rg Range) ([]imagegen.BacklogMonth, error) {
+	startOfYear := fmt.Sprintf("%d-01-01", rg.Year)
+	carryOver := []monthCount{}
+	if err := r.selectInRange(ctx, &carryOver, queryBacklogCarryOver, rg, startOfYear, startOfYear); err != nil {
+		return nil, fmt.Errorf("failed to query backlog carry over: %v", err)
+	}
+	added := []monthCount{}
+	if err := r.selectInRange(ctx, &added, queryBacklogAdded, rg, rg.String()); err != nil {
+		return nil, fmt.Errorf("failed to query backlog added: %v", err)
+	}
+	finished := []monthCount{}
+	if err := r.selectInRange(ctx, &finished, queryBacklogFinished, rg, rg.String(), startOfYear, rg.String()); err != nil {
+		return nil, fmt.Errorf("failed to query backlog finished: %v", err)
+	}
+	open := 0
+	if len(carryOver) > 0 {
+		open = carryOver[0].Count
+	}
+	return backlogMonths(open, added, finished), nil
+}
+
+// backlogMonths adds up the backlog of each month, from the playthroughs
+// open at the start of the year and the ones added and finished each month.
+func backlogMonths(open int, added, finished []monthCount) []imagegen.BacklogMonth {
+	rows := make([]imagegen.BacklogMonth, 12)
+	for i := range rows {
+		rows[i].Month = time.Month(i + 1).String()
+	}
+	for _, mc := range added {
+		if m, err := strconv.Atoi(mc.Title); err == nil && m >= 1 && m <= 12 {
+			rows[m-1].Added = mc.Count
+		}
+	}
+	for _, mc := range finished {
+		if m, err := strconv.Atoi(mc.Title); err == nil && m >= 1 && m <= 12 {
+			rows[m-1].Finished = mc.Count
+		}
+	}
+	for i := range rows {
+		// a playthrough finished in an earlier month than the one it was
+		// added in, like one with the dates swapped, can't take the
+		// backlog below empty
+		open = max(open+rows[i].Added-rows[i].Finished, 0)
+		rows[i].Open = open
+	}
+	return rows
+}
+
+func (r *repository) Playthroughs(ctx context.Context, rg Range) ([]imagegen.Playthrough, error) {
+	rows := []imagegen.Playthrough{}
+	if err := r.selectInRange(ctx, &rows, queryPlaythroughs, rg, rg.String()); err != nil {
+		return nil, fmt.Errorf("failed to query playthroughs: %v", err)
+	}
+	return uniquePlaythroughs(rows), nil
+}
+
+func (r *repository) PlaythroughsUntil(ctx context.Context, rg Range) ([]imagegen.Playthrough, error) {
+	rows := []imagegen.Playthrough{}
+	if err := r.selectInRange(ctx, &rows, queryPlaythroughsUntil, rg, rg.String()); err != nil {
+		return nil, fmt.Errorf("failed to query playthroughs: %v", err)
+	}
+	return uniquePlaythroughs(rows), nil
+}
+
+// uniquePlaythroughs removes the duplicated rows from games linked to more
+// than one platform or serie, keeping the first one.
+func uniquePlaythroughs(rows []imagegen.Playthrough) []imagegen.Playthrough {
+	seen := map[string]bool{}
+	unique := []imagegen.Playthrough{}
+	for _, row := range rows {
+		if seen[row.ID] {
+			continue
+		}
+		seen[row.ID] = true
+		unique = append(unique, row)
+	}
+	return unique
+}
+
+func (r *repository) Custom(ctx context.Context, def Definition, rg Range) ([]imagegen.MostPlayedByPlaytime, error) {
+	args := make([]any, placeholders(def.Query))
+	for i := range args {
+		args[i] = rg.String()
+	}
+	rows := []imagegen.MostPlayedByPlaytime{}
+	if err := r.selectInRange(ctx, &rows, def.Query, rg, args...); err != nil {
+		return nil, fmt.Errorf("failed to query %s: %v", def.ID, err)
+	}
+	return rows, nil
+}
+
+// placeholders counts the ? of the query that are parameters, leaving out
+// the ones in strings, quoted names and comments, like in LIKE '%?%'.
+func placeholders(query string) int {
+	count := 0
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '?':
+			count++
+		case c == '\'', c == '"', c == '`':
+			// quotes are escaped by a backslash, or by doubling them,
+			// which closes and reopens the string
+			for i++; i < len(query) && query[i] != c; i++ {
+				if query[i] == '\\' && c != '`' {
+					i++
+				}
+			}
+		case c == '#', c == '-' && strings.HasPrefix(query[i:], "-- "):
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return count
+			}
+			i += end + 3
+		}
+	}
+	return count
+}
@@ -0,0 +1,55 @@
+package stats
+
+import "testing"
+
+func TestPlaceholders(t *testing.T) {
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"select * from playthroughs p where p.year_start_date = ?", 1},
+		{"select ? as a, ? as b", 2},
+		{"select * from games where name like '%?%' and year = ?", 1},
+		{`select * from games where name = "what?" and year = ?`, 1},
+		{"select `weird?column` from games where year = ?", 1},
+		{`select * from games where name = 'it''s ?' and year = ?`, 1},
+		{`select * from games where name = 'a\'?' and year = ?`, 1},
+		{"select * from games -- why?\nwhere year = ?", 1},
+		{"select * from games # why?\nwhere year = ?", 1},
+		{"select * from games /* why? */ where year = ?", 1},
+		{"select * from games /* unterminated ?", 0},
+		{"select 1-?", 1},
+		{"select 1", 0},
+	}
+	for _, tt := range tests {
+		if got := placeholders(tt.query); got != tt.want {
+			t.Errorf("placeholders(%q) = %d, want %d", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestBacklogMonths(t *testing.T) {
+	added := []monthCount{{Title: "1", Count: 3}, {Title: "3", Count: 1}, {Title: "", Count: 5}}
+	finished := []monthCount{{Title: "2", Count: 2}, {Title: "3", Count: 4}, {Title: "13", Count: 1}}
+	rows := backlogMonths(1, added, finished)
+	if len(rows) != 12 {
+		t.Fatalf("got %d months, want 12", len(rows))
+	}
+	want := []struct{ added, finished, open int }{
+		{3, 0, 4}, {0, 2, 2}, {1, 4, 0}, {0, 0, 0},
+	}
+	for i, w := range want {
+		got := rows[i]
+		if got.Added != w.added || got.Finished != w.finished || got.Open != w.open {
+			t.Errorf("%s = +%d -%d open %d, want +%d -%d open %d", got.Month, got.Added, got.Finished, got.Open, w.added, w.finished, w.open)
+		}
+	}
+	if rows[0].Month != "January" || rows[11].Month != "December" {
+		t.Errorf("months are %s to %s", rows[0].Month, rows[11].Month)
+	}
+	for _, row := range rows {
+		if row.Open < 0 {
+			t.Errorf("%s has %d open", row.Month, row.Open)
+		}
+	}
+}
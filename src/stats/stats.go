@@ -0,0 +1,298 @@
+package stats
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/imagegen"
+	"golang.org/x/sync/errgroup"
+)
+
+// Range selects which playthroughs are aggregated by a query.
+type Range struct {
+	Year int
+	// Player limits the playthroughs to a single profile by name. Empty
+	// means the whole household.
+	Player string
+
+	// playthroughs replaces the playthroughs table of the queries once the
+	// player is resolved, see ResolvePlayer.
+	playthroughs string
+}
+
+func Year(year int) Range {
+	return Range{Year: year}
+}
+
+func CurrentYear() Range {
+	return Year(time.Now().Year())
+}
+
+func (r Range) ForPlayer(player string) Range {
+	if !strings.EqualFold(player, r.Player) {
+		r.playthroughs = ""
+	}
+	r.Player = player
+	return r
+}
+
+// InYear is the same range in another year, keeping the resolved player.
+func (r Range) InYear(year int) Range {
+	r.Year = year
+	return r
+}
+
+func (r Range) String() string {
+	return strconv.Itoa(r.Year)
+}
+
+// Repository exposes the gaming journal stats as typed results, so callers
+// don't need to know about the underlying SQL.
+type Repository interface {
+	MostPlayedConsoles(ctx context.Context, r Range) ([]imagegen.MostPlayedByPlaytime, error)
+	MostPlayedPlatforms(ctx context.Context, r Range) ([]imagegen.MostPlayedByPlaytime, error)
+	MostPlayedGames(ctx context.Context, r Range) ([]imagegen.MostPlayedGame, error)
+	MostPlayedSeries(ctx context.Context, r Range) ([]imagegen.MostPlayedByPlaytime, error)
+	GamesByStatus(ctx context.Context, r Range) ([]imagegen.MostPlayedByNumGames, error)
+	BusiestMonths(ctx context.Context, r Range) ([]imagegen.MostPlayedByPlaytime, error)
+	CompletionRate(ctx context.Context, r Range) ([]imagegen.ShareOfGames, error)
+	TimeToBeat(ctx context.Context, r Range) ([]imagegen.AverageDuration, error)
+	AbandonmentRate(ctx context.Context, r Range) ([]imagegen.ShareOfGames, error)
+	Backlog(ctx context.Context, r Range) ([]imagegen.BacklogMonth, error)
+	AverageRatingByPlatform(ctx context.Context, r Range) ([]imagegen.RatedItem, error)
+	AverageRatingBySeries(ctx context.Context, r Range) ([]imagegen.RatedItem, error)
+	// AverageRatingByYear returns the average rating of all years up to the
+	// range.
+	AverageRatingByYear(ctx context.Context, r Range) ([]imagegen.RatedItem, error)
+	BestRatedGames(ctx context.Context, r Range) ([]imagegen.RatedItem, error)
+	HiddenGems(ctx context.Context, r Range) ([]imagegen.RatedItem, error)
+	RatingVsPlaytime(ctx context.Context, r Range) (RatingCorrelation, error)
+	Playthroughs(ctx context.Context, r Range) ([]imagegen.Playthrough, error)
+	// PlaythroughsUntil returns the playthroughs of all years up to the range.
+	PlaythroughsUntil(ctx context.Context, r Range) ([]imagegen.Playthrough, error)
+	Custom(ctx context.Context, def Definition, r Range) ([]imagegen.MostPlayedByPlaytime, error)
+	// Dimensions discovers the link fields on games and playthroughs from
+	// the schema.
+	Dimensions(ctx context.Context) ([]Dimension, error)
+	PlaytimeBy(ctx context.Context, d Dimension, r Range) ([]imagegen.MostPlayedByPlaytime, error)
+	// Players lists the profiles from the table linked by the player field
+	// of playthroughs, or none when there is no such field.
+	Players(ctx context.Context) ([]Player, error)
+}
+
+type Report struct {
+	Year               int                             `json:"year"`
+	Player             string                          `json:"player,omitempty"`
+	MostPlayedConsoles []imagegen.MostPlayedByPlaytime `json:"most_played_consoles"`
+	MostPlayedPlatform []imagegen.MostPlayedByPlaytime `json:"most_played_platforms"`
+	MostPlayedGames    []imagegen.MostPlayedGame       `json:"most_played_games"`
+	MostPlayedSeries   []imagegen.MostPlayedByPlaytime `json:"most_played_series"`
+	GamesByStatus      []imagegen.MostPlayedByNumGames `json:"games_by_status"`
+	BusiestMonths      []imagegen.MostPlayedByPlaytime `json:"busiest_months"`
+	CompletionRate     []imagegen.ShareOfGames         `json:"completion_rate"`
+	TimeToBeat         []imagegen.AverageDuration      `json:"time_to_beat"`
+	AbandonmentRate    []imagegen.ShareOfGames         `json:"abandonment_rate"`
+	Backlog            []imagegen.BacklogMonth         `json:"backlog"`
+	BurnDown           []imagegen.BurnDownPoint        `json:"burn_down"`
+
+	AverageRatingByPlatform []imagegen.RatedItem `json:"average_rating_by_platform"`
+	AverageRatingBySeries   []imagegen.RatedItem `json:"average_rating_by_series"`
+	AverageRatingByYear     []imagegen.RatedItem `json:"average_rating_by_year"`
+	BestRatedGames          []imagegen.RatedItem `json:"best_rated_games"`
+	HiddenGems              []imagegen.RatedItem `json:"hidden_gems"`
+	RatingVsPlaytime        RatingCorrelation    `json:"rating_vs_playtime"`
+
+	Custom map[string][]imagegen.MostPlayedByPlaytime `json:"custom,omitempty"`
+}
+
+// Icons lists the logos and box art the charts of the report need, in the
+// order they first appear.
+func (r *Report) Icons(defs ...Definition) []imagegen.IconRef {
+	refs := []imagegen.IconRef{}
+	seen := map[imagegen.IconRef]bool{}
+	add := func(name string, boxArt bool) {
+		ref := imagegen.IconRef{Name: name, BoxArt: boxArt}
+		if name == "" || seen[ref] {
+			return
+		}
+		seen[ref] = true
+		refs = append(refs, ref)
+	}
+	addPlaytime := func(rows []imagegen.MostPlayedByPlaytime) {
+		for _, row := range rows {
+			if !row.NoIcon {
+				add(row.Title, row.BoxArt)
+			}
+		}
+	}
+	addRated := func(rows []imagegen.RatedItem) {
+		for _, row := range rows {
+			if !row.NoIcon {
+				add(row.Title, row.BoxArt)
+			}
+		}
+	}
+
+	addPlaytime(r.MostPlayedConsoles)
+	addPlaytime(r.MostPlayedPlatform)
+	for _, game := range r.MostPlayedGames {
+		add(game.Title, true)
+	}
+	addPlaytime(r.MostPlayedSeries)
+	addPlaytime(r.BusiestMonths)
+	addRated(r.AverageRatingByPlatform)
+	addRated(r.AverageRatingBySeries)
+	addRated(r.BestRatedGames)
+	addRated(r.HiddenGems)
+	for _, def := range defs {
+		if def.Metric == MetricCount || def.Icon == IconNone {
+			continue
+		}
+		for _, row := range r.Custom[def.ID] {
+			add(row.Title, def.Icon == IconBoxArt)
+		}
+	}
+	return refs
+}
+
+// Collect runs all the queries from the repository concurrently, including
+// the given user defined stats.
+func Collect(ctx context.Context, repo Repository, r Range, defs ...Definition) (*Report, error) {
+	r, err := ResolvePlayer(ctx, repo, r)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Year: r.Year, Player: r.Player}
+
+	g, ctx := errgroup.WithContext(ctx)
+
+	g.Go(func() (err error) {
+		report.MostPlayedConsoles, err = repo.MostPlayedConsoles(ctx, r)
+		return err
+	})
+
+	g.Go(func() (err error) {
+		report.MostPlayedPlatform, err = repo.MostPlayedPlatforms(ctx, r)
+		return err
+	})
+
+	g.Go(func() (err error) {
+		report.MostPlayedGames, err = repo.MostPlayedGames(ctx, r)
+		return err
+	})
+
+	g.Go(func() (err error) {
+		report.MostPlayedSeries, err = repo.MostPlayedSeries(ctx, r)
+		return err
+	})
+
+	g.Go(func() (err error) {
+		report.GamesByStatus, err = repo.GamesByStatus(ctx, r)
+		return err
+	})
+
+	g.Go(func() (err error) {
+		report.BusiestMonths, err = repo.BusiestMonths(ctx, r)
+		return err
+	})
+
+	g.Go(func() (err error) {
+		report.CompletionRate, err = repo.CompletionRate(ctx, r)
+		return err
+	})
+
+	g.Go(func() (err error) {
+		report.TimeToBeat, err = repo.TimeToBeat(ctx, r)
+		return err
+	})
+
+	g.Go(func() (err error) {
+		report.AbandonmentRate, err = repo.AbandonmentRate(ctx, r)
+		return err
+	})
+
+	g.Go(func() (err error) {
+		report.Backlog, err = repo.Backlog(ctx, r)
+		return err
+	})
+
+	g.Go(func() (err error) {
+		report.AverageRatingByPlatform, err = repo.AverageRatingByPlatform(ctx, r)
+		return err
+	})
+
+	g.Go(func() (err error) {
+		report.AverageRatingBySeries, err = repo.AverageRatingBySeries(ctx, r)
+		return err
+	})
+
+	g.Go(func() (err error) {
+		report.AverageRatingByYear, err = repo.AverageRatingByYear(ctx, r)
+		return err
+	})
+
+	g.Go(func() (err error) {
+		report.BestRatedGames, err = repo.BestRatedGames(ctx, r)
+		return err
+	})
+
+	g.Go(func() (err error) {
+		report.HiddenGems, err = repo.HiddenGems(ctx, r)
+		return err
+	})
+
+	g.Go(func() (err error) {
+		report.RatingVsPlaytime, err = repo.RatingVsPlaytime(ctx, r)
+		return err
+	})
+
+	custom := make([][]imagegen.MostPlayedByPlaytime, len(defs))
+	for i, def := range defs {
+		g.Go(func() (err error) {
+			custom[i], err = repo.Custom(ctx, def, r)
+			return err
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	report.BurnDown = BurnDown(report.Backlog)
+
+	if len(defs) > 0 {
+		report.Custom = map[string][]imagegen.MostPlayedByPlaytime{}
+		for i, def := range defs {
+			report.Custom[def.ID] = custom[i]
+		}
+	}
+	return report, nil
+}
+
+// BurnDown derives a burn-down series from the monthly backlog: the scope is
+// everything that was in the backlog at some point of the year, and each
+// month shows how much of it is still open, next to a linear ideal pace.
+func BurnDown(backlog []imagegen.BacklogMonth) []imagegen.BurnDownPoint {
+	if len(backlog) == 0 {
+		return []imagegen.BurnDownPoint{}
+	}
+	first := backlog[0]
+	scope := first.Open - first.Added + first.Finished
+	for _, bm := range backlog {
+		scope += bm.Added
+	}
+
+	points := make([]imagegen.BurnDownPoint, len(backlog))
+	finished := 0
+	for i, bm := range backlog {
+		finished += bm.Finished
+		points[i] = imagegen.BurnDownPoint{
+			Month:     bm.Month,
+			Remaining: scope - finished,
+			Ideal:     float64(scope) * (1 - float64(i+1)/float64(len(backlog))),
+		}
+	}
+	return points
+}
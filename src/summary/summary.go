@@ -0,0 +1,72 @@
+// Package summary collects the hero card of a year, from its stats and
+// highlights.
+package summary
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/highlights"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/i18n"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/imagegen"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/stats"
+)
+
+// Build collects the summary of the range, with the best scored highlight,
+// in the language.
+func Build(ctx context.Context, repo stats.Repository, r stats.Range, l *i18n.Locale) (imagegen.Summary, error) {
+	yearStr := r.String()
+	s := imagegen.Summary{Title: l.T("My %s in games", yearStr)}
+	if r.Player != "" {
+		s.Title = l.T("%s's %s in games", r.Player, yearStr)
+	}
+
+	r, err := stats.ResolvePlayer(ctx, repo, r)
+	if err != nil {
+		return s, err
+	}
+	games, err := repo.Playthroughs(ctx, r)
+	if err != nil {
+		return s, fmt.Errorf("failed to query playthroughs for %s: %v", yearStr, err)
+	}
+	s.Played = len(games)
+	for _, g := range games {
+		s.Hours += g.Playtime
+		if g.EndDate != nil && g.Status != "Abandoned" {
+			s.Beaten++
+		}
+	}
+
+	topGames, err := repo.MostPlayedGames(ctx, r)
+	if err != nil {
+		return s, err
+	}
+	if len(topGames) > 0 {
+		s.TopGame = topGames[0]
+	}
+	consoles, err := repo.MostPlayedConsoles(ctx, r)
+	if err != nil {
+		return s, err
+	}
+	if len(consoles) > 0 {
+		s.TopConsole = consoles[0]
+	}
+	months, err := repo.BusiestMonths(ctx, r)
+	if err != nil {
+		return s, err
+	}
+	for _, m := range months {
+		if m.Playtime > s.BusiestMonth.Playtime {
+			s.BusiestMonth = m
+		}
+	}
+
+	facts, err := highlights.DefaultEngine().Top(ctx, repo, r, l, 1)
+	if err != nil {
+		return s, fmt.Errorf("failed to compute highlights for %s: %v", yearStr, err)
+	}
+	if len(facts) > 0 {
+		s.Highlight = facts[0].Card()
+	}
+	return s, nil
+}
@@ -0,0 +1,92 @@
+// Package yearbook prints the wrapped of a year as a PDF, with a cover,
+// the highlights, every chart and the list of the games played.
+package yearbook
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/highlights"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/i18n"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/imagegen"
+	"github.com/alvarowolfx/gamer-journal-wrapped/src/stats"
+)
+
+// Render draws the yearbook of the range on a new document, with the theme
+// and language of the options. The canvas defaults to A4 pages.
+func Render(ctx context.Context, repo stats.Repository, r stats.Range, defs []stats.Definition, opts imagegen.Options) (*imagegen.Document, error) {
+	if opts.Locale == nil {
+		opts.Locale = i18n.English
+	}
+	l := opts.Locale
+	yearStr := r.String()
+	r, err := stats.ResolvePlayer(ctx, repo, r)
+	if err != nil {
+		return nil, err
+	}
+	report, err := stats.Collect(ctx, repo, r, defs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stats for %s: %v", yearStr, err)
+	}
+	facts, err := highlights.DefaultEngine().Top(ctx, repo, r, l, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute highlights for %s: %v", yearStr, err)
+	}
+	games, err := repo.Playthroughs(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query playthroughs for %s: %v", yearStr, err)
+	}
+
+	title := l.T("Gaming yearbook")
+	docTitle := title + " " + yearStr
+	if r.Player != "" {
+		docTitle = l.T("%s of %s", docTitle, r.Player)
+	}
+	doc := imagegen.NewDocument(docTitle)
+	opts.Document = doc
+	if opts.Canvas.Width == 0 {
+		opts.Canvas = imagegen.CanvasA4
+	}
+
+	imagegen.RenderCover(title, yearStr, coverLines(r, games, l), opts)
+
+	cards := highlights.Cards(facts)
+	n := 6
+	if opts.Canvas.Portrait() {
+		n = 5
+	}
+	if len(cards) > 0 {
+		imagegen.RenderFactCards(ctx, l.T("Highlights of %s", yearStr), cards[:min(n, len(cards))], opts)
+	}
+
+	for _, chart := range report.Charts(r, l, defs...) {
+		if len(chart.Items) == 0 {
+			continue
+		}
+		imagegen.RenderMostPlayedWrapped(ctx, chart.Title, chart.Items, chart.Limit, opts)
+	}
+
+	imagegen.RenderGameList(l.T("Games played in %s", yearStr), games, opts)
+	return doc, nil
+}
+
+func coverLines(r stats.Range, games []imagegen.Playthrough, l *i18n.Locale) []string {
+	hours := 0.0
+	finished := 0
+	for _, g := range games {
+		hours += g.Playtime
+		if g.EndDate != nil && g.Status != "Abandoned" {
+			finished++
+		}
+	}
+	lines := []string{}
+	if r.Player != "" {
+		lines = append(lines, r.Player)
+	}
+	return append(lines,
+		l.N(len(games), "%s game played", "%s games played"),
+		l.N(int(math.Round(hours)), "%s hour", "%s hours"),
+		l.N(finished, "%s game finished", "%s games finished"),
+	)
+}